@@ -1,41 +1,92 @@
 package main
 
 import (
+	"api-gateway/internal/config"
 	"api-gateway/internal/middleware"
 	"api-gateway/internal/proxy"
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"shared/logging"
+	"shared/metrics"
+	sharedmiddleware "shared/middleware"
+	"shared/tracing"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	logging.Init("api-gateway")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found")
+		slog.Warn(".env file not found")
+	}
+
+	// Load gateway config, falling back to the service URL env vars
+	cfg := config.Default()
+	configPath := os.Getenv("GATEWAY_CONFIG")
+	if configPath != "" {
+		loaded, err := config.Load(configPath)
+		if err != nil {
+			log.Fatalf("Failed to load gateway config: %v", err)
+		}
+		cfg = loaded
+		slog.Info("Loaded gateway config", "path", configPath)
+	}
+
+	// Export spans when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Init(context.Background(), "api-gateway")
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
 	}
 
 	// Create router
 	router := mux.NewRouter()
 
 	// Add middleware
-	router.Use(middleware.CORSMiddleware)
-	router.Use(middleware.LoggingMiddleware)
-	router.Use(middleware.RateLimitMiddleware)
+	cors := middleware.NewCORS(cfg.CORS)
+	router.Use(cors.Middleware)
+	router.Use(sharedmiddleware.RequestIDMiddleware)
 
 	// Initialize proxy router
-	proxyRouter := proxy.NewProxyRouter()
+	proxyRouter, err := proxy.NewProxyRouter(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize proxy router: %v", err)
+	}
+	defer proxyRouter.Close()
 
-	// Setup service routes
-	// Store management routes
-	router.PathPrefix("/api").Handler(proxyRouter.ProxyRequest("store-service"))
+	// Name spans and label access logs and request metrics by the
+	// configured route
+	router.Use(tracing.Middleware(proxyRouter))
+	router.Use(middleware.LoggingMiddleware(proxyRouter))
+	router.Use(metrics.Middleware(proxyRouter))
 
-	// Other service routes
-	router.PathPrefix("/api").Handler(proxyRouter.ProxyRequest("product-service"))
-	router.PathPrefix("/api").Handler(proxyRouter.ProxyRequest("order-service"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Rate limits run after the token is verified, so they can key on the
+	// user, but before requests are rejected, so bad tokens are counted
+	// against the client's address
+	store, err := middleware.NewRateLimitStore(cfg.RateLimit)
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limit store: %v", err)
+	}
+	limiter := middleware.NewRateLimiter(cfg.RateLimit, proxyRouter, store)
+	go limiter.Run(ctx)
+
+	// Verify tokens once here and forward signed identity headers
+	auth, err := middleware.NewAuthFromEnv(ctx, proxyRouter)
+	if err != nil {
+		log.Fatalf("Failed to initialize auth middleware: %v", err)
+	}
 
 	// Health check endpoint
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -43,12 +94,55 @@ func main() {
 		fmt.Fprintf(w, "API Gateway is healthy")
 	})
 
-	// Start server
+	// Prometheus metrics
+	router.Handle("/metrics", metrics.Handler())
+
+	// Service routes are resolved by the proxy's route table
+	router.PathPrefix("/api").Handler(auth.Identify(limiter.Middleware(auth.Require(proxyRouter))))
+
+	// Reload config on SIGHUP or file change
+	if configPath != "" {
+		watcher := config.NewWatcher(configPath, cfg)
+		watcher.OnReload(func(cfg *config.Config) {
+			if err := proxyRouter.Update(cfg); err != nil {
+				slog.Error("Failed to apply reloaded routes", "error", err)
+			}
+			cors.Update(cfg.CORS)
+			limiter.Update(cfg.RateLimit)
+		})
+		go watcher.Run(ctx)
+	}
+
+	// Get port
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8000"
 	}
 
-	log.Printf("API Gateway starting on port %s\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, router))
+	// Create server
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	// Handle graceful shutdown
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	// Start server
+	go func() {
+		slog.Info("API Gateway starting", "port", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	// Wait for stop signal
+	<-stop
+	slog.Info("Shutting down server")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	server.Shutdown(shutdownCtx)
+	shutdownTracing(shutdownCtx)
 }
@@ -0,0 +1,434 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes everything the gateway needs to route and police
+// traffic. It is loaded from a YAML or JSON file and can be reloaded at
+// runtime; a Config value is never mutated once loaded.
+type Config struct {
+	Services  map[string]ServiceConfig `yaml:"services" json:"services"`
+	Routes    []RouteConfig            `yaml:"routes" json:"routes"`
+	CORS      CORSConfig               `yaml:"cors" json:"cors"`
+	RateLimit RateLimitConfig          `yaml:"rate_limit" json:"rate_limit"`
+}
+
+// Load balancing strategies for services with several instances.
+const (
+	RoundRobin       = "round_robin"
+	LeastConnections = "least_connections"
+)
+
+// ServiceConfig is an upstream service the gateway can forward to. A
+// service runs either at a single URL or at a list of Instances that the
+// gateway balances across.
+type ServiceConfig struct {
+	URL            string               `yaml:"url" json:"url"`
+	Instances      []string             `yaml:"instances" json:"instances"`
+	Strategy       string               `yaml:"strategy" json:"strategy"`
+	Timeout        Duration             `yaml:"timeout" json:"timeout"`
+	HealthCheck    HealthCheckConfig    `yaml:"health_check" json:"health_check"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker" json:"circuit_breaker"`
+	Retry          RetryConfig          `yaml:"retry" json:"retry"`
+}
+
+// HealthCheckConfig controls active probing of service instances. An
+// instance leaves rotation after UnhealthyThreshold failed probes in a row
+// and rejoins after HealthyThreshold successful ones. Only a 2xx response
+// counts as a success.
+type HealthCheckConfig struct {
+	Path               string   `yaml:"path" json:"path"`
+	Interval           Duration `yaml:"interval" json:"interval"`
+	Timeout            Duration `yaml:"timeout" json:"timeout"`
+	UnhealthyThreshold int      `yaml:"unhealthy_threshold" json:"unhealthy_threshold"`
+	HealthyThreshold   int      `yaml:"healthy_threshold" json:"healthy_threshold"`
+}
+
+// CircuitBreakerConfig controls when the gateway stops forwarding to a
+// failing service. The circuit opens after FailureThreshold consecutive
+// failures, stays open for OpenTimeout and then lets HalfOpenRequests trial
+// requests through.
+type CircuitBreakerConfig struct {
+	FailureThreshold int      `yaml:"failure_threshold" json:"failure_threshold"`
+	OpenTimeout      Duration `yaml:"open_timeout" json:"open_timeout"`
+	HalfOpenRequests int      `yaml:"half_open_requests" json:"half_open_requests"`
+}
+
+// RetryConfig controls retries of idempotent requests. Attempts includes
+// the first try; the wait between tries doubles from Backoff up to
+// MaxBackoff.
+type RetryConfig struct {
+	Attempts   int      `yaml:"attempts" json:"attempts"`
+	Backoff    Duration `yaml:"backoff" json:"backoff"`
+	MaxBackoff Duration `yaml:"max_backoff" json:"max_backoff"`
+}
+
+// Endpoints returns every instance URL of the service.
+func (s ServiceConfig) Endpoints() []string {
+	if len(s.Instances) > 0 {
+		return s.Instances
+	}
+	if s.URL != "" {
+		return []string{s.URL}
+	}
+	return nil
+}
+
+// WithDefaults fills in the strategy, health check, circuit breaker and
+// retry settings left empty in the config file.
+func (s ServiceConfig) WithDefaults() ServiceConfig {
+	if s.Strategy == "" {
+		s.Strategy = RoundRobin
+	}
+	hc := &s.HealthCheck
+	if hc.Path == "" {
+		hc.Path = "/health"
+	}
+	if hc.Interval == 0 {
+		hc.Interval = Duration(10 * time.Second)
+	}
+	if hc.Timeout == 0 {
+		hc.Timeout = Duration(2 * time.Second)
+	}
+	if hc.UnhealthyThreshold == 0 {
+		hc.UnhealthyThreshold = 3
+	}
+	if hc.HealthyThreshold == 0 {
+		hc.HealthyThreshold = 2
+	}
+
+	cb := &s.CircuitBreaker
+	if cb.FailureThreshold == 0 {
+		cb.FailureThreshold = 5
+	}
+	if cb.OpenTimeout == 0 {
+		cb.OpenTimeout = Duration(30 * time.Second)
+	}
+	if cb.HalfOpenRequests == 0 {
+		cb.HalfOpenRequests = 1
+	}
+
+	retry := &s.Retry
+	if retry.Attempts == 0 {
+		retry.Attempts = 1
+	}
+	if retry.Backoff == 0 {
+		retry.Backoff = Duration(100 * time.Millisecond)
+	}
+	if retry.MaxBackoff == 0 {
+		retry.MaxBackoff = Duration(time.Second)
+	}
+	return s
+}
+
+// Access levels a route can require.
+const (
+	AccessPublic        = "public"
+	AccessAuthenticated = "authenticated"
+	AccessAdmin         = "admin"
+)
+
+// RouteConfig maps a path prefix to a service. A "*" segment in the prefix
+// matches any single path segment. Methods, when set, limits the route to
+// those HTTP methods. Access is public, authenticated (the default) or
+// admin. Timeout, when set, overrides the service's timeout for requests
+// on this route, and RateLimit replaces the default rate limit policy.
+type RouteConfig struct {
+	Prefix    string           `yaml:"prefix" json:"prefix"`
+	Methods   []string         `yaml:"methods" json:"methods"`
+	Service   string           `yaml:"service" json:"service"`
+	Access    string           `yaml:"access" json:"access"`
+	Timeout   Duration         `yaml:"timeout" json:"timeout"`
+	RateLimit *RateLimitPolicy `yaml:"rate_limit" json:"rate_limit"`
+}
+
+// AccessLevel returns the route's access level, defaulting to
+// authenticated.
+func (r RouteConfig) AccessLevel() string {
+	if r.Access == "" {
+		return AccessAuthenticated
+	}
+	return r.Access
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"`
+}
+
+// Rate limit stores: where request counts are kept.
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+// Rate limit keys: what identifies a client for rate limiting.
+const (
+	RateLimitByIP     = "ip"
+	RateLimitByUser   = "user"
+	RateLimitByAPIKey = "api_key"
+)
+
+// RateLimitPolicy allows Requests per Window. The memory store enforces it
+// as a token bucket that refills completely over Window, so clients may
+// burst up to Requests; the Redis store counts requests over a sliding
+// Window.
+type RateLimitPolicy struct {
+	Requests int      `yaml:"requests" json:"requests"`
+	Window   Duration `yaml:"window" json:"window"`
+}
+
+// RateLimitConfig holds the default policy and how clients are told apart.
+// Key is ip (default), user or api_key; user and api_key fall back to the
+// client IP for requests without a user or key. X-Forwarded-For is only
+// honoured for requests arriving from TrustedProxies. Buckets idle for
+// longer than IdleTimeout are evicted.
+//
+// Store is memory (default), limiting each replica on its own, or redis,
+// sharing counters between replicas through the server at RedisURL. The
+// store is chosen at startup and is not switched on reload.
+type RateLimitConfig struct {
+	RateLimitPolicy `yaml:",inline"`
+	Key             string   `yaml:"key" json:"key"`
+	APIKeyHeader    string   `yaml:"api_key_header" json:"api_key_header"`
+	TrustedProxies  []string `yaml:"trusted_proxies" json:"trusted_proxies"`
+	IdleTimeout     Duration `yaml:"idle_timeout" json:"idle_timeout"`
+	Store           string   `yaml:"store" json:"store"`
+	RedisURL        string   `yaml:"redis_url" json:"redis_url"`
+	KeyPrefix       string   `yaml:"key_prefix" json:"key_prefix"`
+}
+
+// WithDefaults fills in the key settings left empty in the config file.
+func (c RateLimitConfig) WithDefaults() RateLimitConfig {
+	if c.Key == "" {
+		c.Key = RateLimitByIP
+	}
+	if c.APIKeyHeader == "" {
+		c.APIKeyHeader = "X-API-Key"
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = Duration(10 * time.Minute)
+	}
+	if c.Store == "" {
+		c.Store = RateLimitStoreMemory
+	}
+	if c.KeyPrefix == "" {
+		c.KeyPrefix = "ratelimit:"
+	}
+	return c
+}
+
+func (p RateLimitPolicy) validate() error {
+	if p.Requests <= 0 || p.Window <= 0 {
+		return fmt.Errorf("needs positive requests and window")
+	}
+	return nil
+}
+
+// Duration is a time.Duration that is written as "5s" or "1m" in config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %w", err)
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Default builds the configuration used when no config file is given,
+// taking upstream URLs from the STORE_SERVICE_URL, PRODUCT_SERVICE_URL and
+// ORDER_SERVICE_URL environment variables. Rate limits are shared through
+// Redis when RATE_LIMIT_REDIS_URL is set.
+func Default() *Config {
+	cfg := &Config{
+		Services: map[string]ServiceConfig{
+			"store-service":   {URL: os.Getenv("STORE_SERVICE_URL"), Timeout: Duration(30 * time.Second)},
+			"product-service": {URL: os.Getenv("PRODUCT_SERVICE_URL"), Timeout: Duration(30 * time.Second)},
+			"order-service":   {URL: os.Getenv("ORDER_SERVICE_URL"), Timeout: Duration(30 * time.Second)},
+		},
+		Routes: []RouteConfig{
+			{Prefix: "/api/store-owners", Service: "store-service"},
+			{Prefix: "/api/stores", Service: "store-service"},
+			{Prefix: "/api/stores/*/products", Service: "product-service"},
+			{Prefix: "/api/products", Service: "product-service"},
+			{Prefix: "/api/products", Methods: []string{"GET"}, Service: "product-service", Access: AccessPublic},
+			{Prefix: "/api/orders", Service: "order-service"},
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:3000"}, // Next.js frontend
+		},
+		RateLimit: RateLimitConfig{
+			RateLimitPolicy: RateLimitPolicy{
+				Requests: 100,
+				Window:   Duration(time.Minute),
+			},
+		},
+	}
+	if redisURL := os.Getenv("RATE_LIMIT_REDIS_URL"); redisURL != "" {
+		cfg.RateLimit.Store = RateLimitStoreRedis
+		cfg.RateLimit.RedisURL = redisURL
+	}
+	return cfg
+}
+
+// Load reads and validates a config file. Files ending in .json are parsed
+// as JSON, anything else as YAML. ${VAR} references are expanded from the
+// environment before parsing.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return Parse(data, strings.EqualFold(filepath.Ext(path), ".json"))
+}
+
+// Parse decodes and validates config data.
+func Parse(data []byte, isJSON bool) (*Config, error) {
+	data = []byte(os.ExpandEnv(string(data)))
+
+	var cfg Config
+	if isJSON {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	} else {
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that every route points at a known service with a usable
+// URL and that the rate limit policy is sensible.
+func (c *Config) Validate() error {
+	if len(c.Routes) == 0 {
+		return fmt.Errorf("config has no routes")
+	}
+
+	for name, svc := range c.Services {
+		if svc.URL != "" && len(svc.Instances) > 0 {
+			return fmt.Errorf("service %q sets both url and instances", name)
+		}
+		endpoints := svc.Endpoints()
+		if len(endpoints) == 0 {
+			return fmt.Errorf("service %q has no url or instances", name)
+		}
+		for _, endpoint := range endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("service %q has an invalid url %q", name, endpoint)
+			}
+		}
+		switch svc.Strategy {
+		case "", RoundRobin, LeastConnections:
+		default:
+			return fmt.Errorf("service %q has unknown strategy %q", name, svc.Strategy)
+		}
+		if svc.Timeout < 0 || svc.HealthCheck.Interval < 0 || svc.HealthCheck.Timeout < 0 {
+			return fmt.Errorf("service %q has a negative duration", name)
+		}
+		if svc.HealthCheck.UnhealthyThreshold < 0 || svc.HealthCheck.HealthyThreshold < 0 {
+			return fmt.Errorf("service %q has a negative health check threshold", name)
+		}
+		cb := svc.CircuitBreaker
+		if cb.FailureThreshold < 0 || cb.HalfOpenRequests < 0 || cb.OpenTimeout < 0 {
+			return fmt.Errorf("service %q has a negative circuit breaker setting", name)
+		}
+		if svc.Retry.Attempts < 0 || svc.Retry.Backoff < 0 || svc.Retry.MaxBackoff < 0 {
+			return fmt.Errorf("service %q has a negative retry setting", name)
+		}
+	}
+
+	for i, route := range c.Routes {
+		if !strings.HasPrefix(route.Prefix, "/") {
+			return fmt.Errorf("route %d: prefix %q must start with /", i, route.Prefix)
+		}
+		if _, ok := c.Services[route.Service]; !ok {
+			return fmt.Errorf("route %d: unknown service %q", i, route.Service)
+		}
+		if route.Timeout < 0 {
+			return fmt.Errorf("route %d: negative timeout", i)
+		}
+		switch route.AccessLevel() {
+		case AccessPublic, AccessAuthenticated, AccessAdmin:
+		default:
+			return fmt.Errorf("route %d: unknown access level %q", i, route.Access)
+		}
+		if route.RateLimit != nil {
+			if err := route.RateLimit.validate(); err != nil {
+				return fmt.Errorf("route %d: rate_limit %v", i, err)
+			}
+		}
+	}
+
+	if err := c.RateLimit.validate(); err != nil {
+		return fmt.Errorf("rate_limit %v", err)
+	}
+	switch c.RateLimit.Key {
+	case "", RateLimitByIP, RateLimitByUser, RateLimitByAPIKey:
+	default:
+		return fmt.Errorf("rate_limit has unknown key %q", c.RateLimit.Key)
+	}
+	for _, proxy := range c.RateLimit.TrustedProxies {
+		if _, err := ParseNetwork(proxy); err != nil {
+			return fmt.Errorf("rate_limit trusted proxy %q: %w", proxy, err)
+		}
+	}
+	if c.RateLimit.IdleTimeout < 0 {
+		return fmt.Errorf("rate_limit has a negative idle_timeout")
+	}
+	switch c.RateLimit.Store {
+	case "", RateLimitStoreMemory:
+	case RateLimitStoreRedis:
+		if c.RateLimit.RedisURL == "" {
+			return fmt.Errorf("rate_limit store redis needs a redis_url")
+		}
+	default:
+		return fmt.Errorf("rate_limit has unknown store %q", c.RateLimit.Store)
+	}
+	return nil
+}
+
+// ParseNetwork parses a CIDR such as "10.0.0.0/8" or a single IP address.
+func ParseNetwork(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher reloads a config file on SIGHUP or when the file changes and
+// hands every successfully validated config to its subscribers. A config
+// that fails to load is logged and discarded, leaving the last good one in
+// place.
+type Watcher struct {
+	path string
+
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(*Config)
+}
+
+func NewWatcher(path string, initial *Config) *Watcher {
+	return &Watcher{path: path, current: initial}
+}
+
+// Current returns the last config that loaded successfully.
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// OnReload registers fn to be called with each newly loaded config.
+func (w *Watcher) OnReload(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload loads the config file and, if it is valid, publishes it.
+func (w *Watcher) Reload() error {
+	cfg, err := Load(w.path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.current = cfg
+	for _, fn := range w.subscribers {
+		fn(cfg)
+	}
+	return nil
+}
+
+// Run blocks until ctx is cancelled, reloading on SIGHUP and on writes to
+// the config file. The file's directory is watched rather than the file
+// itself so that atomic replaces (and Kubernetes ConfigMap symlink swaps)
+// are picked up.
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Warn("Config file watching disabled", "error", err)
+	} else {
+		defer fsw.Close()
+		if err := fsw.Add(filepath.Dir(w.path)); err != nil {
+			slog.Warn("Config file watching disabled", "error", err)
+		} else {
+			events, errs = fsw.Events, fsw.Errors
+		}
+	}
+
+	// Editors and ConfigMap updates produce bursts of events; wait for
+	// them to settle before reloading.
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.reload("SIGHUP")
+		case event := <-events:
+			if w.affects(event) {
+				debounce.Reset(100 * time.Millisecond)
+			}
+		case <-debounce.C:
+			w.reload("file change")
+		case err := <-errs:
+			slog.Warn("Config watcher error", "error", err)
+		}
+	}
+}
+
+func (w *Watcher) affects(event fsnotify.Event) bool {
+	if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	return name == filepath.Base(w.path) || name == "..data"
+}
+
+func (w *Watcher) reload(trigger string) {
+	if err := w.Reload(); err != nil {
+		slog.Error("Config reload rejected, keeping previous config", "trigger", trigger, "error", err)
+		return
+	}
+	slog.Info("Config reloaded", "trigger", trigger, "path", w.path)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const validConfig = `
+services:
+  store-service:
+    url: http://stores:8001
+    timeout: 5s
+routes:
+  - prefix: /api/stores
+    service: store-service
+cors:
+  allowed_origins: [http://localhost:3000]
+rate_limit:
+  requests: 10
+  window: 1m
+`
+
+// badConfig routes to a service that is not declared.
+const badConfig = `
+services:
+  store-service:
+    url: http://stores:8001
+routes:
+  - prefix: /api/orders
+    service: order-service
+rate_limit:
+  requests: 10
+  window: 1m
+`
+
+func TestWatcherKeepsLastGoodConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.yaml")
+	if err := os.WriteFile(path, []byte(validConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	initial, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	w := NewWatcher(path, initial)
+	reloads := 0
+	w.OnReload(func(*Config) { reloads++ })
+
+	if err := os.WriteFile(path, []byte(badConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err == nil {
+		t.Fatal("Reload accepted a route to an unknown service")
+	}
+	if w.Current() != initial || reloads != 0 {
+		t.Fatal("rejected config replaced the current one")
+	}
+
+	if err := os.WriteFile(path, []byte(validConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if w.Current() == initial || reloads != 1 {
+		t.Fatal("valid config was not published")
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"services": {"order-service": {"url": "http://orders:8003", "timeout": "2s"}},
+		"routes": [{"prefix": "/api/orders", "service": "order-service"}],
+		"rate_limit": {"requests": 5, "window": "10s"}
+	}`), true)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := cfg.Services["order-service"].Timeout; got != Duration(2e9) {
+		t.Errorf("timeout = %v, want 2s", got)
+	}
+}
@@ -0,0 +1,28 @@
+// Package metrics records the gateway's upstream metrics. Request metrics
+// come from shared/metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	upstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_upstream_errors_total",
+		Help: "Failed upstream attempts and rejected requests, by service and reason.",
+	}, []string{"service", "reason"})
+)
+
+// Upstream error reasons.
+const (
+	ReasonTimeout    = "timeout"
+	ReasonConnection = "connection"
+	ReasonStatus5xx  = "status_5xx"
+)
+
+// UpstreamError counts a failed attempt to reach service. Retried attempts
+// are counted individually.
+func UpstreamError(service, reason string) {
+	upstreamErrors.WithLabelValues(service, reason).Inc()
+}
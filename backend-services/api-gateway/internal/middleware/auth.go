@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"api-gateway/internal/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"shared/auth"
+	"shared/logging"
+	"shared/response"
+	"strings"
+)
+
+// Identity headers the gateway sets on requests it forwards. Any values a
+// client sends for them are removed first. The proxy's request signature
+// covers them, so services can trust them.
+const (
+	HeaderUserID    = "X-User-ID"
+	HeaderUserEmail = "X-User-Email"
+	HeaderUserRole  = "X-User-Role"
+	HeaderStoreID   = "X-Store-ID"
+)
+
+var identityHeaders = []string{
+	HeaderUserID,
+	HeaderUserEmail,
+	HeaderUserRole,
+	HeaderStoreID,
+}
+
+// AccessPolicy reports which access level a request needs.
+type AccessPolicy interface {
+	AccessLevel(method, path string) string
+}
+
+// Auth verifies bearer tokens once at the gateway and forwards the caller's
+// identity to services in headers.
+type Auth struct {
+	verifier *auth.Verifier
+	policy   AccessPolicy
+}
+
+func NewAuth(verifier *auth.Verifier, policy AccessPolicy) *Auth {
+	return &Auth{verifier: verifier, policy: policy}
+}
+
+// NewAuthFromEnv builds an Auth from the token settings read by
+// auth.NewVerifierFromEnv.
+func NewAuthFromEnv(ctx context.Context, policy AccessPolicy) (*Auth, error) {
+	verifier, err := auth.NewVerifierFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuth(verifier, policy), nil
+}
+
+// identity is what Identify learnt about the caller.
+type identity struct {
+	claims *auth.Claims
+	err    error
+}
+
+type identityKey struct{}
+
+// Middleware verifies the caller and rejects requests their access level
+// does not allow.
+func (a *Auth) Middleware(next http.Handler) http.Handler {
+	return a.Identify(a.Require(next))
+}
+
+// Identify verifies the bearer token, if any, and sets the identity
+// headers. It never rejects a request, so that a rate limiter placed
+// between Identify and Require counts requests with bad tokens against the
+// client's address.
+func (a *Auth) Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, header := range identityHeaders {
+			r.Header.Del(header)
+		}
+
+		claims, err := a.authenticate(r)
+		if claims != nil {
+			logging.Add(r.Context(), "user_id", claims.ID)
+			a.setIdentity(r, *claims)
+		}
+		ctx := context.WithValue(r.Context(), identityKey{}, identity{claims: claims, err: err})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Require rejects requests whose route needs a caller Identify could not
+// verify.
+func (a *Auth) Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := r.Context().Value(identityKey{}).(identity)
+		access := a.policy.AccessLevel(r.Method, r.URL.Path)
+		if access == config.AccessPublic {
+			// Public routes are served anonymously when the token is bad.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if id.err != nil {
+			slog.WarnContext(r.Context(), "Rejected token", "method", r.Method, "path", r.URL.Path, "error", id.err)
+			response.Error(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+		if id.claims == nil {
+			response.Error(w, http.StatusUnauthorized, "Authorization header required")
+			return
+		}
+		if access == config.AccessAdmin && id.claims.Role != auth.RoleAdmin {
+			response.Error(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate returns the caller's claims, or nil if no bearer token was
+// sent.
+func (a *Auth) authenticate(r *http.Request) (*auth.Claims, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, fmt.Errorf("malformed Authorization header")
+	}
+
+	claims, err := a.verifier.Verify(r.Context(), parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (a *Auth) setIdentity(r *http.Request, claims auth.Claims) {
+	r.Header.Set(HeaderUserID, claims.ID)
+	r.Header.Set(HeaderUserEmail, claims.Email)
+	r.Header.Set(HeaderUserRole, claims.Role)
+	r.Header.Set(HeaderStoreID, claims.StoreID)
+}
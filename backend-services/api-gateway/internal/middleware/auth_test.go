@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"api-gateway/internal/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+type staticPolicy map[string]string
+
+func (p staticPolicy) AccessLevel(method, path string) string {
+	return p[path]
+}
+
+func newTestAuth(t *testing.T) (*Auth, func(claims auth.Claims) string) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, _ := jwk.New(pub)
+	pubKey.Set(jwk.KeyIDKey, "test-key")
+	pubKey.Set(jwk.AlgorithmKey, jwa.EdDSA)
+	privKey, _ := jwk.New(priv)
+	privKey.Set(jwk.KeyIDKey, "test-key")
+	keys, err := auth.NewKeySet("", pubKey, 0)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	verifier := auth.NewVerifier(keys, auth.DefaultIssuer, auth.DefaultAudience)
+
+	sign := func(claims auth.Claims) string {
+		token := jwt.New()
+		token.Set("id", claims.ID)
+		token.Set("email", claims.Email)
+		token.Set("role", claims.Role)
+		token.Set(jwt.IssuerKey, auth.DefaultIssuer)
+		token.Set(jwt.AudienceKey, auth.DefaultAudience)
+		token.Set(jwt.ExpirationKey, time.Now().Add(time.Hour))
+		signed, err := jwt.Sign(token, jwa.EdDSA, privKey)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return string(signed)
+	}
+
+	policy := staticPolicy{
+		"/public":  config.AccessPublic,
+		"/private": config.AccessAuthenticated,
+		"/admin":   config.AccessAdmin,
+	}
+	return NewAuth(verifier, policy), sign
+}
+
+func TestAuthAccessLevels(t *testing.T) {
+	gw, sign := newTestAuth(t)
+	customer := sign(auth.Claims{ID: "u1", Email: "u1@example.com", Role: "user"})
+	admin := sign(auth.Claims{ID: "a1", Email: "a1@example.com", Role: "admin"})
+
+	tests := []struct {
+		path  string
+		token string
+		want  int
+	}{
+		{"/public", "", http.StatusOK},
+		{"/public", "garbage", http.StatusOK},
+		{"/private", "", http.StatusUnauthorized},
+		{"/private", "garbage", http.StatusUnauthorized},
+		{"/private", customer, http.StatusOK},
+		{"/admin", customer, http.StatusForbidden},
+		{"/admin", admin, http.StatusOK},
+	}
+
+	handler := gw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s with token %.10q: status = %d, want %d", tt.path, tt.token, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestAuthForwardsIdentity(t *testing.T) {
+	gw, sign := newTestAuth(t)
+	claims := auth.Claims{ID: "u1", Email: "u1@example.com", Role: "user"}
+
+	var got http.Header
+	handler := gw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+
+	// Identity headers sent by the client are dropped, not forwarded.
+	req := httptest.NewRequest(http.MethodGet, "/public", nil)
+	req.Header.Set(HeaderUserID, "spoofed")
+	req.Header.Set(HeaderUserRole, "admin")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got.Get(HeaderUserID) != "" || got.Get(HeaderUserRole) != "" {
+		t.Fatalf("client identity headers forwarded: %v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/private", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(claims))
+	req.Header.Set(HeaderUserID, "spoofed")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.Get(HeaderUserID) != "u1" || got.Get(HeaderUserEmail) != "u1@example.com" || got.Get(HeaderUserRole) != "user" {
+		t.Fatalf("identity headers = %v", got)
+	}
+}
+
+func TestBadTokensAreRateLimited(t *testing.T) {
+	gw, sign := newTestAuth(t)
+	l, _ := newTestLimiter(config.RateLimitConfig{
+		RateLimitPolicy: config.RateLimitPolicy{Requests: 3, Window: config.Duration(time.Minute)},
+		Key:             config.RateLimitByUser,
+	})
+	h := gw.Identify(l.Middleware(gw.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))))
+	bad := map[string]string{"Authorization": "Bearer garbage"}
+
+	for i := 0; i < 3; i++ {
+		if rec := serve(h, http.MethodGet, "/private", "1.2.3.4:1", bad); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("bad token %d: status = %d, want 401", i, rec.Code)
+		}
+	}
+	if rec := serve(h, http.MethodGet, "/private", "1.2.3.4:1", bad); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("bad token after the limit: status = %d, want 429", rec.Code)
+	}
+
+	// A verified user from the same address has a bucket of their own.
+	user := map[string]string{"Authorization": "Bearer " + sign(auth.Claims{ID: "u1", Role: "user"})}
+	if rec := serve(h, http.MethodGet, "/private", "1.2.3.4:1", user); rec.Code != http.StatusOK {
+		t.Errorf("verified user: status = %d, want 200", rec.Code)
+	}
+}
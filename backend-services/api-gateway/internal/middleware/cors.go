@@ -1,13 +1,45 @@
 package middleware
 
-import "net/http"
+import (
+	"api-gateway/internal/config"
+	"net/http"
+	"sync/atomic"
+)
 
-func CORSMiddleware(next http.Handler) http.Handler {
+// CORS answers preflight requests and sets CORS headers for the origins
+// listed in the gateway config. "*" allows any origin.
+type CORS struct {
+	origins atomic.Pointer[map[string]bool]
+}
+
+func NewCORS(cfg config.CORSConfig) *CORS {
+	c := &CORS{}
+	c.Update(cfg)
+	return c
+}
+
+// Update replaces the allowed origins.
+func (c *CORS) Update(cfg config.CORSConfig) {
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		origins[origin] = true
+	}
+	c.origins.Store(&origins)
+}
+
+func (c *CORS) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000") // Next.js frontend
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Gateway-Secret, X-Gateway-Service")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		origins := *c.origins.Load()
+		origin := r.Header.Get("Origin")
+
+		w.Header().Add("Vary", "Origin")
+		if origin != "" && (origins[origin] || origins["*"]) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Gateway-Service, X-API-Key, X-Request-ID, traceparent, tracestate")
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Expose-Headers", "X-RateLimit-Limit, X-RateLimit-Remaining, Retry-After, X-Request-ID")
+		}
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
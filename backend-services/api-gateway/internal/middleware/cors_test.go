@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"api-gateway/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	cors := NewCORS(config.CORSConfig{AllowedOrigins: []string{"https://shop.example.com"}})
+	h := cors.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("preflight reached the next handler")
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/orders", nil)
+	req.Header.Set("Origin", "https://shop.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://shop.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+
+	allowed := rec.Header().Get("Access-Control-Allow-Headers")
+	for _, header := range []string{"Authorization", "X-Request-ID", "traceparent", "tracestate", "X-API-Key"} {
+		if !strings.Contains(allowed, header) {
+			t.Errorf("Access-Control-Allow-Headers = %q, missing %s", allowed, header)
+		}
+	}
+	if exposed := rec.Header().Get("Access-Control-Expose-Headers"); !strings.Contains(exposed, "X-Request-ID") {
+		t.Errorf("Access-Control-Expose-Headers = %q, missing X-Request-ID", exposed)
+	}
+}
+
+func TestCORSUnknownOrigin(t *testing.T) {
+	cors := NewCORS(config.CORSConfig{AllowedOrigins: []string{"https://shop.example.com"}})
+	req := httptest.NewRequest(http.MethodOptions, "/api/orders", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec := httptest.NewRecorder()
+	cors.Middleware(http.NotFoundHandler()).ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
@@ -1,23 +1,33 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	sharedmiddleware "shared/middleware"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+// LoggingMiddleware writes an access log line for each request. Only the
+// path is logged: query strings may carry personal data.
+func LoggingMiddleware(routes sharedmiddleware.RouteTemplates) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := sharedmiddleware.NewResponseRecorder(w)
 
-		next.ServeHTTP(w, r)
+			next.ServeHTTP(rw, r)
 
-		log.Printf(
-			"%s %s %s %v",
-			r.Method,
-			r.RequestURI,
-			r.RemoteAddr,
-			time.Since(start),
-		)
-	})
-}
\ No newline at end of file
+			slog.InfoContext(r.Context(), "request",
+				"method", r.Method,
+				"route", sharedmiddleware.Route(routes, r),
+				"path", r.URL.Path,
+				"status", rw.Status,
+				"bytes", rw.Bytes,
+				"latency_ms", time.Since(start).Milliseconds(),
+				"remote_addr", r.RemoteAddr,
+			)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"shared/logging"
+	sharedmiddleware "shared/middleware"
+	"strings"
+	"testing"
+)
+
+type noRoutes struct{}
+
+func (noRoutes) RouteTemplate(method, path string) string { return "" }
+
+func TestLoggingMiddlewareAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(&buf, slog.LevelInfo)))
+	defer slog.SetDefault(previous)
+
+	gw, sign := newTestAuth(t)
+	token := sign(auth.Claims{ID: "u1", Email: "u1@example.com", Role: "user"})
+
+	h := sharedmiddleware.RequestIDMiddleware(LoggingMiddleware(noRoutes{})(gw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "hello")
+	}))))
+
+	req := httptest.NewRequest(http.MethodPost, "/private?email=u1@example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(sharedmiddleware.HeaderRequestID, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	if strings.Contains(line, token) || strings.Contains(line, "u1@example.com") {
+		t.Fatalf("log line leaks token or email: %s", line)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v: %s", err, line)
+	}
+	want := map[string]interface{}{
+		"msg":        "request",
+		"request_id": "req-1",
+		"user_id":    "u1",
+		"path":       "/private",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+}
@@ -1,44 +1,186 @@
 package middleware
 
 import (
+	"api-gateway/internal/config"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
-	"sync"
+	"net/netip"
+	"shared/response"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
+// RoutePolicies reports the rate limit policy of the route serving a
+// request, if it overrides the default.
+type RoutePolicies interface {
+	RateLimitPolicy(method, path string) (string, *config.RateLimitPolicy)
+}
+
+// evicter is implemented by stores that must drop idle counters
+// themselves rather than relying on key expiry.
+type evicter interface {
+	Evict(idle time.Duration)
+}
+
+// rateLimitSettings is the part of the config a request needs, swapped
+// atomically on reload.
+type rateLimitSettings struct {
+	config.RateLimitConfig
+	trusted []netip.Prefix
+}
+
+// RateLimiter enforces limits per client and route, keeping the counts in
+// a RateLimitStore.
 type RateLimiter struct {
-	requests map[string][]time.Time
-	mutex    sync.Mutex
+	settings atomic.Pointer[rateLimitSettings]
+	routes   RoutePolicies
+	store    RateLimitStore
 }
 
-var limiter = &RateLimiter{
-	requests: make(map[string][]time.Time),
+func NewRateLimiter(cfg config.RateLimitConfig, routes RoutePolicies, store RateLimitStore) *RateLimiter {
+	l := &RateLimiter{routes: routes, store: store}
+	l.Update(cfg)
+	return l
 }
 
-func RateLimitMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		limiter.mutex.Lock()
-		defer limiter.mutex.Unlock()
+// NewRateLimitStore builds the store named in the config.
+func NewRateLimitStore(cfg config.RateLimitConfig) (RateLimitStore, error) {
+	cfg = cfg.WithDefaults()
+	if cfg.Store != config.RateLimitStoreRedis {
+		return NewMemoryStore(), nil
+	}
 
-		ip := r.RemoteAddr
-		now := time.Now()
-		window := now.Add(-time.Minute)
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate limit redis_url: %w", err)
+	}
+	return NewRedisStore(redis.NewClient(opts), cfg.KeyPrefix), nil
+}
 
-		requests := limiter.requests[ip]
-		validRequests := make([]time.Time, 0)
+// Update replaces the rate limit settings. Existing counters are kept, so
+// clients are measured against the new limits straight away.
+func (l *RateLimiter) Update(cfg config.RateLimitConfig) {
+	cfg = cfg.WithDefaults()
+	settings := &rateLimitSettings{RateLimitConfig: cfg}
+	for _, proxy := range cfg.TrustedProxies {
+		if prefix, err := config.ParseNetwork(proxy); err == nil {
+			settings.trusted = append(settings.trusted, prefix)
+		}
+	}
+	l.settings.Store(settings)
+}
+
+// Run evicts idle counters until ctx is cancelled, if the store needs it.
+func (l *RateLimiter) Run(ctx context.Context) {
+	store, ok := l.store.(evicter)
+	if !ok {
+		return
+	}
+	for {
+		idle := time.Duration(l.settings.Load().IdleTimeout)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(idle / 2):
+			store.Evict(idle)
+		}
+	}
+}
+
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings := l.settings.Load()
 
-		for _, req := range requests {
-			if req.After(window) {
-				validRequests = append(validRequests, req)
+		policy := settings.RateLimitPolicy
+		key := l.clientKey(settings, r)
+		if l.routes != nil {
+			if route, routePolicy := l.routes.RateLimitPolicy(r.Method, r.URL.Path); routePolicy != nil {
+				policy = *routePolicy
+				key = route + "|" + key
 			}
 		}
 
-		if len(validRequests) >= 100 { // 100 requests per minute
-			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+		decision, err := l.store.Take(r.Context(), key, policy)
+		if err != nil {
+			// An unreachable store must not take the API down with it.
+			slog.WarnContext(r.Context(), "Rate limit store unavailable, allowing request", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(policy.Requests))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		if !decision.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+			response.Error(w, http.StatusTooManyRequests, "Rate limit exceeded")
 			return
 		}
 
-		limiter.requests[ip] = append(validRequests, now)
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+
+// clientKey identifies the caller according to the configured key.
+func (l *RateLimiter) clientKey(settings *rateLimitSettings, r *http.Request) string {
+	switch settings.Key {
+	case config.RateLimitByUser:
+		// Set by the auth middleware from a verified token only.
+		if userID := r.Header.Get(HeaderUserID); userID != "" {
+			return "user:" + userID
+		}
+	case config.RateLimitByAPIKey:
+		if apiKey := r.Header.Get(settings.APIKeyHeader); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "key:" + hex.EncodeToString(sum[:8])
+		}
+	}
+	return "ip:" + clientIP(r, settings.trusted)
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only
+// trusted when the request came from a trusted proxy, and then only up to
+// the first hop that is not itself a trusted proxy.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !isTrusted(remote, trusted) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		if !isTrusted(hop, trusted) {
+			return hop.String()
+		}
+		host = hop.String()
+	}
+	return host
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"api-gateway/internal/config"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript counts a request against a sliding window
+// approximated from two fixed windows: the previous window's count is
+// weighted by how much of it still overlaps the sliding window. It runs
+// atomically in Redis and uses the server clock so that every gateway
+// replica sees the same windows.
+//
+// KEYS[1] key prefix; ARGV[1] limit; ARGV[2] window in milliseconds.
+// Returns {allowed, remaining, retry_after_ms}.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local index = math.floor(now / window)
+local elapsed = now - index * window
+
+local current_key = KEYS[1] .. ':' .. index
+local previous_key = KEYS[1] .. ':' .. (index - 1)
+local current = tonumber(redis.call('GET', current_key) or '0')
+local previous = tonumber(redis.call('GET', previous_key) or '0')
+
+local weight = (window - elapsed) / window
+local count = previous * weight + current
+
+if count + 1 > limit then
+	local retry_after = window - elapsed
+	if current + 1 <= limit and previous > 0 then
+		-- Wait until enough of the previous window has slid out.
+		local excess = count + 1 - limit
+		retry_after = math.ceil(excess * window / previous)
+	end
+	return {0, 0, retry_after}
+end
+
+current = redis.call('INCR', current_key)
+redis.call('PEXPIRE', current_key, window * 2)
+return {1, math.floor(limit - (previous * weight + current)), 0}
+`)
+
+// RedisStore shares sliding-window counters between gateway replicas
+// through any server speaking the Redis protocol.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Take counts a request for key against the policy's sliding window.
+func (s *RedisStore) Take(ctx context.Context, key string, policy config.RateLimitPolicy) (RateLimitDecision, error) {
+	window := time.Duration(policy.Window).Milliseconds()
+	result, err := slidingWindowScript.Run(ctx, s.client, []string{s.prefix + key}, policy.Requests, window).Int64Slice()
+	if err != nil {
+		return RateLimitDecision{}, fmt.Errorf("rate limit script: %w", err)
+	}
+	if len(result) != 3 {
+		return RateLimitDecision{}, fmt.Errorf("rate limit script returned %d values", len(result))
+	}
+
+	remaining := int(result[1])
+	if remaining < 0 {
+		remaining = 0
+	}
+	return RateLimitDecision{
+		Allowed:    result[0] == 1,
+		Remaining:  remaining,
+		RetryAfter: time.Duration(result[2]) * time.Millisecond,
+	}, nil
+}
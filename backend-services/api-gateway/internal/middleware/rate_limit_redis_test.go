@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"api-gateway/internal/config"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisStores(t *testing.T) (*miniredis.Miniredis, *RedisStore, *RedisStore) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	// Two gateway replicas, each with its own connection.
+	newStore := func() *RedisStore {
+		client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return NewRedisStore(client, "ratelimit:")
+	}
+	return srv, newStore(), newStore()
+}
+
+func TestRedisStoreSharesCounters(t *testing.T) {
+	srv, a, b := newTestRedisStores(t)
+	srv.SetTime(time.Unix(1000*60, 0))
+	ctx := context.Background()
+	policy := config.RateLimitPolicy{Requests: 3, Window: config.Duration(time.Minute)}
+
+	for i, store := range []*RedisStore{a, b, a} {
+		d, err := store.Take(ctx, "ip:1.2.3.4", policy)
+		if err != nil {
+			t.Fatalf("Take %d: %v", i, err)
+		}
+		if !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("Take %d = %+v, want allowed with %d remaining", i, d, 2-i)
+		}
+	}
+
+	d, err := b.Take(ctx, "ip:1.2.3.4", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Allowed || d.RetryAfter != time.Minute {
+		t.Fatalf("Take over limit = %+v, want rejection with 1m retry", d)
+	}
+
+	if d, _ := b.Take(ctx, "ip:5.6.7.8", policy); !d.Allowed {
+		t.Error("other client limited")
+	}
+}
+
+func TestRedisStoreSlidingWindow(t *testing.T) {
+	srv, store, _ := newTestRedisStores(t)
+	ctx := context.Background()
+	policy := config.RateLimitPolicy{Requests: 4, Window: config.Duration(time.Minute)}
+	start := time.Unix(1000*60, 0)
+
+	srv.SetTime(start)
+	for i := 0; i < 4; i++ {
+		store.Take(ctx, "k", policy)
+	}
+
+	// A quarter into the next window, three quarters of the previous
+	// window's four requests still count.
+	srv.SetTime(start.Add(75 * time.Second))
+	d, err := store.Take(ctx, "k", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("Take = %+v, want allowed with 0 remaining", d)
+	}
+	d, _ = store.Take(ctx, "k", policy)
+	if d.Allowed {
+		t.Fatalf("Take = %+v, want rejection", d)
+	}
+	// 3 + 1 + 1 exceeds 4 by one; a quarter window must slide out.
+	if d.RetryAfter != 15*time.Second {
+		t.Errorf("RetryAfter = %v, want 15s", d.RetryAfter)
+	}
+
+	srv.SetTime(start.Add(2*time.Minute + time.Second))
+	if d, _ := store.Take(ctx, "k", policy); !d.Allowed {
+		t.Errorf("Take after window = %+v, want allowed", d)
+	}
+}
+
+func TestRedisStoreUnavailable(t *testing.T) {
+	srv, store, _ := newTestRedisStores(t)
+	srv.Close()
+
+	_, err := store.Take(context.Background(), "k", config.RateLimitPolicy{Requests: 1, Window: config.Duration(time.Minute)})
+	if err == nil {
+		t.Fatal("Take succeeded with the server down")
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"api-gateway/internal/config"
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitDecision is the outcome of counting one request against a
+// policy.
+type RateLimitDecision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// RateLimitStore counts requests per key. The in-memory store limits each
+// gateway replica on its own; the Redis store shares counters between
+// replicas.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy config.RateLimitPolicy) (RateLimitDecision, error)
+}
+
+const rateLimitShards = 32
+
+// bucket is a token bucket. tokens is refilled lazily on each take.
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+type shard struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// MemoryStore keeps a token bucket per key in process memory. Buckets are
+// spread over shards so that requests from different clients rarely
+// contend on the same lock.
+type MemoryStore struct {
+	shards [rateLimitShards]shard
+	now    func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{now: time.Now}
+	for i := range s.shards {
+		s.shards[i].buckets = make(map[string]*bucket)
+	}
+	return s
+}
+
+// Take removes a token from the bucket for key.
+func (s *MemoryStore) Take(ctx context.Context, key string, policy config.RateLimitPolicy) (RateLimitDecision, error) {
+	capacity := float64(policy.Requests)
+	perSecond := capacity / time.Duration(policy.Window).Seconds()
+	now := s.now()
+
+	sh := &s.shards[shardFor(key)]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	b, ok := sh.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		sh.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*perSecond)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+		return RateLimitDecision{RetryAfter: wait}, nil
+	}
+	b.tokens--
+	return RateLimitDecision{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// Evict drops buckets not used within idle.
+func (s *MemoryStore) Evict(idle time.Duration) {
+	cutoff := s.now().Add(-idle)
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		for key, b := range sh.buckets {
+			if b.lastSeen.Before(cutoff) {
+				delete(sh.buckets, key)
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+func shardFor(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % rateLimitShards)
+}
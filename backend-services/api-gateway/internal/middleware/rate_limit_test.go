@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"api-gateway/internal/config"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+type orderRoutePolicy struct{}
+
+func (orderRoutePolicy) RateLimitPolicy(method, path string) (string, *config.RateLimitPolicy) {
+	if method == http.MethodPost && path == "/api/orders" {
+		return "POST /api/orders", &config.RateLimitPolicy{Requests: 1, Window: config.Duration(time.Minute)}
+	}
+	return "", nil
+}
+
+func newTestLimiter(cfg config.RateLimitConfig) (*RateLimiter, *time.Time) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	return NewRateLimiter(cfg, orderRoutePolicy{}, store), &now
+}
+
+func serve(h http.Handler, method, path, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	l, now := newTestLimiter(config.RateLimitConfig{
+		RateLimitPolicy: config.RateLimitPolicy{Requests: 2, Window: config.Duration(2 * time.Second)},
+	})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Different source ports are the same client.
+	for i, addr := range []string{"1.2.3.4:1000", "1.2.3.4:2000"} {
+		rec := serve(h, http.MethodGet, "/api/products", addr, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d", i, rec.Code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != []string{"1", "0"}[i] {
+			t.Errorf("request %d: remaining = %s", i, got)
+		}
+	}
+
+	rec := serve(h, http.MethodGet, "/api/products", "1.2.3.4:3000", nil)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("status = %d, Retry-After = %q; want 429 and 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("limit = %q, want 2", rec.Header().Get("X-RateLimit-Limit"))
+	}
+
+	if rec := serve(h, http.MethodGet, "/api/products", "5.6.7.8:1000", nil); rec.Code != http.StatusOK {
+		t.Errorf("other client limited: status = %d", rec.Code)
+	}
+
+	*now = now.Add(time.Second)
+	if rec := serve(h, http.MethodGet, "/api/products", "1.2.3.4:1000", nil); rec.Code != http.StatusOK {
+		t.Errorf("bucket did not refill: status = %d", rec.Code)
+	}
+}
+
+func TestRateLimiterRoutePolicy(t *testing.T) {
+	l, _ := newTestLimiter(config.RateLimitConfig{
+		RateLimitPolicy: config.RateLimitPolicy{Requests: 100, Window: config.Duration(time.Minute)},
+		Key:             config.RateLimitByUser,
+	})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	user := map[string]string{HeaderUserID: "u1"}
+
+	if rec := serve(h, http.MethodPost, "/api/orders", "1.1.1.1:1", user); rec.Code != http.StatusOK {
+		t.Fatalf("first order: status = %d", rec.Code)
+	}
+	// Same user from another address shares the bucket.
+	if rec := serve(h, http.MethodPost, "/api/orders", "2.2.2.2:1", user); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second order: status = %d, want 429", rec.Code)
+	}
+	if rec := serve(h, http.MethodGet, "/api/orders", "1.1.1.1:1", user); rec.Code != http.StatusOK {
+		t.Fatalf("default policy affected by route policy: status = %d", rec.Code)
+	}
+}
+
+func TestRateLimiterFailsOpen(t *testing.T) {
+	l := NewRateLimiter(config.RateLimitConfig{
+		RateLimitPolicy: config.RateLimitPolicy{Requests: 1, Window: config.Duration(time.Minute)},
+	}, nil, failingStore{})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i := 0; i < 3; i++ {
+		if rec := serve(h, http.MethodGet, "/api/products", "1.2.3.4:1", nil); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i, rec.Code)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, config.RateLimitPolicy) (RateLimitDecision, error) {
+	return RateLimitDecision{}, errors.New("connection refused")
+}
+
+func TestMemoryStoreEvictsIdleBuckets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	s.Take(context.Background(), "ip:1.2.3.4", config.RateLimitPolicy{Requests: 1, Window: config.Duration(time.Minute)})
+
+	now = now.Add(2 * time.Minute)
+	s.Evict(time.Minute)
+	for i := range s.shards {
+		if n := len(s.shards[i].buckets); n != 0 {
+			t.Fatalf("shard %d still has %d buckets", i, n)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		remote, xff, want string
+	}{
+		{"1.2.3.4:5", "9.9.9.9", "1.2.3.4"},
+		{"10.0.0.1:5", "9.9.9.9", "9.9.9.9"},
+		{"10.0.0.1:5", "6.6.6.6, 9.9.9.9, 10.0.0.2", "9.9.9.9"},
+		{"10.0.0.1:5", "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remote
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if got := clientIP(req, trusted); got != tt.want {
+			t.Errorf("clientIP(%s, %q) = %s, want %s", tt.remote, tt.xff, got, tt.want)
+		}
+	}
+}
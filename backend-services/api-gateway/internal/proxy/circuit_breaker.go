@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"api-gateway/internal/config"
+	"sync"
+	"time"
+)
+
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through and counts failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests until the open timeout has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a few trial requests through to decide whether
+	// the upstream has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops the gateway from forwarding to a service that keeps
+// failing. After FailureThreshold consecutive failures it opens for
+// OpenTimeout, then half-opens and lets HalfOpenRequests trial requests
+// through; if they all succeed it closes, and any failure reopens it.
+type CircuitBreaker struct {
+	mu  sync.Mutex
+	cfg config.CircuitBreakerConfig
+	now func() time.Time
+
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	trials    int
+	successes int
+}
+
+func NewCircuitBreaker(cfg config.CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{cfg: cfg, now: time.Now}
+}
+
+// Update applies new settings without resetting the breaker's state.
+func (cb *CircuitBreaker) Update(cfg config.CircuitBreakerConfig) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.cfg = cfg
+}
+
+// State returns the current state, moving from open to half-open if the
+// open timeout has passed.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.advance()
+	return cb.state
+}
+
+// Allow reports whether a request may be sent. When it may not, it returns
+// how long until the breaker will let trial requests through again. Every
+// allowed request must be followed by a call to Record or Release.
+func (cb *CircuitBreaker) Allow() (bool, time.Duration) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.advance()
+
+	switch cb.state {
+	case CircuitOpen:
+		return false, cb.openedAt.Add(time.Duration(cb.cfg.OpenTimeout)).Sub(cb.now())
+	case CircuitHalfOpen:
+		if cb.trials >= cb.cfg.HalfOpenRequests {
+			return false, time.Second
+		}
+		cb.trials++
+	}
+	return true, 0
+}
+
+// Record reports the outcome of a request that Allow let through.
+func (cb *CircuitBreaker) Record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitClosed:
+		if success {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		if cb.failures >= cb.cfg.FailureThreshold {
+			cb.open()
+		}
+	case CircuitHalfOpen:
+		if !success {
+			cb.open()
+			return
+		}
+		cb.successes++
+		if cb.successes >= cb.cfg.HalfOpenRequests {
+			cb.state = CircuitClosed
+			cb.failures = 0
+		}
+	}
+}
+
+// Release gives back the trial slot of a request that Allow let through
+// but whose outcome says nothing about the upstream, without recording
+// it.
+func (cb *CircuitBreaker) Release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitHalfOpen && cb.trials > 0 {
+		cb.trials--
+	}
+}
+
+func (cb *CircuitBreaker) open() {
+	cb.state = CircuitOpen
+	cb.openedAt = cb.now()
+}
+
+func (cb *CircuitBreaker) advance() {
+	if cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= time.Duration(cb.cfg.OpenTimeout) {
+		cb.state = CircuitHalfOpen
+		cb.trials = 0
+		cb.successes = 0
+	}
+}
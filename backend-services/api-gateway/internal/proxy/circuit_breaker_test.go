@@ -0,0 +1,239 @@
+package proxy
+
+import (
+	"api-gateway/internal/config"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(config.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      config.Duration(10 * time.Second),
+		HalfOpenRequests: 1,
+	})
+	cb.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := cb.Allow(); !ok {
+			t.Fatalf("request %d rejected while closed", i)
+		}
+		cb.Record(false)
+	}
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s, want open", cb.State())
+	}
+
+	now = now.Add(4 * time.Second)
+	if ok, retryAfter := cb.Allow(); ok || retryAfter != 6*time.Second {
+		t.Fatalf("Allow() = %v, %v; want rejection with 6s retry", ok, retryAfter)
+	}
+
+	now = now.Add(6 * time.Second)
+	if ok, _ := cb.Allow(); !ok {
+		t.Fatal("trial request rejected while half-open")
+	}
+	if ok, _ := cb.Allow(); ok {
+		t.Fatal("second trial request allowed while half-open")
+	}
+	cb.Record(false)
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s after failed trial, want open", cb.State())
+	}
+
+	now = now.Add(10 * time.Second)
+	cb.Allow()
+	cb.Record(true)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s after successful trial, want closed", cb.State())
+	}
+}
+
+func TestCircuitBreakerRelease(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(config.CircuitBreakerConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      config.Duration(10 * time.Second),
+		HalfOpenRequests: 1,
+	})
+	cb.now = func() time.Time { return now }
+
+	cb.Allow()
+	cb.Record(false)
+	now = now.Add(10 * time.Second)
+	if ok, _ := cb.Allow(); !ok {
+		t.Fatal("trial request rejected while half-open")
+	}
+
+	// The trial's client hung up: the slot is freed and nothing recorded
+	cb.Release()
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s after release, want half-open", cb.State())
+	}
+	if ok, _ := cb.Allow(); !ok {
+		t.Fatal("trial request rejected after the previous one was released")
+	}
+	cb.Record(true)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s after successful trial, want closed", cb.State())
+	}
+}
+
+func newResilienceRouter(t *testing.T, upstream string, svc config.ServiceConfig) *ProxyRouter {
+	t.Helper()
+	svc.URL = upstream
+	pr, err := NewProxyRouter(&config.Config{
+		Services: map[string]config.ServiceConfig{"svc": svc},
+		Routes:   []config.RouteConfig{{Prefix: "/api", Service: "svc"}},
+	})
+	if err != nil {
+		t.Fatalf("NewProxyRouter: %v", err)
+	}
+	t.Cleanup(pr.Close)
+	return pr
+}
+
+func TestForwardRetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			return
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	pr := newResilienceRouter(t, upstream.URL, config.ServiceConfig{
+		Retry: config.RetryConfig{Attempts: 3, Backoff: config.Duration(time.Millisecond)},
+	})
+
+	rec := httptest.NewRecorder()
+	pr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/things", nil))
+	if rec.Code != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("GET: status %d after %d calls, want 200 after 3", rec.Code, calls.Load())
+	}
+
+	calls.Store(0)
+	rec = httptest.NewRecorder()
+	pr.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/things", nil))
+	if rec.Code != http.StatusBadGateway || calls.Load() != 1 {
+		t.Fatalf("POST: status %d after %d calls, want 502 after 1", rec.Code, calls.Load())
+	}
+}
+
+func TestForwardRejectsWhileCircuitOpen(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			calls.Add(1)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	pr := newResilienceRouter(t, upstream.URL, config.ServiceConfig{
+		CircuitBreaker: config.CircuitBreakerConfig{
+			FailureThreshold: 2,
+			OpenTimeout:      config.Duration(time.Minute),
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		pr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/things", nil))
+	}
+
+	rec := httptest.NewRecorder()
+	pr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/things", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("upstream called %d times, want 2", calls.Load())
+	}
+	if rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q, want 60", rec.Header().Get("Retry-After"))
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body["code"] != "circuit_open" || body["retry_after"] != float64(60) {
+		t.Errorf("body = %v (%v), want code circuit_open", body, err)
+	}
+}
+
+func TestForwardRouteTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer upstream.Close()
+
+	pr, err := NewProxyRouter(&config.Config{
+		Services: map[string]config.ServiceConfig{"svc": {URL: upstream.URL}},
+		Routes:   []config.RouteConfig{{Prefix: "/api", Service: "svc", Timeout: config.Duration(20 * time.Millisecond)}},
+	})
+	if err != nil {
+		t.Fatalf("NewProxyRouter: %v", err)
+	}
+	defer pr.Close()
+
+	rec := httptest.NewRecorder()
+	pr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/slow", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", rec.Code)
+	}
+}
+
+func TestForwardCountsUpstreamErrors(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer upstream.Close()
+
+	pr := newResilienceRouter(t, upstream.URL, config.ServiceConfig{
+		Retry: config.RetryConfig{Attempts: 2, Backoff: config.Duration(time.Millisecond)},
+	})
+
+	before := upstreamErrorCount(t, "svc", "status_5xx")
+	pr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/things", nil))
+	if got := upstreamErrorCount(t, "svc", "status_5xx") - before; got != 2 {
+		t.Errorf("upstream errors = %v, want 2", got)
+	}
+}
+
+func upstreamErrorCount(t *testing.T, service, reason string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "gateway_upstream_errors_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["service"] == service && labels["reason"] == reason {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"api-gateway/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"shared/response"
+	"sync/atomic"
+	"time"
+)
+
+// Backend is a single instance of an upstream service.
+type Backend struct {
+	URL   *url.URL
+	proxy *httputil.ReverseProxy
+
+	healthy atomic.Bool
+	active  atomic.Int64
+
+	// Consecutive probe results.
+	successes atomic.Int32
+	failures  atomic.Int32
+}
+
+func newBackend(rawURL string) (*Backend, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance url %q: %w", rawURL, err)
+	}
+
+	b := &Backend{URL: u, proxy: httputil.NewSingleHostReverseProxy(u)}
+	b.proxy.ModifyResponse = func(resp *http.Response) error {
+		outcome := outcomeFrom(resp.Request.Context())
+		if outcome != nil {
+			outcome.status = resp.StatusCode
+		}
+		if outcome != nil && resp.StatusCode >= http.StatusInternalServerError {
+			outcome.failed = true
+			if !outcome.final {
+				return errRetryableStatus
+			}
+		}
+		return nil
+	}
+	b.proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		outcome := outcomeFrom(r.Context())
+		if outcome != nil {
+			outcome.failed = true
+			outcome.err = err
+			if !outcome.final {
+				return
+			}
+		}
+
+		slog.WarnContext(r.Context(), "Upstream error", "host", u.Host, "error", err)
+		if errors.Is(err, context.DeadlineExceeded) {
+			response.Error(w, http.StatusGatewayTimeout, "Upstream timed out")
+			return
+		}
+		response.Error(w, http.StatusBadGateway, "Upstream unavailable")
+	}
+	b.healthy.Store(true)
+	return b, nil
+}
+
+// Healthy reports whether the backend is in rotation.
+func (b *Backend) Healthy() bool {
+	return b.healthy.Load()
+}
+
+// RequestURI returns the path and query the backend receives when r is
+// forwarded to it.
+func (b *Backend) RequestURI(r *http.Request) string {
+	out := r.Clone(r.Context())
+	b.proxy.Director(out)
+	return out.URL.RequestURI()
+}
+
+// ServeHTTP forwards r to the backend, tracking it as an active connection.
+func (b *Backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.active.Add(1)
+	defer b.active.Add(-1)
+
+	b.proxy.ServeHTTP(w, r)
+}
+
+// LoadBalancer picks a healthy backend for each request according to the
+// service's strategy and keeps backend health up to date with active
+// probes.
+type LoadBalancer struct {
+	service  string
+	backends []*Backend
+	strategy string
+	next     atomic.Uint64
+
+	healthCheck config.HealthCheckConfig
+	client      *http.Client
+	stop        context.CancelFunc
+}
+
+// NewLoadBalancer creates a balancer for the service's instances. Backends
+// found in previous, keyed by URL, are reused so that their health state
+// survives a config reload.
+func NewLoadBalancer(service string, cfg config.ServiceConfig, previous map[string]*Backend) (*LoadBalancer, error) {
+	cfg = cfg.WithDefaults()
+
+	lb := &LoadBalancer{
+		service:     service,
+		strategy:    cfg.Strategy,
+		healthCheck: cfg.HealthCheck,
+		client:      &http.Client{Timeout: time.Duration(cfg.HealthCheck.Timeout)},
+	}
+	for _, endpoint := range cfg.Endpoints() {
+		if b, ok := previous[endpoint]; ok {
+			lb.backends = append(lb.backends, b)
+			continue
+		}
+		b, err := newBackend(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		lb.backends = append(lb.backends, b)
+	}
+	return lb, nil
+}
+
+// Backends returns every instance of the service, healthy or not.
+func (lb *LoadBalancer) Backends() []*Backend {
+	return lb.backends
+}
+
+// Next returns the backend that should serve the next request, or nil when
+// no instance is healthy.
+func (lb *LoadBalancer) Next() *Backend {
+	n := len(lb.backends)
+	if n == 0 {
+		return nil
+	}
+
+	start := int(lb.next.Add(1) - 1)
+	if lb.strategy == config.LeastConnections {
+		var best *Backend
+		for i := 0; i < n; i++ {
+			b := lb.backends[(start+i)%n]
+			if b.Healthy() && (best == nil || b.active.Load() < best.active.Load()) {
+				best = b
+			}
+		}
+		return best
+	}
+
+	for i := 0; i < n; i++ {
+		if b := lb.backends[(start+i)%n]; b.Healthy() {
+			return b
+		}
+	}
+	return nil
+}
+
+// StartHealthChecks probes every backend on the configured interval until
+// Stop is called.
+func (lb *LoadBalancer) StartHealthChecks() {
+	ctx, cancel := context.WithCancel(context.Background())
+	lb.stop = cancel
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(lb.healthCheck.Interval))
+		defer ticker.Stop()
+		for {
+			lb.CheckHealth(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends health checking. Requests already holding a backend are not
+// affected.
+func (lb *LoadBalancer) Stop() {
+	if lb.stop != nil {
+		lb.stop()
+	}
+}
+
+// CheckHealth probes every backend once and moves backends in or out of
+// rotation once they cross the configured thresholds.
+func (lb *LoadBalancer) CheckHealth(ctx context.Context) {
+	for _, b := range lb.backends {
+		if ctx.Err() != nil {
+			return
+		}
+
+		ok := lb.probe(ctx, b)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if ok {
+			b.failures.Store(0)
+			if b.successes.Add(1) >= int32(lb.healthCheck.HealthyThreshold) && !b.Healthy() {
+				b.healthy.Store(true)
+				slog.Info("Upstream instance is healthy again", "service", lb.service, "host", b.URL.Host)
+			}
+		} else {
+			b.successes.Store(0)
+			if b.failures.Add(1) >= int32(lb.healthCheck.UnhealthyThreshold) && b.Healthy() {
+				b.healthy.Store(false)
+				slog.Warn("Upstream instance removed from rotation", "service", lb.service, "host", b.URL.Host)
+			}
+		}
+	}
+}
+
+func (lb *LoadBalancer) probe(ctx context.Context, b *Backend) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.URL.JoinPath(lb.healthCheck.Path).String(), nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := lb.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
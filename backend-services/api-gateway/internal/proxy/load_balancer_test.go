@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"api-gateway/internal/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLoadBalancerRoundRobin(t *testing.T) {
+	a := newUpstream(t, "a")
+	b := newUpstream(t, "b")
+
+	lb, err := NewLoadBalancer("svc", config.ServiceConfig{Instances: []string{a.URL, b.URL}}, nil)
+	if err != nil {
+		t.Fatalf("NewLoadBalancer: %v", err)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 10; i++ {
+		counts[lb.Next().URL.String()]++
+	}
+	if counts[a.URL] != 5 || counts[b.URL] != 5 {
+		t.Errorf("round robin spread = %v, want 5 each", counts)
+	}
+}
+
+func TestLoadBalancerLeastConnections(t *testing.T) {
+	lb, err := NewLoadBalancer("svc", config.ServiceConfig{
+		Instances: []string{"http://a:1", "http://b:1"},
+		Strategy:  config.LeastConnections,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewLoadBalancer: %v", err)
+	}
+
+	busy := lb.Backends()[0]
+	busy.active.Add(3)
+	for i := 0; i < 4; i++ {
+		if got := lb.Next(); got == busy {
+			t.Fatalf("picked busy backend %s", got.URL)
+		}
+	}
+}
+
+func TestLoadBalancerHealthChecks(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer flaky.Close()
+	stable := newUpstream(t, "stable")
+
+	lb, err := NewLoadBalancer("svc", config.ServiceConfig{
+		Instances: []string{flaky.URL, stable.URL},
+		HealthCheck: config.HealthCheckConfig{
+			UnhealthyThreshold: 2,
+			HealthyThreshold:   1,
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewLoadBalancer: %v", err)
+	}
+	ctx := context.Background()
+	flakyBackend := lb.Backends()[0]
+
+	status.Store(http.StatusServiceUnavailable)
+	lb.CheckHealth(ctx)
+	if !flakyBackend.Healthy() {
+		t.Fatal("backend removed before reaching the unhealthy threshold")
+	}
+	// A missing health endpoint is no sign of health either
+	status.Store(http.StatusNotFound)
+	lb.CheckHealth(ctx)
+	if flakyBackend.Healthy() {
+		t.Fatal("backend still in rotation after failing health checks")
+	}
+	for i := 0; i < 4; i++ {
+		if lb.Next() == flakyBackend {
+			t.Fatal("unhealthy backend selected")
+		}
+	}
+
+	status.Store(http.StatusOK)
+	lb.CheckHealth(ctx)
+	if !flakyBackend.Healthy() {
+		t.Fatal("backend not returned to rotation after recovering")
+	}
+}
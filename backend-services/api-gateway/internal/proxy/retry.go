@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"api-gateway/internal/config"
+	"api-gateway/internal/metrics"
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"shared/response"
+	"strconv"
+	"time"
+)
+
+// errRetryableStatus makes the reverse proxy discard an upstream 5xx
+// response so that the request can be retried.
+var errRetryableStatus = errors.New("retryable upstream status")
+
+type attemptKey struct{}
+
+// attemptOutcome is carried in the request context of a single proxy
+// attempt. The backend's reverse proxy marks it failed on transport errors
+// and 5xx responses and, unless the attempt is the final one, swallows the
+// failure instead of writing it to the client. status and err record what
+// the upstream returned for tracing.
+type attemptOutcome struct {
+	final  bool
+	failed bool
+	status int
+	err    error
+}
+
+func outcomeFrom(ctx context.Context) *attemptOutcome {
+	outcome, _ := ctx.Value(attemptKey{}).(*attemptOutcome)
+	return outcome
+}
+
+// recordAttempt counts a failed attempt in the upstream error metrics.
+func recordAttempt(service string, outcome *attemptOutcome) {
+	switch {
+	case outcome.err != nil && errors.Is(outcome.err, context.DeadlineExceeded):
+		metrics.UpstreamError(service, metrics.ReasonTimeout)
+	case outcome.err != nil && !errors.Is(outcome.err, errRetryableStatus):
+		metrics.UpstreamError(service, metrics.ReasonConnection)
+	case outcome.status >= http.StatusInternalServerError:
+		metrics.UpstreamError(service, metrics.ReasonStatus5xx)
+	}
+}
+
+// isIdempotent reports whether a request with this method can safely be
+// sent more than once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the attempt after the given one: the
+// configured backoff doubled per attempt, capped, with up to 50% jitter.
+func backoff(cfg config.RetryConfig, attempt int) time.Duration {
+	wait := float64(cfg.Backoff) * math.Pow(2, float64(attempt-1))
+	if wait > float64(cfg.MaxBackoff) {
+		wait = float64(cfg.MaxBackoff)
+	}
+	return time.Duration(wait/2 + rand.Float64()*wait/2)
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// writeUnavailable writes a 503 problem telling the client when to try
+// again.
+func writeUnavailable(w http.ResponseWriter, service, code, message string, retryAfter time.Duration) {
+	metrics.UpstreamError(service, code)
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	problem := response.NewProblem(http.StatusServiceUnavailable, response.Code(code), message)
+	problem.Extensions = map[string]interface{}{
+		"service":     service,
+		"retry_after": seconds,
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	response.WriteError(w, problem)
+}
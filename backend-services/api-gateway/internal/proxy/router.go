@@ -1,51 +1,356 @@
 package proxy
 
 import (
+	"api-gateway/internal/config"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
-	"os"
+	"shared/response"
+	"shared/signing"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
+// Route maps a path prefix to the upstream service that serves it.
+// A "*" segment in the prefix matches any single path segment, so
+// "/api/stores/*/products" matches "/api/stores/42/products". A route with
+// Methods only matches requests using one of them.
+type Route struct {
+	Prefix    string
+	Methods   []string
+	Service   string
+	Access    string
+	Timeout   time.Duration
+	RateLimit *config.RateLimitPolicy
+}
+
+// Name identifies the route in logs and rate limit buckets, e.g.
+// "POST /api/orders".
+func (r Route) Name() string {
+	if len(r.Methods) == 0 {
+		return r.Prefix
+	}
+	return strings.Join(r.Methods, ",") + " " + r.Prefix
+}
+
+// routeTable is an immutable snapshot of the gateway config. Requests
+// resolve against whichever snapshot was current when they arrived, so a
+// reload never affects requests already in flight.
+type routeTable struct {
+	services  map[string]config.ServiceConfig
+	balancers map[string]*LoadBalancer
+	breakers  map[string]*CircuitBreaker
+	routes    []Route
+}
+
 type ProxyRouter struct {
-	serviceURLs  map[string]string
-	gatewaySecret string
+	table  atomic.Pointer[routeTable]
+	signer *signing.Signer
+}
+
+// NewProxyRouter routes according to cfg, signing upstream requests with
+// the key configured by signing.NewSignerFromEnv.
+func NewProxyRouter(cfg *config.Config) (*ProxyRouter, error) {
+	signer, err := signing.NewSignerFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	pr := &ProxyRouter{signer: signer}
+	if err := pr.Update(cfg); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// Update swaps in the routes and services from cfg and restarts health
+// checking. Instances that appear in both the old and new config keep
+// their health state, and services keep their circuit breaker state. On
+// error the current table stays in place.
+func (pr *ProxyRouter) Update(cfg *config.Config) error {
+	old := pr.table.Load()
+	previous := make(map[string]*Backend)
+	if old != nil {
+		for _, lb := range old.balancers {
+			for _, b := range lb.Backends() {
+				previous[b.URL.String()] = b
+			}
+		}
+	}
+
+	services := make(map[string]config.ServiceConfig, len(cfg.Services))
+	balancers := make(map[string]*LoadBalancer, len(cfg.Services))
+	breakers := make(map[string]*CircuitBreaker, len(cfg.Services))
+	for name, svc := range cfg.Services {
+		svc = svc.WithDefaults()
+		lb, err := NewLoadBalancer(name, svc, previous)
+		if err != nil {
+			return fmt.Errorf("service %q: %w", name, err)
+		}
+		services[name] = svc
+		balancers[name] = lb
+
+		if old != nil && old.breakers[name] != nil {
+			breakers[name] = old.breakers[name]
+			breakers[name].Update(svc.CircuitBreaker)
+		} else {
+			breakers[name] = NewCircuitBreaker(svc.CircuitBreaker)
+		}
+	}
+
+	routes := make([]Route, len(cfg.Routes))
+	for i, route := range cfg.Routes {
+		routes[i] = Route{
+			Prefix:    route.Prefix,
+			Methods:   route.Methods,
+			Service:   route.Service,
+			Access:    route.AccessLevel(),
+			Timeout:   time.Duration(route.Timeout),
+			RateLimit: route.RateLimit,
+		}
+	}
+
+	pr.table.Store(&routeTable{services: services, balancers: balancers, breakers: breakers, routes: routes})
+	for _, lb := range balancers {
+		lb.StartHealthChecks()
+	}
+	if old != nil {
+		for _, lb := range old.balancers {
+			lb.Stop()
+		}
+	}
+	return nil
+}
+
+// Close stops health checking for every service.
+func (pr *ProxyRouter) Close() {
+	for _, lb := range pr.table.Load().balancers {
+		lb.Stop()
+	}
+}
+
+// ServeHTTP proxies the request to the service owning the longest
+// matching route prefix.
+func (pr *ProxyRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	table := pr.table.Load()
+	route, ok := table.match(r.Method, r.URL.Path)
+	if !ok {
+		response.Error(w, http.StatusNotFound, "No route for path")
+		return
+	}
+	pr.forward(w, r, table, route.Service, route.Timeout)
+}
+
+// Match returns the route that would serve a request.
+func (pr *ProxyRouter) Match(method, path string) (Route, bool) {
+	return pr.table.Load().match(method, path)
+}
+
+// RouteTemplate returns the prefix of the route serving a request, or ""
+// if no route matches.
+func (pr *ProxyRouter) RouteTemplate(method, path string) string {
+	route, ok := pr.Match(method, path)
+	if !ok {
+		return ""
+	}
+	return route.Prefix
+}
+
+// AccessLevel returns the access level required by the route serving a
+// request, or config.AccessPublic if no route matches so that the proxy
+// can answer with a 404.
+func (pr *ProxyRouter) AccessLevel(method, path string) string {
+	route, ok := pr.Match(method, path)
+	if !ok {
+		return config.AccessPublic
+	}
+	return route.Access
 }
 
-func NewProxyRouter() *ProxyRouter {
-	return &ProxyRouter{
-		serviceURLs: map[string]string{
-			"store-service":   os.Getenv("STORE_SERVICE_URL"),
-			"product-service": os.Getenv("PRODUCT_SERVICE_URL"),
-			"order-service":  os.Getenv("ORDER_SERVICE_URL"),
-		},
-		gatewaySecret: os.Getenv("GATEWAY_SECRET"),
+// RateLimitPolicy returns the rate limit policy of the route serving a
+// request and the route's name, or nil if the route has no policy of its
+// own.
+func (pr *ProxyRouter) RateLimitPolicy(method, path string) (string, *config.RateLimitPolicy) {
+	route, ok := pr.Match(method, path)
+	if !ok || route.RateLimit == nil {
+		return "", nil
 	}
+	return route.Name(), route.RateLimit
 }
 
+// match returns the route whose prefix covers the most segments of path.
+// When two prefixes are equally long, a route restricted to the request's
+// method beats one that accepts any method, and then the one with fewer
+// wildcards wins.
+func (t *routeTable) match(method, path string) (Route, bool) {
+	pathSegments := splitPath(path)
+
+	var best Route
+	bestLen, bestSpecific, bestWildcards := -1, false, 0
+	for _, route := range t.routes {
+		specific := len(route.Methods) > 0
+		if specific && !containsMethod(route.Methods, method) {
+			continue
+		}
+		prefixSegments := splitPath(route.Prefix)
+		wildcards, ok := matchSegments(prefixSegments, pathSegments)
+		if !ok {
+			continue
+		}
+		if better(len(prefixSegments), specific, wildcards, bestLen, bestSpecific, bestWildcards) {
+			best = route
+			bestLen, bestSpecific, bestWildcards = len(prefixSegments), specific, wildcards
+		}
+	}
+
+	return best, bestLen >= 0
+}
+
+func better(length int, specific bool, wildcards int, bestLen int, bestSpecific bool, bestWildcards int) bool {
+	if length != bestLen {
+		return length > bestLen
+	}
+	if specific != bestSpecific {
+		return specific
+	}
+	return wildcards < bestWildcards
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments reports whether prefix matches the start of path on whole
+// segments, and how many wildcard segments were used to do so.
+func matchSegments(prefix, path []string) (int, bool) {
+	if len(prefix) > len(path) {
+		return 0, false
+	}
+
+	wildcards := 0
+	for i, segment := range prefix {
+		if segment == "*" {
+			wildcards++
+			continue
+		}
+		if segment != path[i] {
+			return 0, false
+		}
+	}
+	return wildcards, true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// ProxyRequest returns a handler that forwards every request to service.
 func (pr *ProxyRouter) ProxyRequest(service string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		serviceURL := pr.serviceURLs[service]
-		if serviceURL == "" {
-			http.Error(w, "Service not found", http.StatusNotFound)
+		pr.forward(w, r, pr.table.Load(), service, 0)
+	})
+}
+
+// forward sends r to a healthy instance of service through the service's
+// circuit breaker. Idempotent requests that fail are retried with backoff
+// on the next instance. Each attempt is bounded by timeout, or by the
+// service timeout when timeout is zero.
+func (pr *ProxyRouter) forward(w http.ResponseWriter, r *http.Request, table *routeTable, service string, timeout time.Duration) {
+	svc, ok := table.services[service]
+	lb := table.balancers[service]
+	if !ok || len(lb.Backends()) == 0 {
+		response.Error(w, http.StatusNotFound, "Service not found")
+		return
+	}
+	breaker := table.breakers[service]
+
+	if timeout == 0 {
+		timeout = time.Duration(svc.Timeout)
+	}
+
+	// The body is signed, so it is buffered whole, which also lets
+	// idempotent requests be replayed.
+	body, err := signing.ReadBody(r)
+	if err != nil {
+		if errors.Is(err, signing.ErrBodyTooLarge) {
+			response.Error(w, http.StatusRequestEntityTooLarge, "Request body too large")
 			return
 		}
+		response.Error(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	attempts := 1
+	if isIdempotent(r.Method) && svc.Retry.Attempts > 1 {
+		attempts = svc.Retry.Attempts
+	}
 
-		url, err := url.Parse(serviceURL)
-		if err != nil {
-			http.Error(w, "Invalid service URL", http.StatusInternalServerError)
+	r.Header.Set("X-Gateway-Service", service)
+	r.Header.Set("X-Forwarded-Host", r.Host)
+
+	for attempt := 1; ; attempt++ {
+		allowed, retryAfter := breaker.Allow()
+		if !allowed {
+			writeUnavailable(w, service, "circuit_open", "Service is temporarily unavailable", retryAfter)
 			return
 		}
 
-		// Add gateway secret header for service authentication
-		r.Header.Set("X-Gateway-Secret", pr.gatewaySecret)
-		r.Header.Set("X-Gateway-Service", service)
-		r.Header.Set("X-Forwarded-Host", r.Header.Get("Host"))
+		backend := lb.Next()
+		if backend == nil {
+			breaker.Record(false)
+			writeUnavailable(w, service, "no_healthy_upstream", "No healthy upstream instance", time.Duration(svc.HealthCheck.Interval))
+			return
+		}
 
-		proxy := httputil.NewSingleHostReverseProxy(url)
-		r.URL.Host = url.Host
-		r.URL.Scheme = url.Scheme
+		outcome := &attemptOutcome{final: attempt >= attempts}
+		ctx := context.WithValue(r.Context(), attemptKey{}, outcome)
+		cancel := func() {}
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		ctx, span := startAttemptSpan(ctx, service, backend, attempt)
+		req := r.Clone(ctx)
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+		// Each attempt gets a fresh nonce, so retries are not taken for
+		// replays.
+		pr.signer.Sign(req, backend.RequestURI(req), body)
 
-		proxy.ServeHTTP(w, r)
-	})
-}
\ No newline at end of file
+		backend.ServeHTTP(w, req)
+		endAttemptSpan(span, outcome)
+		// The attempt is over, so its timer is not kept until the request
+		// ends
+		cancel()
+
+		// A client that hung up says nothing about the upstream's health.
+		if r.Context().Err() != nil {
+			breaker.Release()
+			return
+		}
+		recordAttempt(service, outcome)
+		breaker.Record(!outcome.failed)
+		if !outcome.failed || outcome.final {
+			return
+		}
+
+		if !sleep(r.Context(), backoff(svc.Retry, attempt)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"api-gateway/internal/config"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"shared/response"
+	"shared/signing"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testKey = signing.Key{ID: "test", Secret: []byte(strings.Repeat("k", 32))}
+
+func TestMain(m *testing.M) {
+	os.Setenv("SERVICE_SIGNING_KEYS", testKey.ID+":"+string(testKey.Secret))
+	os.Exit(m.Run())
+}
+
+func newUpstream(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", name)
+		io.WriteString(w, r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestProxyRouterRoutesByLongestPrefix(t *testing.T) {
+	stores := newUpstream(t, "store-service")
+	products := newUpstream(t, "product-service")
+	orders := newUpstream(t, "order-service")
+
+	t.Setenv("STORE_SERVICE_URL", stores.URL)
+	t.Setenv("PRODUCT_SERVICE_URL", products.URL)
+	t.Setenv("ORDER_SERVICE_URL", orders.URL)
+
+	pr, err := NewProxyRouter(config.Default())
+	if err != nil {
+		t.Fatalf("NewProxyRouter: %v", err)
+	}
+	defer pr.Close()
+
+	gateway := httptest.NewServer(pr)
+	defer gateway.Close()
+
+	tests := []struct {
+		path     string
+		upstream string
+	}{
+		{"/api/stores", "store-service"},
+		{"/api/stores/42", "store-service"},
+		{"/api/store-owners", "store-service"},
+		{"/api/store-owners/7/stores", "store-service"},
+		{"/api/stores/42/products", "product-service"},
+		{"/api/products", "product-service"},
+		{"/api/products/9/images", "product-service"},
+		{"/api/orders", "order-service"},
+		{"/api/orders/3", "order-service"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get(gateway.URL + tt.path)
+			if err != nil {
+				t.Fatalf("GET %s: %v", tt.path, err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if got := resp.Header.Get("X-Upstream"); got != tt.upstream {
+				t.Errorf("upstream = %q, want %q", got, tt.upstream)
+			}
+			if string(body) != tt.path {
+				t.Errorf("upstream saw path %q, want %q", body, tt.path)
+			}
+		})
+	}
+}
+
+func TestProxyRouterUnknownPrefix(t *testing.T) {
+	pr, err := NewProxyRouter(config.Default())
+	if err != nil {
+		t.Fatalf("NewProxyRouter: %v", err)
+	}
+	defer pr.Close()
+
+	for _, path := range []string{"/api/storesX", "/api/unknown", "/"} {
+		rec := httptest.NewRecorder()
+		pr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, http.StatusNotFound)
+		}
+
+		var problem response.Problem
+		if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil || problem.Code != response.CodeNotFound {
+			t.Errorf("%s: problem = %+v (%v), want code not_found", path, problem, err)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != response.ProblemContentType {
+			t.Errorf("%s: Content-Type = %q, want %q", path, ct, response.ProblemContentType)
+		}
+	}
+}
+
+func TestForwardSignsRequests(t *testing.T) {
+	verifier, err := signing.NewVerifier([]signing.Key{testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls, verified atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/health" {
+			return
+		}
+		if err := verifier.Verify(r); err != nil {
+			t.Errorf("upstream rejected %s %s: %v", r.Method, r.URL, err)
+		} else {
+			verified.Add(1)
+		}
+		if body, _ := io.ReadAll(r.Body); string(body) != `{"name":"x"}` {
+			t.Errorf("upstream body = %q", body)
+		}
+		// Fail the first attempt so the retry must be signed afresh.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer upstream.Close()
+
+	pr := newResilienceRouter(t, upstream.URL+"/v1", config.ServiceConfig{
+		Retry: config.RetryConfig{Attempts: 2, Backoff: config.Duration(time.Millisecond)},
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/api/things/1?fields=a,b", strings.NewReader(`{"name":"x"}`))
+	req.Header.Set(signing.HeaderSignature, "forged")
+	rec := httptest.NewRecorder()
+	pr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || verified.Load() != 2 {
+		t.Fatalf("status %d with %d verified attempts, want 200 with 2", rec.Code, verified.Load())
+	}
+}
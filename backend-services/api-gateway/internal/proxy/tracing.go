@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"shared/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startAttemptSpan starts the client span for one proxy attempt. Its
+// context is what the upstream sees in traceparent, so each retry shows up
+// as a separate hop.
+func startAttemptSpan(ctx context.Context, service string, backend *Backend, attempt int) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "proxy "+service,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gateway.service", service),
+			attribute.String("server.address", backend.URL.Host),
+			attribute.Int("gateway.attempt", attempt),
+		),
+	)
+}
+
+func endAttemptSpan(span trace.Span, outcome *attemptOutcome) {
+	if outcome.status != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", outcome.status))
+	}
+	switch {
+	case outcome.err != nil:
+		span.RecordError(outcome.err)
+		span.SetStatus(codes.Error, outcome.err.Error())
+	case outcome.status >= http.StatusInternalServerError:
+		span.SetStatus(codes.Error, http.StatusText(outcome.status))
+	}
+	span.End()
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"api-gateway/internal/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestForwardPropagatesTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			got = r.Header.Clone()
+		}
+	}))
+	defer upstream.Close()
+
+	pr := newResilienceRouter(t, upstream.URL, config.ServiceConfig{})
+
+	const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/api/things", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier{"Traceparent": {incoming}})
+	pr.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	if got.Get("X-Request-ID") != "req-1" {
+		t.Errorf("X-Request-ID = %q, want req-1", got.Get("X-Request-ID"))
+	}
+
+	upstreamCtx := propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(got))
+	sc := trace.SpanContextFromContext(upstreamCtx)
+	if sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("upstream traceparent = %q, want the incoming trace", got.Get("Traceparent"))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "proxy svc" {
+		t.Fatalf("spans = %v, want one proxy svc span", spans)
+	}
+	if sc.SpanID() != spans[0].SpanContext().SpanID() {
+		t.Errorf("upstream parent = %s, want the proxy span %s", sc.SpanID(), spans[0].SpanContext().SpanID())
+	}
+}
@@ -2,24 +2,79 @@ package routes
 
 import (
 	"order-management/internal/handlers"
-	"order-management/internal/middleware"
+	"order-management/internal/jobs"
+	"order-management/internal/repository/postgres"
+	"order-management/internal/service/impl"
+	"shared/auth"
+	"shared/catalog"
+	"shared/stores"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(router *mux.Router, db *gorm.DB) error {
-	authMiddleware, err := middleware.NewAuthMiddleware()
+// Handlers are the handlers served by the order management service.
+type Handlers struct {
+	Order   *handlers.OrderHandler
+	Payment *handlers.PaymentHandler
+}
+
+// SetupRoutes registers the service's routes and returns the sweeper for
+// its orders, which the caller starts.
+func SetupRoutes(router *mux.Router, db *gorm.DB) (*jobs.Sweeper, error) {
+	authMiddleware, err := auth.NewMiddleware()
+	if err != nil {
+		return nil, err
+	}
+
+	// Sellers are matched to an order's store through store-management
+	storeResolver, err := stores.NewResolverFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	// Users owning a store act as store owners
+	authMiddleware.PromoteStoreOwners(storeResolver)
+	// Orders are priced from product-catalog, which also holds their stock
+	products, err := catalog.NewClientFromEnv()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	orders := postgres.NewOrderRepository(db)
+	payments := postgres.NewPaymentRepository(db)
+	history := postgres.NewStatusHistoryRepository(db)
+	tx := postgres.NewTransactor(db)
 
-	orderHandler := handlers.NewOrderHandler(db)
+	orderService := impl.NewOrderService(orders, history, tx, storeResolver, products, products)
+	sweeper, err := jobs.NewSweeperFromEnv(orderService)
+	if err != nil {
+		return nil, err
+	}
 
+	Register(router, authMiddleware, Handlers{
+		Order:   handlers.NewOrderHandler(orderService),
+		Payment: handlers.NewPaymentHandler(impl.NewPaymentService(orders, payments, tx, storeResolver, products)),
+	})
+	return sweeper, nil
+}
+
+// Register adds the routes served by h, guarded by authMiddleware.
+func Register(router *mux.Router, authMiddleware *auth.Middleware, h Handlers) {
 	// Order routes
-	router.HandleFunc("/api/orders", authMiddleware.ValidateToken(orderHandler.CreateOrder)).Methods("POST")
-	router.HandleFunc("/api/orders", authMiddleware.ValidateToken(orderHandler.GetUserOrders)).Methods("GET")
-	router.HandleFunc("/api/orders/{orderId}", authMiddleware.ValidateToken(orderHandler.GetOrderByID)).Methods("GET")
+	router.HandleFunc("/api/orders", authMiddleware.Require(auth.PermOrderCreate, h.Order.CreateOrder)).Methods("POST")
+	router.HandleFunc("/api/orders", authMiddleware.Require(auth.PermOrderRead, h.Order.GetUserOrders)).Methods("GET")
+	router.HandleFunc("/api/orders/{orderId}", authMiddleware.Require(auth.PermOrderRead, h.Order.GetOrderByID)).Methods("GET")
+
+	// Status routes; customers may only cancel, and only while pending
+	router.HandleFunc("/api/orders/{orderId}/history", authMiddleware.Require(auth.PermOrderRead, h.Order.GetOrderHistory)).Methods("GET")
+	router.HandleFunc("/api/orders/{orderId}/confirm", authMiddleware.Require(auth.PermOrderManage, h.Order.ConfirmOrder)).Methods("POST")
+	router.HandleFunc("/api/orders/{orderId}/ship", authMiddleware.Require(auth.PermOrderManage, h.Order.ShipOrder)).Methods("POST")
+	router.HandleFunc("/api/orders/{orderId}/deliver", authMiddleware.Require(auth.PermOrderManage, h.Order.DeliverOrder)).Methods("POST")
+	router.HandleFunc("/api/orders/{orderId}/cancel", authMiddleware.Require(auth.PermOrderCreate, h.Order.CancelOrder)).Methods("POST")
 
-	return nil
-}
\ No newline at end of file
+	// Payment routes; settling a payment is up to the order's store
+	router.HandleFunc("/api/orders/{orderId}/payments", authMiddleware.Require(auth.PermOrderCreate, h.Payment.RecordPayment)).Methods("POST")
+	router.HandleFunc("/api/orders/{orderId}/payments", authMiddleware.Require(auth.PermOrderRead, h.Payment.GetPayments)).Methods("GET")
+	router.HandleFunc("/api/orders/{orderId}/payments/{paymentId}/confirm", authMiddleware.Require(auth.PermOrderManage, h.Payment.ConfirmPayment)).Methods("POST")
+	router.HandleFunc("/api/orders/{orderId}/payments/{paymentId}/fail", authMiddleware.Require(auth.PermOrderManage, h.Payment.FailPayment)).Methods("POST")
+	router.HandleFunc("/api/orders/{orderId}/payments/{paymentId}/refund", authMiddleware.Require(auth.PermOrderManage, h.Payment.RefundPayment)).Methods("POST")
+}
@@ -2,43 +2,89 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"os/signal"
 
 	"order-management/api/routes"
-	"order-management/internal/database"
-	"order-management/internal/middleware"
-	"syscall"
-	"time"
+	"order-management/internal/database/migrations"
+	"shared/database"
+	"shared/logging"
+	"shared/metrics"
+	"shared/middleware"
+	"shared/server"
+	"shared/signing"
+	"shared/tracing"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	logging.Init("order-management")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found")
+		slog.Warn(".env file not found")
+	}
+
+	// "migrate [up|down [steps]|status]" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := database.MigrateCommand("order-management", migrations.FS, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
 	}
 
 	// Initialize database connection
-	dbConn, err := database.InitDB()
+	dbConn, err := database.InitDB("order-management", migrations.FS)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer dbConn.Close()
-	log.Println("Database connected")
+	slog.Info("Database connected")
+	metrics.RegisterDB(dbConn.SQLDB, "order-management")
+
+	// Export spans when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Init(context.Background(), "order-management")
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Create router
 	router := mux.NewRouter()
 
+	// Link logs and spans to the gateway's request
+	router.Use(middleware.RequestIDMiddleware)
+	router.Use(tracing.Middleware(middleware.MuxRoutes))
+	router.Use(middleware.LoggingMiddleware)
+	router.Use(metrics.Middleware(middleware.MuxRoutes))
+
 	// Setup routes
-	routes.SetupRoutes(router, dbConn.GormDB)
+	sweeper, err := routes.SetupRoutes(router, dbConn.GormDB)
+	if err != nil {
+		log.Fatalf("Failed to set up routes: %v", err)
+	}
 
-	router.Use(middleware.ServiceAuthMiddleware)
+	// Cancel unpaid orders and settle their stock in the background
+	sweeper.Start()
+	defer sweeper.Stop()
+
+	// Only the gateway and other services holding a signing key may call in
+	serviceAuth, err := signing.NewVerifierFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize request signing: %v", err)
+	}
+	router.Use(serviceAuth.Middleware)
+
+	// Metrics are scraped and health probed directly, without a request
+	// signature
+	handler := http.NewServeMux()
+	handler.Handle("/metrics", metrics.Handler())
+	handler.Handle("/health", database.HealthHandler(dbConn.SQLDB))
+	handler.Handle("/", router)
 
 	// Get port
 	port := os.Getenv("PORT")
@@ -46,29 +92,8 @@ func main() {
 		port = "8003" // Different port from product-catalog service
 	}
 
-	// Create server
-	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+	// Serve until SIGINT or SIGTERM, then drain in-flight requests
+	if err := server.Run(":"+port, handler); err != nil {
+		log.Fatalf("Server error: %v", err)
 	}
-
-	// Handle graceful shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-
-	// Start server
-	go func() {
-		fmt.Printf("Server starting on port %s\n", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
-		}
-	}()
-
-	// Wait for stop signal
-	<-stop
-	log.Println("Shutting down server...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	server.Shutdown(ctx)
 }
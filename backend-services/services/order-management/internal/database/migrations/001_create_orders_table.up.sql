@@ -0,0 +1,15 @@
+-- Databases set up by GORM's AutoMigrate before versioned migrations
+-- already have the initial tables, so these migrations only create what
+-- is missing.
+CREATE TABLE IF NOT EXISTS orders (
+    id                  uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id             text NOT NULL,
+    order_number        text NOT NULL UNIQUE,
+    status              varchar(20) NOT NULL DEFAULT 'pending',
+    total_amount        decimal(10,2) NOT NULL,
+    shipping_address_id uuid NOT NULL,
+    created_at          timestamptz,
+    updated_at          timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS order_items (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id    uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    product_id  uuid NOT NULL,
+    quantity    bigint NOT NULL,
+    unit_price  decimal(10,2) NOT NULL,
+    total_price decimal(10,2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_store_id;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS amount_paid;
+ALTER TABLE orders DROP COLUMN IF EXISTS store_id;
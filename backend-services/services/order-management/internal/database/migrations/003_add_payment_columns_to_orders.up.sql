@@ -0,0 +1,6 @@
+-- Payments are confirmed by the store that received the order and are
+-- summed into amount_paid.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS store_id text;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS amount_paid decimal(10,2) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_orders_store_id ON orders (store_id);
@@ -0,0 +1,14 @@
+CREATE TABLE payments (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id       uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount         decimal(10,2) NOT NULL CHECK (amount > 0),
+    payment_method varchar(20) NOT NULL,
+    status         varchar(20) NOT NULL DEFAULT 'pending',
+    transaction_id text,
+    notes          text,
+    recorded_by    text NOT NULL,
+    created_at     timestamptz,
+    updated_at     timestamptz
+);
+
+CREATE INDEX idx_payments_order_id ON payments (order_id);
@@ -0,0 +1,13 @@
+-- Every status change, including placing the order, with who made it
+-- and why.
+CREATE TABLE order_status_history (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id    uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status varchar(20),
+    to_status   varchar(20) NOT NULL,
+    changed_by  text NOT NULL,
+    reason      text,
+    created_at  timestamptz
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history (order_id);
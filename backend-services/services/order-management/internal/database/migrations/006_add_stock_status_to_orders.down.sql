@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_reserved_stock;
+DROP INDEX IF EXISTS idx_orders_pending_created_at;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS stock_status;
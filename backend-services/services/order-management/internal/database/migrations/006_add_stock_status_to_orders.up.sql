@@ -0,0 +1,8 @@
+-- Tracks the stock reserved for each order in product-catalog. Orders
+-- placed before reservations have none to settle.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS stock_status varchar(20) NOT NULL DEFAULT 'none';
+
+-- The sweeper looks for unpaid orders past the payment timeout and for
+-- orders whose stock is still to be released or committed
+CREATE INDEX IF NOT EXISTS idx_orders_pending_created_at ON orders (created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_orders_reserved_stock ON orders (updated_at) WHERE stock_status = 'reserved';
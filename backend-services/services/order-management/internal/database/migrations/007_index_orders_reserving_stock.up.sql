@@ -0,0 +1,3 @@
+-- Orders are saved before their stock is reserved. The sweeper looks for
+-- those whose reservation never finished.
+CREATE INDEX IF NOT EXISTS idx_orders_reserving_stock ON orders (created_at) WHERE stock_status = 'reserving';
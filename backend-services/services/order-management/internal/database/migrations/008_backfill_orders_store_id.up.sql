@@ -0,0 +1,18 @@
+-- Orders placed before 003 have no store_id, so their sellers cannot see
+-- them. All items of an order come from one store; take it from the
+-- catalog's products when they share this database. Orders whose products
+-- are gone keep a NULL store_id.
+DO $$
+BEGIN
+    IF to_regclass('products') IS NOT NULL THEN
+        UPDATE orders o
+        SET store_id = items.store_id
+        FROM (
+            SELECT DISTINCT ON (oi.order_id) oi.order_id, p.store_id
+            FROM order_items oi
+            JOIN products p ON p.id = oi.product_id
+            ORDER BY oi.order_id
+        ) items
+        WHERE o.id = items.order_id AND o.store_id IS NULL;
+    END IF;
+END $$;
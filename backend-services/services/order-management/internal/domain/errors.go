@@ -0,0 +1,41 @@
+package domain
+
+import "errors"
+
+// Errors returned by repositories and services. Handlers map them to
+// HTTP statuses.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrForbidden         = errors.New("access denied")
+	ErrStoreLookup       = errors.New("failed to verify store access")
+	ErrInvalidPayment    = errors.New("payment needs a positive amount and a supported method")
+	ErrOverpayment       = errors.New("payment exceeds the order's outstanding balance")
+	ErrOrderCancelled    = errors.New("order is cancelled")
+	ErrPaymentTransition = errors.New("payment cannot change from its current status")
+	ErrStatusTransition  = errors.New("order cannot move to the requested status")
+	ErrOrderNotPending   = errors.New("order is no longer pending")
+	ErrOrderPaid         = errors.New("order has completed payments to refund first")
+	ErrInvalidOrder      = errors.New("order needs a shipping address and items with a product and a positive quantity")
+	ErrProductNotFound   = errors.New("product does not exist")
+	ErrProductInactive   = errors.New("product is not for sale")
+	ErrMixedStores       = errors.New("order items come from several stores")
+	ErrCatalogLookup     = errors.New("failed to look up products")
+	ErrOutOfStock        = errors.New("product is out of stock")
+	ErrInventory         = errors.New("failed to reserve stock")
+	ErrStockChanged      = errors.New("order stock status changed concurrently")
+)
+
+// ProductError ties ErrProductNotFound, ErrProductInactive or
+// ErrOutOfStock to the product concerned.
+type ProductError struct {
+	ProductID string
+	Err       error
+}
+
+func (e *ProductError) Error() string {
+	return e.Err.Error() + ": " + e.ProductID
+}
+
+func (e *ProductError) Unwrap() error {
+	return e.Err
+}
@@ -14,12 +14,49 @@ const (
 	Cancelled OrderStatus = "cancelled"
 )
 
+// orderTransitions lists the statuses each status may move to. Delivered
+// and cancelled orders are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	Pending:   {Confirmed, Cancelled},
+	Confirmed: {Shipped, Cancelled},
+	Shipped:   {Delivered},
+}
+
+// CanTransitionTo reports whether an order may move from s to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// StockStatus tracks the stock reserved for an order in product-catalog.
+// Reserved stock is released when the order is cancelled and committed
+// when it ships.
+type StockStatus string
+
+const (
+	// StockNone is for orders placed before stock was reserved.
+	StockNone StockStatus = "none"
+	// StockReserving is for orders saved but whose stock is still being
+	// reserved.
+	StockReserving StockStatus = "reserving"
+	StockReserved  StockStatus = "reserved"
+	StockReleased  StockStatus = "released"
+	StockCommitted StockStatus = "committed"
+)
+
 type Order struct {
 	ID                string      `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	UserID            string      `gorm:"not null"`
+	StoreID           string      `gorm:"index"`
 	OrderNumber       string      `gorm:"not null;unique"`
 	Status            OrderStatus `gorm:"type:varchar(20);not null;default:'pending'"`
 	TotalAmount       float64     `gorm:"type:decimal(10,2);not null"`
+	AmountPaid        float64     `gorm:"type:decimal(10,2);not null;default:0"`
+	StockStatus       StockStatus `gorm:"type:varchar(20);not null;default:'none'"`
 	ShippingAddressID string      `gorm:"type:uuid;not null"`
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+)
+
+// OrderStatusHistory records one change of an order's status. FromStatus
+// is empty for the entry made when the order is placed.
+type OrderStatusHistory struct {
+	ID         string      `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID    string      `gorm:"type:uuid;not null;index"`
+	FromStatus OrderStatus `gorm:"type:varchar(20)"`
+	ToStatus   OrderStatus `gorm:"type:varchar(20);not null"`
+	ChangedBy  string      `gorm:"not null"`
+	Reason     string      `gorm:"type:text"`
+	CreatedAt  time.Time
+}
+
+// TableName keeps GORM from pluralizing the table name.
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+)
+
+type PaymentMethod string
+
+const (
+	WhatsApp       PaymentMethod = "whatsapp"
+	CashOnDelivery PaymentMethod = "cod"
+	BankTransfer   PaymentMethod = "bank_transfer"
+)
+
+// Valid reports whether m is a supported payment method.
+func (m PaymentMethod) Valid() bool {
+	switch m {
+	case WhatsApp, CashOnDelivery, BankTransfer:
+		return true
+	}
+	return false
+}
+
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "pending"
+	PaymentCompleted PaymentStatus = "completed"
+	PaymentFailed    PaymentStatus = "failed"
+	PaymentRefunded  PaymentStatus = "refunded"
+)
+
+// Payment is one attempt to pay for part or all of an order. Only
+// completed payments count towards Order.AmountPaid.
+type Payment struct {
+	ID            string        `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID       string        `gorm:"type:uuid;not null"`
+	Amount        float64       `gorm:"type:decimal(10,2);not null"`
+	Method        PaymentMethod `gorm:"column:payment_method;type:varchar(20);not null"`
+	Status        PaymentStatus `gorm:"type:varchar(20);not null;default:'pending'"`
+	TransactionID string
+	Notes         string `gorm:"type:text"`
+	RecordedBy    string `gorm:"not null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
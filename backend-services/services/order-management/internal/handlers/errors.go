@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"order-management/internal/domain"
+	"shared/response"
+)
+
+// Codes for the errors clients may handle specially.
+const (
+	codeOrderAccessDenied response.Code = "order_access_denied"
+	codeInvalidPayment    response.Code = "invalid_payment"
+	codeOverpayment       response.Code = "overpayment"
+	codeOrderCancelled    response.Code = "order_cancelled"
+	codePaymentTransition response.Code = "invalid_payment_transition"
+	codeStatusTransition  response.Code = "invalid_status_transition"
+	codeOrderNotPending   response.Code = "order_not_pending"
+	codeOrderPaid         response.Code = "order_paid"
+	codeInvalidOrder      response.Code = "invalid_order"
+	codeUnknownProduct    response.Code = "unknown_product"
+	codeProductInactive   response.Code = "product_unavailable"
+	codeMixedStores       response.Code = "mixed_stores"
+	codeOutOfStock        response.Code = "out_of_stock"
+)
+
+// writeServiceError responds to an error returned by a service. notFound
+// and failed are the messages for a missing record and an unexpected
+// failure.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, notFound, failed string) {
+	var problem *response.Problem
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		problem = response.NewProblem(http.StatusNotFound, response.CodeNotFound, notFound)
+	case errors.Is(err, domain.ErrForbidden):
+		problem = response.NewProblem(http.StatusForbidden, codeOrderAccessDenied, "Forbidden - Only the order's store may do this")
+	case errors.Is(err, domain.ErrStoreLookup):
+		slog.ErrorContext(r.Context(), "Failed to resolve caller's stores", "error", err)
+		problem = response.NewProblem(http.StatusBadGateway, response.CodeBadGateway, "Failed to verify store access")
+	case errors.Is(err, domain.ErrInvalidPayment):
+		problem = response.NewProblem(http.StatusBadRequest, codeInvalidPayment, "Payment needs a positive amount and a method of whatsapp, cod or bank_transfer")
+	case errors.Is(err, domain.ErrOverpayment):
+		problem = response.NewProblem(http.StatusConflict, codeOverpayment, "Payment exceeds the order's outstanding balance")
+	case errors.Is(err, domain.ErrOrderCancelled):
+		problem = response.NewProblem(http.StatusConflict, codeOrderCancelled, "Order is cancelled")
+	case errors.Is(err, domain.ErrPaymentTransition):
+		problem = response.NewProblem(http.StatusConflict, codePaymentTransition, "Payment cannot change from its current status")
+	case errors.Is(err, domain.ErrStatusTransition):
+		problem = response.NewProblem(http.StatusConflict, codeStatusTransition, "Order cannot move to that status from its current one")
+	case errors.Is(err, domain.ErrOrderNotPending):
+		problem = response.NewProblem(http.StatusConflict, codeOrderNotPending, "Order can no longer be cancelled by the customer")
+	case errors.Is(err, domain.ErrOrderPaid):
+		problem = response.NewProblem(http.StatusConflict, codeOrderPaid, "Order has completed payments; refund them to cancel it")
+	case errors.Is(err, domain.ErrInvalidOrder):
+		problem = response.NewProblem(http.StatusBadRequest, codeInvalidOrder, "Order needs a shipping address and items with a product and a positive quantity")
+	case errors.Is(err, domain.ErrProductNotFound):
+		problem = productProblem(err, http.StatusBadRequest, codeUnknownProduct, "Product does not exist")
+	case errors.Is(err, domain.ErrProductInactive):
+		problem = productProblem(err, http.StatusConflict, codeProductInactive, "Product is no longer for sale")
+	case errors.Is(err, domain.ErrMixedStores):
+		problem = response.NewProblem(http.StatusBadRequest, codeMixedStores, "All items of an order must come from the same store")
+	case errors.Is(err, domain.ErrCatalogLookup):
+		slog.ErrorContext(r.Context(), "Failed to look up products", "error", err)
+		problem = response.NewProblem(http.StatusBadGateway, response.CodeBadGateway, "Failed to look up products")
+	case errors.Is(err, domain.ErrOutOfStock):
+		problem = productProblem(err, http.StatusConflict, codeOutOfStock, "Not enough stock of the product is available")
+	case errors.Is(err, domain.ErrInventory):
+		slog.ErrorContext(r.Context(), "Failed to reserve stock", "error", err)
+		problem = response.NewProblem(http.StatusBadGateway, response.CodeBadGateway, "Failed to reserve stock")
+	default:
+		slog.ErrorContext(r.Context(), failed, "error", err)
+		problem = response.NewProblem(http.StatusInternalServerError, response.CodeInternal, failed)
+	}
+	response.WriteError(w, problem)
+}
+
+// productProblem names the product concerned by err in a product_id
+// member.
+func productProblem(err error, status int, code response.Code, detail string) *response.Problem {
+	problem := response.NewProblem(status, code, detail)
+	var productErr *domain.ProductError
+	if errors.As(err, &productErr) {
+		problem.Extensions = map[string]interface{}{"product_id": productErr.ProductID}
+	}
+	return problem
+}
@@ -0,0 +1,194 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"order-management/api/routes"
+	"order-management/internal/domain"
+	"order-management/internal/handlers"
+	"order-management/internal/repository/memory"
+	"order-management/internal/service/impl"
+	"shared/auth"
+	"shared/auth/authtest"
+	"shared/catalog"
+	"shared/response"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	alice    = auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+	bob      = auth.Claims{ID: "bob", Role: auth.RoleStoreOwner}
+	customer = auth.Claims{ID: "dave", Role: auth.RoleCustomer}
+	stranger = auth.Claims{ID: "erin", Role: auth.RoleCustomer}
+	admin    = auth.Claims{ID: "root", Role: auth.RoleAdmin}
+)
+
+// fakeStores gives alice store-a and bob store-b.
+type fakeStores struct {
+	fail bool
+}
+
+func (f *fakeStores) IsStoreOwner(ctx context.Context, userID string) (bool, error) {
+	if f.fail {
+		return false, errors.New("store-management unavailable")
+	}
+	return userID == "alice" || userID == "bob", nil
+}
+
+func (f *fakeStores) CanManage(ctx context.Context, claims auth.Claims, storeID string) (bool, error) {
+	if f.fail {
+		return false, errors.New("store-management unavailable")
+	}
+	if claims.Can(auth.PermStoreManageAny) {
+		return true, nil
+	}
+	return map[string]string{"alice": "store-a", "bob": "store-b"}[claims.ID] == storeID, nil
+}
+
+// Catalog products: a teapot and a retired vase in store-a, a rug in
+// store-b.
+const (
+	teapot  = "00000000-0000-0000-0000-00000000000a"
+	vase    = "00000000-0000-0000-0000-00000000000b"
+	rug     = "00000000-0000-0000-0000-00000000000c"
+	address = "00000000-0000-0000-0000-0000000000ad"
+)
+
+type fakeCatalog struct {
+	fail bool
+}
+
+func (f *fakeCatalog) Products(ctx context.Context, ids []string) ([]catalog.Product, error) {
+	if f.fail {
+		return nil, errors.New("product-catalog unavailable")
+	}
+	known := map[string]catalog.Product{
+		teapot: {ID: teapot, StoreID: "store-a", Name: "Teapot", Price: 120, IsActive: true},
+		vase:   {ID: vase, StoreID: "store-a", Name: "Vase", Price: 45},
+		rug:    {ID: rug, StoreID: "store-b", Name: "Rug", Price: 900, IsActive: true},
+	}
+	var products []catalog.Product
+	for _, id := range ids {
+		if p, ok := known[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+// fakeInventory has five of every product in stock.
+type fakeInventory struct {
+	fail bool
+}
+
+func (f *fakeInventory) Reserve(ctx context.Context, productID, orderID string, quantity int) error {
+	if f.fail {
+		return errors.New("product-catalog unavailable")
+	}
+	if quantity > 5 {
+		return catalog.ErrInsufficientStock
+	}
+	return nil
+}
+
+func (f *fakeInventory) Release(ctx context.Context, productID, orderID string) error {
+	if f.fail {
+		return errors.New("product-catalog unavailable")
+	}
+	return nil
+}
+
+func (f *fakeInventory) Commit(ctx context.Context, productID, orderID string) error {
+	return f.Release(ctx, productID, orderID)
+}
+
+// fixture serves the routes from in-memory repositories holding order-1,
+// a pending order of 100 placed by dave with store-a.
+type fixture struct {
+	orders   *memory.OrderRepository
+	payments *memory.PaymentRepository
+	history  *memory.StatusHistoryRepository
+	stores   *fakeStores
+	catalog  *fakeCatalog
+	stock    *fakeInventory
+	issuer   *authtest.Issuer
+	router   *mux.Router
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	f := &fixture{
+		orders:   memory.NewOrderRepository(),
+		payments: memory.NewPaymentRepository(),
+		history:  memory.NewStatusHistoryRepository(),
+		stores:   &fakeStores{},
+		catalog:  &fakeCatalog{},
+		stock:    &fakeInventory{},
+		issuer:   authtest.NewIssuer(t),
+		router:   mux.NewRouter(),
+	}
+	order := domain.Order{ID: "order-1", UserID: "dave", StoreID: "store-a", OrderNumber: "ORD-1", TotalAmount: 100}
+	if err := f.orders.Create(context.Background(), &order); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := memory.NewTransactor(f.orders, f.payments, f.history)
+	routes.Register(f.router, f.issuer.Middleware().PromoteStoreOwners(f.stores), routes.Handlers{
+		Order:   handlers.NewOrderHandler(impl.NewOrderService(f.orders, f.history, tx, f.stores, f.catalog, f.stock)),
+		Payment: handlers.NewPaymentHandler(impl.NewPaymentService(f.orders, f.payments, tx, f.stores, f.stock)),
+	})
+	return f
+}
+
+// serve sends req through the router, authenticated as claims unless
+// they are empty.
+func (f *fixture) serve(t *testing.T, req *http.Request, claims auth.Claims) *httptest.ResponseRecorder {
+	t.Helper()
+	if claims.ID != "" {
+		f.issuer.Authorize(t, req, claims)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// status returns order-1's current status.
+func (f *fixture) status(t *testing.T) domain.OrderStatus {
+	t.Helper()
+	order, err := f.orders.FindByID(context.Background(), "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order.Status
+}
+
+// decodeData decodes the data of a success envelope into v.
+func decodeData(rec *httptest.ResponseRecorder, v interface{}) error {
+	return json.NewDecoder(rec.Body).Decode(&response.Envelope{Data: v})
+}
+
+// decodeProblem decodes an error response.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) response.Problem {
+	t.Helper()
+	var problem response.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Header().Get("Content-Type") != response.ProblemContentType || problem.Status != rec.Code {
+		t.Errorf("%d %s response: %+v", rec.Code, rec.Header().Get("Content-Type"), problem)
+	}
+	return problem
+}
+
+func jsonRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
@@ -1,97 +1,152 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"order-management/internal/domain"
-	"order-management/internal/middleware"
+	"order-management/internal/metrics"
+	services "order-management/internal/service/interfaces"
+	"shared/auth"
+	"shared/response"
 
 	"github.com/gorilla/mux"
-	"gorm.io/gorm"
 )
 
 type OrderHandler struct {
-	db *gorm.DB
+	orders services.OrderService
 }
 
-func NewOrderHandler(db *gorm.DB) *OrderHandler {
-	return &OrderHandler{db: db}
+func NewOrderHandler(orders services.OrderService) *OrderHandler {
+	return &OrderHandler{orders: orders}
 }
 
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
-	claims, ok := middleware.GetClaims(r.Context())
+	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	var order domain.Order
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	// Prices, totals and the store come from the catalog, so the body
+	// may hold nothing else
+	var req struct {
+		ShippingAddressID string `json:"shipping_address_id"`
+		Items             []struct {
+			ProductID string `json:"product_id"`
+			Quantity  int    `json:"quantity"`
+		} `json:"items"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	order.UserID = claims.ID
+	input := services.OrderInput{ShippingAddressID: req.ShippingAddressID}
+	for _, item := range req.Items {
+		input.Items = append(input.Items, services.OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
 
-	tx := h.db.Begin()
-	if err := tx.Create(&order).Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to create order", http.StatusInternalServerError)
+	order, err := h.orders.Create(r.Context(), claims, input)
+	if err != nil {
+		writeServiceError(w, r, err, "Order not found", "Failed to create order")
 		return
 	}
+	metrics.OrdersCreated.Inc()
+
+	response.JSON(w, http.StatusCreated, order)
+}
 
-	if err := tx.Create(&order.OrderItems).Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to create order items", http.StatusInternalServerError)
+func (h *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+	orders, err := h.orders.ListMine(r.Context(), claims)
+	if err != nil {
+		writeServiceError(w, r, err, "Order not found", "Failed to fetch orders")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	response.JSON(w, http.StatusOK, orders)
 }
 
-func (h *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
-	claims, ok := middleware.GetClaims(r.Context())
+func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	var orders []domain.Order
-	if err := h.db.Preload("OrderItems").Where("user_id = ?", claims.ID).Find(&orders).Error; err != nil {
-		http.Error(w, "Failed to fetch orders", http.StatusInternalServerError)
+	order, err := h.orders.Get(r.Context(), claims, mux.Vars(r)["orderId"])
+	if err != nil {
+		writeServiceError(w, r, err, "Order not found", "Failed to fetch order")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(orders)
+	response.JSON(w, http.StatusOK, order)
 }
 
-func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
-	claims, ok := middleware.GetClaims(r.Context())
+func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	history, err := h.orders.History(r.Context(), claims, mux.Vars(r)["orderId"])
+	if err != nil {
+		writeServiceError(w, r, err, "Order not found", "Failed to fetch order history")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, history)
+}
+
+func (h *OrderHandler) ConfirmOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.orders.Confirm, "Failed to confirm order")
+}
+
+func (h *OrderHandler) ShipOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.orders.Ship, "Failed to ship order")
+}
+
+func (h *OrderHandler) DeliverOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.orders.Deliver, "Failed to mark order as delivered")
+}
+
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.orders.Cancel, "Failed to cancel order")
+}
+
+// changeStatus applies change with the reason from the optional request
+// body and responds with the updated order.
+func (h *OrderHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error), failed string) {
+	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	params := mux.Vars(r)
-	orderID := params["orderId"]
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
 
-	var order domain.Order
-	if err := h.db.Preload("OrderItems").Where("id = ? AND user_id = ?", orderID, claims.ID).First(&order).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Order not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to fetch order", http.StatusInternalServerError)
+	order, err := change(r.Context(), claims, mux.Vars(r)["orderId"], req.Reason)
+	if err != nil {
+		writeServiceError(w, r, err, "Order not found", failed)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
-}
\ No newline at end of file
+	response.JSON(w, http.StatusOK, order)
+}
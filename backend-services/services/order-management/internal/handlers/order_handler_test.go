@@ -0,0 +1,245 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"order-management/internal/domain"
+	"shared/auth"
+	"shared/response"
+	"testing"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   auth.Claims
+		from     domain.OrderStatus
+		action   string
+		want     int
+		wantCode response.Code
+		wantTo   domain.OrderStatus
+	}{
+		{"seller confirms", alice, domain.Pending, "confirm", http.StatusOK, "", domain.Confirmed},
+		{"seller with the user role confirms", auth.Claims{ID: "alice", Role: "user"}, domain.Pending, "confirm", http.StatusOK, "", domain.Confirmed},
+		{"admin confirms", admin, domain.Pending, "confirm", http.StatusOK, "", domain.Confirmed},
+		{"seller ships confirmed", alice, domain.Confirmed, "ship", http.StatusOK, "", domain.Shipped},
+		{"seller delivers shipped", alice, domain.Shipped, "deliver", http.StatusOK, "", domain.Delivered},
+		{"seller ships pending", alice, domain.Pending, "ship", http.StatusConflict, "invalid_status_transition", domain.Pending},
+		{"seller confirms twice", alice, domain.Confirmed, "confirm", http.StatusConflict, "invalid_status_transition", domain.Confirmed},
+		{"another store confirms", bob, domain.Pending, "confirm", http.StatusNotFound, response.CodeNotFound, domain.Pending},
+		{"customer confirms", customer, domain.Pending, "confirm", http.StatusForbidden, response.CodeForbidden, domain.Pending},
+		{"customer cancels pending", customer, domain.Pending, "cancel", http.StatusOK, "", domain.Cancelled},
+		{"customer cancels confirmed", customer, domain.Confirmed, "cancel", http.StatusConflict, "order_not_pending", domain.Confirmed},
+		{"stranger cancels", stranger, domain.Pending, "cancel", http.StatusNotFound, response.CodeNotFound, domain.Pending},
+		{"seller cancels confirmed", alice, domain.Confirmed, "cancel", http.StatusOK, "", domain.Cancelled},
+		{"seller cancels shipped", alice, domain.Shipped, "cancel", http.StatusConflict, "invalid_status_transition", domain.Shipped},
+		{"seller revives cancelled", alice, domain.Cancelled, "confirm", http.StatusConflict, "invalid_status_transition", domain.Cancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			order, _ := f.orders.FindByID(context.Background(), "order-1")
+			order.Status = tt.from
+			f.orders.Update(context.Background(), &order)
+
+			rec := f.serve(t, jsonRequest(http.MethodPost, "/api/orders/order-1/"+tt.action, `{"reason":"Checked"}`), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				if problem := decodeProblem(t, rec); problem.Code != tt.wantCode {
+					t.Errorf("code = %s, want %s", problem.Code, tt.wantCode)
+				}
+			}
+			if status := f.status(t); status != tt.wantTo {
+				t.Errorf("order status = %s, want %s", status, tt.wantTo)
+			}
+
+			history, _ := f.history.ListByOrderID(context.Background(), "order-1")
+			if tt.want != http.StatusOK {
+				if len(history) != 0 {
+					t.Errorf("refused transition recorded %+v", history)
+				}
+				return
+			}
+			if len(history) != 1 || history[0].FromStatus != tt.from || history[0].ToStatus != tt.wantTo || history[0].ChangedBy != tt.claims.ID || history[0].Reason != "Checked" {
+				t.Errorf("history = %+v", history)
+			}
+		})
+	}
+}
+
+func TestTransitionWithoutBody(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodPost, "/api/orders/order-1/confirm", nil), alice)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var order struct{ Status domain.OrderStatus }
+	decodeData(rec, &order)
+	if order.Status != domain.Confirmed {
+		t.Errorf("order = %+v", order)
+	}
+
+	rec = f.serve(t, jsonRequest(http.MethodPost, "/api/orders/order-1/ship", `{"reason":`), alice)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid body status = %d, want 400", rec.Code)
+	}
+}
+
+func TestOrderHistory(t *testing.T) {
+	f := newFixture(t)
+	f.serve(t, httptest.NewRequest(http.MethodPost, "/api/orders/order-1/confirm", nil), alice)
+	f.serve(t, jsonRequest(http.MethodPost, "/api/orders/order-1/ship", `{"reason":"Sent with Amana"}`), alice)
+
+	tests := []struct {
+		name   string
+		claims auth.Claims
+		want   int
+	}{
+		{"customer", customer, http.StatusOK},
+		{"seller", alice, http.StatusOK},
+		{"another store", bob, http.StatusNotFound},
+		{"stranger", stranger, http.StatusNotFound},
+		{"anonymous", auth.Claims{}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/orders/order-1/history", nil), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			var history []struct {
+				FromStatus, ToStatus domain.OrderStatus
+				ChangedBy, Reason    string
+			}
+			decodeData(rec, &history)
+			if len(history) != 2 || history[1].FromStatus != domain.Confirmed || history[1].ToStatus != domain.Shipped || history[1].Reason != "Sent with Amana" {
+				t.Errorf("history = %+v", history)
+			}
+		})
+	}
+}
+
+func TestStoreLookupFailure(t *testing.T) {
+	f := newFixture(t)
+	f.stores.fail = true
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodPost, "/api/orders/order-1/confirm", nil), alice)
+	if rec.Code != http.StatusBadGateway || f.status(t) != domain.Pending {
+		t.Errorf("status = %d, order %s: %s", rec.Code, f.status(t), rec.Body.String())
+	}
+}
+
+func TestCreateOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		failing   bool
+		want      int
+		wantCode  response.Code
+		wantTotal float64
+	}{
+		{"priced by the catalog", `{"shipping_address_id":"` + address + `","items":[{"product_id":"` + teapot + `","quantity":2}]}`, false, http.StatusCreated, "", 240},
+		{"client sets a price", `{"shipping_address_id":"` + address + `","items":[{"product_id":"` + teapot + `","quantity":2,"unit_price":1}]}`, false, http.StatusBadRequest, response.CodeBadRequest, 0},
+		{"client sets the total", `{"shipping_address_id":"` + address + `","total_amount":1,"items":[{"product_id":"` + teapot + `","quantity":2}]}`, false, http.StatusBadRequest, response.CodeBadRequest, 0},
+		{"no items", `{"shipping_address_id":"` + address + `","items":[]}`, false, http.StatusBadRequest, "invalid_order", 0},
+		{"unknown product", `{"shipping_address_id":"` + address + `","items":[{"product_id":"00000000-0000-0000-0000-000000000099","quantity":1}]}`, false, http.StatusBadRequest, "unknown_product", 0},
+		{"retired product", `{"shipping_address_id":"` + address + `","items":[{"product_id":"` + vase + `","quantity":1}]}`, false, http.StatusConflict, "product_unavailable", 0},
+		{"two stores", `{"shipping_address_id":"` + address + `","items":[{"product_id":"` + teapot + `","quantity":1},{"product_id":"` + rug + `","quantity":1}]}`, false, http.StatusBadRequest, "mixed_stores", 0},
+		{"catalog down", `{"shipping_address_id":"` + address + `","items":[{"product_id":"` + teapot + `","quantity":1}]}`, true, http.StatusBadGateway, response.CodeBadGateway, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.catalog.fail = tt.failing
+
+			rec := f.serve(t, jsonRequest(http.MethodPost, "/api/orders", tt.body), customer)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				if problem := decodeProblem(t, rec); problem.Code != tt.wantCode {
+					t.Errorf("code = %s, want %s", problem.Code, tt.wantCode)
+				}
+				return
+			}
+
+			var order struct {
+				ID, StoreID, UserID string
+				TotalAmount         float64
+				OrderItems          []struct{ UnitPrice, TotalPrice float64 }
+			}
+			decodeData(rec, &order)
+			if order.StoreID != "store-a" || order.UserID != "dave" || order.TotalAmount != tt.wantTotal {
+				t.Errorf("order = %+v", order)
+			}
+			if len(order.OrderItems) != 1 || order.OrderItems[0].UnitPrice != 120 || order.OrderItems[0].TotalPrice != 240 {
+				t.Errorf("items = %+v", order.OrderItems)
+			}
+			if history, _ := f.history.ListByOrderID(context.Background(), order.ID); len(history) != 1 || history[0].ToStatus != domain.Pending {
+				t.Errorf("history = %+v", history)
+			}
+		})
+	}
+}
+
+func TestCreateOrderReservesStock(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity string
+		failing  bool
+		want     int
+		wantCode response.Code
+	}{
+		{"in stock", "5", false, http.StatusCreated, ""},
+		{"out of stock", "6", false, http.StatusConflict, "out_of_stock"},
+		{"inventory down", "1", true, http.StatusBadGateway, response.CodeBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.stock.fail = tt.failing
+
+			rec := f.serve(t, jsonRequest(http.MethodPost, "/api/orders", `{"shipping_address_id":"`+address+`","items":[{"product_id":"`+teapot+`","quantity":`+tt.quantity+`}]}`), customer)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				if problem := decodeProblem(t, rec); problem.Code != tt.wantCode {
+					t.Errorf("code = %s, want %s", problem.Code, tt.wantCode)
+				}
+				return
+			}
+			var order struct{ StockStatus domain.StockStatus }
+			decodeData(rec, &order)
+			if order.StockStatus != domain.StockReserved {
+				t.Errorf("stock status = %s, want reserved", order.StockStatus)
+			}
+		})
+	}
+}
+
+func TestUnknownProductIsNamed(t *testing.T) {
+	f := newFixture(t)
+	unknown := "00000000-0000-0000-0000-000000000099"
+
+	rec := f.serve(t, jsonRequest(http.MethodPost, "/api/orders", `{"shipping_address_id":"`+address+`","items":[{"product_id":"`+unknown+`","quantity":1}]}`), customer)
+	var body map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["product_id"] != unknown {
+		t.Errorf("problem = %v, want product_id %s", body, unknown)
+	}
+}
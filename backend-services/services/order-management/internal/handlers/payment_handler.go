@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"order-management/internal/domain"
+	services "order-management/internal/service/interfaces"
+	"shared/auth"
+	"shared/response"
+
+	"github.com/gorilla/mux"
+)
+
+type PaymentHandler struct {
+	payments services.PaymentService
+}
+
+func NewPaymentHandler(payments services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{payments: payments}
+}
+
+func (h *PaymentHandler) RecordPayment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Amount        float64              `json:"amount"`
+		Method        domain.PaymentMethod `json:"method"`
+		TransactionID string               `json:"transaction_id"`
+		Notes         string               `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	payment, err := h.payments.Record(r.Context(), claims, mux.Vars(r)["orderId"], services.PaymentInput{
+		Amount:        req.Amount,
+		Method:        req.Method,
+		TransactionID: req.TransactionID,
+		Notes:         req.Notes,
+	})
+	if err != nil {
+		writeServiceError(w, r, err, "Order not found", "Failed to record payment")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, payment)
+}
+
+func (h *PaymentHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	payments, err := h.payments.List(r.Context(), claims, mux.Vars(r)["orderId"])
+	if err != nil {
+		writeServiceError(w, r, err, "Order not found", "Failed to fetch payments")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, payments)
+}
+
+func (h *PaymentHandler) ConfirmPayment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	h.settle(w, r, &req, "Failed to confirm payment", func(claims auth.Claims, orderID, paymentID string) (domain.Payment, error) {
+		return h.payments.Confirm(r.Context(), claims, orderID, paymentID, req.TransactionID)
+	})
+}
+
+func (h *PaymentHandler) FailPayment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	h.settle(w, r, &req, "Failed to mark payment as failed", func(claims auth.Claims, orderID, paymentID string) (domain.Payment, error) {
+		return h.payments.Fail(r.Context(), claims, orderID, paymentID, req.Reason)
+	})
+}
+
+func (h *PaymentHandler) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	h.settle(w, r, &req, "Failed to refund payment", func(claims auth.Claims, orderID, paymentID string) (domain.Payment, error) {
+		return h.payments.Refund(r.Context(), claims, orderID, paymentID, req.Reason)
+	})
+}
+
+// settle decodes the optional request body into req and responds with the
+// payment returned by change.
+func (h *PaymentHandler) settle(w http.ResponseWriter, r *http.Request, req interface{}, failed string, change func(claims auth.Claims, orderID, paymentID string) (domain.Payment, error)) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	vars := mux.Vars(r)
+	payment, err := change(claims, vars["orderId"], vars["paymentId"])
+	if err != nil {
+		writeServiceError(w, r, err, "Payment not found", failed)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, payment)
+}
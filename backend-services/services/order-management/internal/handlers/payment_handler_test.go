@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"order-management/internal/domain"
+	"shared/auth"
+	"shared/response"
+	"testing"
+)
+
+func TestRecordPayment(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   auth.Claims
+		body     string
+		want     int
+		wantCode response.Code
+	}{
+		{"customer", customer, `{"amount":40,"method":"bank_transfer","transaction_id":"VIR-1"}`, http.StatusCreated, ""},
+		{"seller", alice, `{"amount":100,"method":"cod"}`, http.StatusCreated, ""},
+		{"unknown method", customer, `{"amount":40,"method":"card"}`, http.StatusBadRequest, "invalid_payment"},
+		{"no amount", customer, `{"method":"cod"}`, http.StatusBadRequest, "invalid_payment"},
+		{"more than the total", customer, `{"amount":100.01,"method":"cod"}`, http.StatusConflict, "overpayment"},
+		{"invalid body", customer, `{"amount":"forty"}`, http.StatusBadRequest, response.CodeBadRequest},
+		{"stranger", stranger, `{"amount":40,"method":"cod"}`, http.StatusNotFound, response.CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			rec := f.serve(t, jsonRequest(http.MethodPost, "/api/orders/order-1/payments", tt.body), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				if problem := decodeProblem(t, rec); problem.Code != tt.wantCode {
+					t.Errorf("code = %s, want %s", problem.Code, tt.wantCode)
+				}
+				return
+			}
+			var payment struct {
+				Status     domain.PaymentStatus
+				RecordedBy string
+			}
+			decodeData(rec, &payment)
+			if payment.Status != domain.PaymentPending || payment.RecordedBy != tt.claims.ID {
+				t.Errorf("payment = %+v", payment)
+			}
+		})
+	}
+}
+
+func TestSettlePayment(t *testing.T) {
+	f := newFixture(t)
+	record := func(amount string) string {
+		rec := f.serve(t, jsonRequest(http.MethodPost, "/api/orders/order-1/payments", `{"amount":`+amount+`,"method":"whatsapp"}`), customer)
+		var payment struct{ ID string }
+		decodeData(rec, &payment)
+		return payment.ID
+	}
+	settle := func(claims auth.Claims, id, action, body string) *httptest.ResponseRecorder {
+		return f.serve(t, jsonRequest(http.MethodPost, "/api/orders/order-1/payments/"+id+"/"+action, body), claims)
+	}
+
+	first, second := record("30"), record("70")
+	if rec := settle(customer, first, "confirm", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("confirm by customer status = %d, want 403", rec.Code)
+	}
+	if rec := settle(bob, first, "confirm", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("confirm by another store status = %d, want 404", rec.Code)
+	}
+	if rec := settle(alice, first, "confirm", `{"transaction_id":"WA-1"}`); rec.Code != http.StatusOK || f.status(t) != domain.Pending {
+		t.Fatalf("partial confirm status = %d, order %s: %s", rec.Code, f.status(t), rec.Body.String())
+	}
+	if rec := settle(alice, first, "fail", `{"reason":"Late"}`); rec.Code != http.StatusConflict || decodeProblem(t, rec).Code != "invalid_payment_transition" {
+		t.Errorf("fail of completed payment status = %d", rec.Code)
+	}
+	if rec := settle(alice, second, "confirm", ""); rec.Code != http.StatusOK || f.status(t) != domain.Confirmed {
+		t.Fatalf("full confirm status = %d, order %s: %s", rec.Code, f.status(t), rec.Body.String())
+	}
+
+	settle(alice, first, "refund", `{"reason":"Damaged"}`)
+	if rec := settle(alice, second, "refund", ""); rec.Code != http.StatusOK || f.status(t) != domain.Cancelled {
+		t.Fatalf("last refund status = %d, order %s: %s", rec.Code, f.status(t), rec.Body.String())
+	}
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/orders/order-1/payments", nil), customer)
+	var payments []struct {
+		Status domain.PaymentStatus
+		Notes  string
+	}
+	decodeData(rec, &payments)
+	if len(payments) != 2 || payments[0].Status != domain.PaymentRefunded || payments[0].Notes != "Damaged" {
+		t.Errorf("payments = %+v", payments)
+	}
+
+	// Both automatic status changes are in the history
+	history, _ := f.history.ListByOrderID(context.Background(), "order-1")
+	if len(history) != 2 || history[0].ToStatus != domain.Confirmed || history[1].ToStatus != domain.Cancelled {
+		t.Errorf("history = %+v", history)
+	}
+	if rec := f.serve(t, jsonRequest(http.MethodPost, "/api/orders/order-1/payments", `{"amount":10,"method":"cod"}`), customer); decodeProblem(t, rec).Code != "order_cancelled" {
+		t.Errorf("payment on cancelled order status = %d", rec.Code)
+	}
+}
+
+func TestCancelPaidOrder(t *testing.T) {
+	f := newFixture(t)
+	rec := f.serve(t, jsonRequest(http.MethodPost, "/api/orders/order-1/payments", `{"amount":40,"method":"cod"}`), customer)
+	var payment struct{ ID string }
+	decodeData(rec, &payment)
+	f.serve(t, httptest.NewRequest(http.MethodPost, "/api/orders/order-1/payments/"+payment.ID+"/confirm", nil), alice)
+
+	cancel := func(claims auth.Claims) *httptest.ResponseRecorder {
+		return f.serve(t, httptest.NewRequest(http.MethodPost, "/api/orders/order-1/cancel", nil), claims)
+	}
+	for _, claims := range []auth.Claims{customer, alice} {
+		rec := cancel(claims)
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("%s cancels paid order: status = %d, want 409: %s", claims.ID, rec.Code, rec.Body.String())
+		}
+		if problem := decodeProblem(t, rec); problem.Code != "order_paid" {
+			t.Errorf("code = %s, want order_paid", problem.Code)
+		}
+	}
+	if status := f.status(t); status != domain.Pending {
+		t.Fatalf("order status = %s, want pending", status)
+	}
+
+	f.serve(t, httptest.NewRequest(http.MethodPost, "/api/orders/order-1/payments/"+payment.ID+"/refund", nil), alice)
+	if rec := cancel(customer); rec.Code != http.StatusOK {
+		t.Fatalf("cancel after refund: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	stored, _ := f.payments.FindByID(context.Background(), payment.ID)
+	if stored.Status != domain.PaymentRefunded || f.status(t) != domain.Cancelled {
+		t.Errorf("payment %s, order %s after refund and cancel", stored.Status, f.status(t))
+	}
+}
@@ -0,0 +1,117 @@
+// Package jobs runs order-management's background work.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	services "order-management/internal/service/interfaces"
+	"os"
+	"time"
+)
+
+// Defaults for the sweeper's settings.
+const (
+	DefaultPaymentTimeout = 48 * time.Hour
+	DefaultSweepInterval  = 5 * time.Minute
+)
+
+// reservationGrace is how long an order may take to reserve its stock
+// before the sweeper takes it for abandoned, well beyond any request.
+const reservationGrace = 10 * time.Minute
+
+// Sweeper cancels orders left unpaid past the payment timeout or whose
+// stock reservation never finished, and settles stock that could not be
+// released or committed when the order changed.
+type Sweeper struct {
+	orders         services.Sweeper
+	paymentTimeout time.Duration
+	interval       time.Duration
+	stop           context.CancelFunc
+}
+
+func NewSweeper(orders services.Sweeper, paymentTimeout, interval time.Duration) *Sweeper {
+	return &Sweeper{orders: orders, paymentTimeout: paymentTimeout, interval: interval}
+}
+
+// NewSweeperFromEnv reads the payment timeout and the interval between
+// sweeps from ORDER_PAYMENT_TIMEOUT and ORDER_SWEEP_INTERVAL, such as
+// "48h" and "5m".
+func NewSweeperFromEnv(orders services.Sweeper) (*Sweeper, error) {
+	paymentTimeout, err := durationFromEnv("ORDER_PAYMENT_TIMEOUT", DefaultPaymentTimeout)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := durationFromEnv("ORDER_SWEEP_INTERVAL", DefaultSweepInterval)
+	if err != nil {
+		return nil, err
+	}
+	return NewSweeper(orders, paymentTimeout, interval), nil
+}
+
+// Start sweeps now and then on every interval until Stop is called.
+func (s *Sweeper) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.Sweep(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends sweeping, abandoning a sweep in progress.
+func (s *Sweeper) Stop() {
+	if s.stop != nil {
+		s.stop()
+	}
+}
+
+// Sweep expires unpaid orders and abandons unreserved ones once, then
+// settles any stock left over. Stock is settled last, so that the stock of
+// orders whose release failed is retried straight away.
+func (s *Sweeper) Sweep(ctx context.Context) {
+	abandoned, err := s.orders.AbandonReserving(ctx, time.Now().Add(-reservationGrace))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to abandon orders left reserving stock", "error", err)
+	}
+	if abandoned > 0 {
+		slog.InfoContext(ctx, "Cancelled orders left reserving stock", "count", abandoned)
+	}
+
+	expired, err := s.orders.ExpireUnpaid(ctx, time.Now().Add(-s.paymentTimeout))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to expire unpaid orders", "error", err)
+	}
+	if expired > 0 {
+		slog.InfoContext(ctx, "Cancelled unpaid orders", "count", expired)
+	}
+
+	settled, err := s.orders.SettleStock(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to settle order stock", "error", err)
+	}
+	if settled > 0 {
+		slog.InfoContext(ctx, "Settled order stock", "count", settled)
+	}
+}
+
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, v)
+	}
+	return d, nil
+}
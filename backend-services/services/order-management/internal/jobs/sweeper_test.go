@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeOrders struct {
+	before    time.Time
+	abandoned time.Time
+	settled   int
+}
+
+func (f *fakeOrders) ExpireUnpaid(ctx context.Context, before time.Time) (int, error) {
+	f.before = before
+	return 0, errors.New("database unavailable")
+}
+
+func (f *fakeOrders) AbandonReserving(ctx context.Context, before time.Time) (int, error) {
+	f.abandoned = before
+	return 0, nil
+}
+
+func (f *fakeOrders) SettleStock(ctx context.Context) (int, error) {
+	f.settled++
+	return 1, nil
+}
+
+func TestSweepSettlesStockAfterExpiryFails(t *testing.T) {
+	orders := &fakeOrders{}
+	s := NewSweeper(orders, time.Hour, time.Minute)
+
+	s.Sweep(context.Background())
+	if cutoff := time.Since(orders.before); cutoff < time.Hour || cutoff > time.Hour+time.Minute {
+		t.Errorf("expired orders placed %v ago, want an hour", cutoff)
+	}
+	if cutoff := time.Since(orders.abandoned); cutoff < reservationGrace || cutoff > reservationGrace+time.Minute {
+		t.Errorf("abandoned orders placed %v ago, want %v", cutoff, reservationGrace)
+	}
+	if orders.settled != 1 {
+		t.Errorf("settled stock %d times, want once", orders.settled)
+	}
+}
+
+func TestNewSweeperFromEnv(t *testing.T) {
+	t.Setenv("ORDER_PAYMENT_TIMEOUT", "")
+	t.Setenv("ORDER_SWEEP_INTERVAL", "30s")
+	s, err := NewSweeperFromEnv(&fakeOrders{})
+	if err != nil || s.paymentTimeout != DefaultPaymentTimeout || s.interval != 30*time.Second {
+		t.Fatalf("NewSweeperFromEnv = %+v, %v", s, err)
+	}
+
+	for _, v := range []string{"soon", "0s", "-1h"} {
+		t.Setenv("ORDER_PAYMENT_TIMEOUT", v)
+		if _, err := NewSweeperFromEnv(&fakeOrders{}); err == nil {
+			t.Errorf("ORDER_PAYMENT_TIMEOUT=%q accepted", v)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// Package metrics exposes the service's business metrics. The HTTP and
+// database metrics come from shared/metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// OrdersCreated counts successful creations.
+var OrdersCreated = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "orders_created_total",
+	Help: "Orders created.",
+})
@@ -0,0 +1,49 @@
+// Package interfaces declares the storage the order-management services
+// depend on. Lookups return domain.ErrNotFound when nothing matches.
+package interfaces
+
+import (
+	"context"
+	"order-management/internal/domain"
+	"time"
+)
+
+// OrderRepository stores orders. Orders are returned with their items.
+type OrderRepository interface {
+	// Create saves the order along with its items.
+	Create(ctx context.Context, order *domain.Order) error
+	// FindByID returns the order. Within a transaction the order is
+	// locked until the transaction ends.
+	FindByID(ctx context.Context, id string) (domain.Order, error)
+	// Update saves the order's own fields, leaving its items and stock
+	// status alone.
+	Update(ctx context.Context, order *domain.Order) error
+	// SetStockStatus records what became of the order's reserved stock,
+	// if its stock status is still from. It returns domain.ErrStockChanged
+	// otherwise.
+	SetStockStatus(ctx context.Context, id string, from, to domain.StockStatus) error
+	ListByUserID(ctx context.Context, userID string) ([]domain.Order, error)
+	// ListExpired returns the pending orders placed before the given time
+	// that nothing has been paid for.
+	ListExpired(ctx context.Context, before time.Time) ([]domain.Order, error)
+	// ListReserving returns the orders placed before the given time whose
+	// stock is still being reserved.
+	ListReserving(ctx context.Context, before time.Time) ([]domain.Order, error)
+	// ListUnsettledStock returns the cancelled, shipped and delivered
+	// orders whose stock is still reserved.
+	ListUnsettledStock(ctx context.Context) ([]domain.Order, error)
+}
+
+// Repositories are the repositories bound to one transaction.
+type Repositories struct {
+	Orders   OrderRepository
+	Payments PaymentRepository
+	History  StatusHistoryRepository
+}
+
+// Transactor runs units of work that must succeed or fail together.
+type Transactor interface {
+	// InTx runs fn in a transaction, committing it if fn returns nil
+	// and rolling it back otherwise.
+	InTx(ctx context.Context, fn func(Repositories) error) error
+}
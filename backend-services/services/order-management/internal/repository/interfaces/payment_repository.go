@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"order-management/internal/domain"
+)
+
+// PaymentRepository stores payments.
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *domain.Payment) error
+	FindByID(ctx context.Context, id string) (domain.Payment, error)
+	// ListByOrderID returns the order's payments, oldest first.
+	ListByOrderID(ctx context.Context, orderID string) ([]domain.Payment, error)
+	Update(ctx context.Context, payment *domain.Payment) error
+}
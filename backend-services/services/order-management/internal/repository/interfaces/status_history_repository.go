@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"order-management/internal/domain"
+)
+
+// StatusHistoryRepository stores the status changes of orders.
+type StatusHistoryRepository interface {
+	Create(ctx context.Context, entry *domain.OrderStatusHistory) error
+	// ListByOrderID returns the order's status changes, oldest first.
+	ListByOrderID(ctx context.Context, orderID string) ([]domain.OrderStatusHistory, error)
+}
@@ -0,0 +1,182 @@
+// Package memory implements the repositories in memory, for tests and
+// local runs without a database.
+package memory
+
+import (
+	"context"
+	"order-management/internal/domain"
+	repositories "order-management/internal/repository/interfaces"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrderRepository struct {
+	mu     sync.RWMutex
+	orders map[string]domain.Order
+}
+
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{orders: make(map[string]domain.Order)}
+}
+
+// Create adds an order with its items.
+func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if order.ID == "" {
+		order.ID = uuid.NewString()
+	}
+	if order.Status == "" {
+		order.Status = domain.Pending
+	}
+	if order.StockStatus == "" {
+		order.StockStatus = domain.StockNone
+	}
+	for i := range order.OrderItems {
+		if order.OrderItems[i].ID == "" {
+			order.OrderItems[i].ID = uuid.NewString()
+		}
+		order.OrderItems[i].OrderID = order.ID
+	}
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+	r.orders[order.ID] = copyOrder(*order)
+	return nil
+}
+
+func (r *OrderRepository) FindByID(ctx context.Context, id string) (domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, ok := r.orders[id]
+	if !ok {
+		return domain.Order{}, domain.ErrNotFound
+	}
+	return copyOrder(order), nil
+}
+
+func (r *OrderRepository) Update(ctx context.Context, order *domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.orders[order.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	order.UpdatedAt = time.Now()
+	updated := *order
+	updated.OrderItems = existing.OrderItems
+	updated.StockStatus = existing.StockStatus
+	r.orders[order.ID] = updated
+	return nil
+}
+
+func (r *OrderRepository) SetStockStatus(ctx context.Context, id string, from, to domain.StockStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[id]
+	if !ok || order.StockStatus != from {
+		return domain.ErrStockChanged
+	}
+	order.StockStatus = to
+	r.orders[id] = order
+	return nil
+}
+
+func (r *OrderRepository) ListByUserID(ctx context.Context, userID string) ([]domain.Order, error) {
+	return r.list(func(order domain.Order) bool { return order.UserID == userID }), nil
+}
+
+func (r *OrderRepository) ListExpired(ctx context.Context, before time.Time) ([]domain.Order, error) {
+	return r.list(func(order domain.Order) bool {
+		return order.Status == domain.Pending && order.AmountPaid == 0 && order.CreatedAt.Before(before)
+	}), nil
+}
+
+func (r *OrderRepository) ListReserving(ctx context.Context, before time.Time) ([]domain.Order, error) {
+	return r.list(func(order domain.Order) bool {
+		return order.StockStatus == domain.StockReserving && order.CreatedAt.Before(before)
+	}), nil
+}
+
+func (r *OrderRepository) ListUnsettledStock(ctx context.Context) ([]domain.Order, error) {
+	return r.list(func(order domain.Order) bool {
+		switch order.Status {
+		case domain.Cancelled, domain.Shipped, domain.Delivered:
+			return order.StockStatus == domain.StockReserved
+		}
+		return false
+	}), nil
+}
+
+// list returns the orders matching keep, oldest first.
+func (r *OrderRepository) list(keep func(domain.Order) bool) []domain.Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := []domain.Order{}
+	for _, order := range r.orders {
+		if keep(order) {
+			orders = append(orders, copyOrder(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].CreatedAt.Before(orders[j].CreatedAt) })
+	return orders
+}
+
+// copyOrder keeps callers from changing stored items in place.
+func copyOrder(order domain.Order) domain.Order {
+	order.OrderItems = append([]domain.OrderItem(nil), order.OrderItems...)
+	return order
+}
+
+// Transactor serializes units of work over the in-memory repositories and
+// undoes the changes of those that fail.
+type Transactor struct {
+	mu       sync.Mutex
+	orders   *OrderRepository
+	payments *PaymentRepository
+	history  *StatusHistoryRepository
+}
+
+func NewTransactor(orders *OrderRepository, payments *PaymentRepository, history *StatusHistoryRepository) *Transactor {
+	return &Transactor{orders: orders, payments: payments, history: history}
+}
+
+func (t *Transactor) InTx(ctx context.Context, fn func(repositories.Repositories) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	orders := t.orders.snapshot()
+	payments := t.payments.snapshot()
+	history := t.history.snapshot()
+	if err := fn(repositories.Repositories{Orders: t.orders, Payments: t.payments, History: t.history}); err != nil {
+		t.orders.restore(orders)
+		t.payments.restore(payments)
+		t.history.restore(history)
+		return err
+	}
+	return nil
+}
+
+func (r *OrderRepository) snapshot() map[string]domain.Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make(map[string]domain.Order, len(r.orders))
+	for id, order := range r.orders {
+		orders[id] = order
+	}
+	return orders
+}
+
+func (r *OrderRepository) restore(orders map[string]domain.Order) {
+	r.mu.Lock()
+	r.orders = orders
+	r.mu.Unlock()
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"order-management/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentRepository struct {
+	mu       sync.RWMutex
+	payments map[string]domain.Payment
+	order    []string
+}
+
+func NewPaymentRepository() *PaymentRepository {
+	return &PaymentRepository{payments: make(map[string]domain.Payment)}
+}
+
+func (r *PaymentRepository) Create(ctx context.Context, payment *domain.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if payment.ID == "" {
+		payment.ID = uuid.NewString()
+	}
+	payment.CreatedAt = time.Now()
+	payment.UpdatedAt = payment.CreatedAt
+	r.payments[payment.ID] = *payment
+	r.order = append(r.order, payment.ID)
+	return nil
+}
+
+func (r *PaymentRepository) FindByID(ctx context.Context, id string) (domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return domain.Payment{}, domain.ErrNotFound
+	}
+	return payment, nil
+}
+
+func (r *PaymentRepository) ListByOrderID(ctx context.Context, orderID string) ([]domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payments := []domain.Payment{}
+	for _, id := range r.order {
+		if payment, ok := r.payments[id]; ok && payment.OrderID == orderID {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (r *PaymentRepository) Update(ctx context.Context, payment *domain.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.payments[payment.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	payment.UpdatedAt = time.Now()
+	r.payments[payment.ID] = *payment
+	return nil
+}
+
+type paymentSnapshot struct {
+	payments map[string]domain.Payment
+	order    []string
+}
+
+func (r *PaymentRepository) snapshot() paymentSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payments := make(map[string]domain.Payment, len(r.payments))
+	for id, payment := range r.payments {
+		payments[id] = payment
+	}
+	return paymentSnapshot{payments: payments, order: append([]string(nil), r.order...)}
+}
+
+func (r *PaymentRepository) restore(s paymentSnapshot) {
+	r.mu.Lock()
+	r.payments = s.payments
+	r.order = s.order
+	r.mu.Unlock()
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"order-management/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StatusHistoryRepository struct {
+	mu      sync.RWMutex
+	entries []domain.OrderStatusHistory
+}
+
+func NewStatusHistoryRepository() *StatusHistoryRepository {
+	return &StatusHistoryRepository{}
+}
+
+func (r *StatusHistoryRepository) Create(ctx context.Context, entry *domain.OrderStatusHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	entry.CreatedAt = time.Now()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *StatusHistoryRepository) ListByOrderID(ctx context.Context, orderID string) ([]domain.OrderStatusHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := []domain.OrderStatusHistory{}
+	for _, entry := range r.entries {
+		if entry.OrderID == orderID {
+			history = append(history, entry)
+		}
+	}
+	return history, nil
+}
+
+func (r *StatusHistoryRepository) snapshot() []domain.OrderStatusHistory {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.OrderStatusHistory(nil), r.entries...)
+}
+
+func (r *StatusHistoryRepository) restore(entries []domain.OrderStatusHistory) {
+	r.mu.Lock()
+	r.entries = entries
+	r.mu.Unlock()
+}
@@ -0,0 +1,115 @@
+// Package postgres implements the repositories with GORM.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"order-management/internal/domain"
+	repositories "order-management/internal/repository/interfaces"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrderRepository struct {
+	db *gorm.DB
+	// lock is set for repositories bound to a transaction, where lookups
+	// lock the rows they return.
+	lock bool
+}
+
+func NewOrderRepository(db *gorm.DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+func (r *OrderRepository) FindByID(ctx context.Context, id string) (domain.Order, error) {
+	var order domain.Order
+	query := r.db.WithContext(ctx)
+	if r.lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := query.First(&order, "id = ?", id).Error; err != nil {
+		return order, notFound(err)
+	}
+	err := r.db.WithContext(ctx).Where("order_id = ?", id).Find(&order.OrderItems).Error
+	return order, err
+}
+
+func (r *OrderRepository) Update(ctx context.Context, order *domain.Order) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations, "StockStatus").Save(order).Error
+}
+
+func (r *OrderRepository) SetStockStatus(ctx context.Context, id string, from, to domain.StockStatus) error {
+	result := r.db.WithContext(ctx).Model(&domain.Order{}).
+		Where("id = ? AND stock_status = ?", id, from).
+		Update("stock_status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrStockChanged
+	}
+	return nil
+}
+
+func (r *OrderRepository) ListByUserID(ctx context.Context, userID string) ([]domain.Order, error) {
+	orders := []domain.Order{}
+	err := r.db.WithContext(ctx).Preload("OrderItems").Where("user_id = ?", userID).Find(&orders).Error
+	return orders, err
+}
+
+func (r *OrderRepository) ListExpired(ctx context.Context, before time.Time) ([]domain.Order, error) {
+	orders := []domain.Order{}
+	err := r.db.WithContext(ctx).Preload("OrderItems").
+		Where("status = ? AND amount_paid = 0 AND created_at < ?", domain.Pending, before).
+		Order("created_at").Find(&orders).Error
+	return orders, err
+}
+
+func (r *OrderRepository) ListReserving(ctx context.Context, before time.Time) ([]domain.Order, error) {
+	orders := []domain.Order{}
+	err := r.db.WithContext(ctx).Preload("OrderItems").
+		Where("stock_status = ? AND created_at < ?", domain.StockReserving, before).
+		Order("created_at").Find(&orders).Error
+	return orders, err
+}
+
+func (r *OrderRepository) ListUnsettledStock(ctx context.Context) ([]domain.Order, error) {
+	orders := []domain.Order{}
+	err := r.db.WithContext(ctx).Preload("OrderItems").
+		Where("stock_status = ? AND status IN ?", domain.StockReserved, []domain.OrderStatus{domain.Cancelled, domain.Shipped, domain.Delivered}).
+		Order("updated_at").Find(&orders).Error
+	return orders, err
+}
+
+// Transactor runs units of work in database transactions.
+type Transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+func (t *Transactor) InTx(ctx context.Context, fn func(repositories.Repositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(repositories.Repositories{
+			Orders:   &OrderRepository{db: tx, lock: true},
+			Payments: NewPaymentRepository(tx),
+			History:  NewStatusHistoryRepository(tx),
+		})
+	})
+}
+
+// notFound translates GORM's missing record error into the domain's.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"order-management/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type PaymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+func (r *PaymentRepository) Create(ctx context.Context, payment *domain.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *PaymentRepository) FindByID(ctx context.Context, id string) (domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).First(&payment, "id = ?", id).Error
+	return payment, notFound(err)
+}
+
+func (r *PaymentRepository) ListByOrderID(ctx context.Context, orderID string) ([]domain.Payment, error) {
+	payments := []domain.Payment{}
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at").Find(&payments).Error
+	return payments, err
+}
+
+func (r *PaymentRepository) Update(ctx context.Context, payment *domain.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"context"
+	"order-management/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type StatusHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewStatusHistoryRepository(db *gorm.DB) *StatusHistoryRepository {
+	return &StatusHistoryRepository{db: db}
+}
+
+func (r *StatusHistoryRepository) Create(ctx context.Context, entry *domain.OrderStatusHistory) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *StatusHistoryRepository) ListByOrderID(ctx context.Context, orderID string) ([]domain.OrderStatusHistory, error) {
+	history := []domain.OrderStatusHistory{}
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at").Find(&history).Error
+	return history, err
+}
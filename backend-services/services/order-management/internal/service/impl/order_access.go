@@ -0,0 +1,73 @@
+// Package impl implements the order-management services on top of the
+// repositories.
+package impl
+
+import (
+	"context"
+	"fmt"
+	"order-management/internal/domain"
+	repositories "order-management/internal/repository/interfaces"
+	services "order-management/internal/service/interfaces"
+	"shared/auth"
+)
+
+// canManage reports whether the caller sells for the order's store.
+func canManage(ctx context.Context, stores services.StoreAccess, claims auth.Claims, order domain.Order) (bool, error) {
+	if !claims.Can(auth.PermOrderManage) {
+		return false, nil
+	}
+	ok, err := stores.CanManage(ctx, claims, order.StoreID)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", domain.ErrStoreLookup, err)
+	}
+	return ok, nil
+}
+
+// visibleOrder returns the order if the caller placed it or sells for its
+// store.
+func visibleOrder(ctx context.Context, orders repositories.OrderRepository, stores services.StoreAccess, claims auth.Claims, id string) (domain.Order, error) {
+	order, err := orders.FindByID(ctx, id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if order.UserID == claims.ID {
+		return order, nil
+	}
+	ok, err := canManage(ctx, stores, claims, order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if !ok {
+		return domain.Order{}, domain.ErrNotFound
+	}
+	return order, nil
+}
+
+// managedOrder returns the order if the caller sells for its store. The
+// customer who placed it is refused rather than told it does not exist.
+func managedOrder(ctx context.Context, orders repositories.OrderRepository, stores services.StoreAccess, claims auth.Claims, id string) (domain.Order, error) {
+	order, err := orders.FindByID(ctx, id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	ok, err := canManage(ctx, stores, claims, order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	switch {
+	case ok:
+		return order, nil
+	case order.UserID == claims.ID:
+		return domain.Order{}, domain.ErrForbidden
+	default:
+		return domain.Order{}, domain.ErrNotFound
+	}
+}
+
+// cents converts an amount to whole cents so balances compare exactly.
+func cents(amount float64) int64 {
+	if amount < 0 {
+		return int64(amount*100 - 0.5)
+	}
+	return int64(amount*100 + 0.5)
+}
@@ -0,0 +1,77 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"order-management/internal/domain"
+	services "order-management/internal/service/interfaces"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// priceOrder builds an order from input at the catalog's current prices.
+func priceOrder(ctx context.Context, products services.Catalog, input services.OrderInput) (domain.Order, error) {
+	if _, err := uuid.Parse(input.ShippingAddressID); err != nil || len(input.Items) == 0 {
+		return domain.Order{}, domain.ErrInvalidOrder
+	}
+
+	// The same product listed twice is one line
+	var ids []string
+	quantities := make(map[string]int)
+	for _, item := range input.Items {
+		if _, err := uuid.Parse(item.ProductID); err != nil || item.Quantity <= 0 {
+			return domain.Order{}, domain.ErrInvalidOrder
+		}
+		if _, ok := quantities[item.ProductID]; !ok {
+			ids = append(ids, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	found, err := products.Products(ctx, ids)
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("%w: %v", domain.ErrCatalogLookup, err)
+	}
+	byID := make(map[string]int, len(found))
+	for i, p := range found {
+		byID[p.ID] = i
+	}
+
+	order := domain.Order{ShippingAddressID: input.ShippingAddressID}
+	var total int64
+	for _, id := range ids {
+		i, ok := byID[id]
+		if !ok {
+			return domain.Order{}, &domain.ProductError{ProductID: id, Err: domain.ErrProductNotFound}
+		}
+		product := found[i]
+		if !product.IsActive {
+			return domain.Order{}, &domain.ProductError{ProductID: id, Err: domain.ErrProductInactive}
+		}
+		if order.StoreID == "" {
+			order.StoreID = product.StoreID
+		} else if product.StoreID != order.StoreID {
+			return domain.Order{}, domain.ErrMixedStores
+		}
+
+		line := cents(product.Price) * int64(quantities[id])
+		total += line
+		order.OrderItems = append(order.OrderItems, domain.OrderItem{
+			ProductID:  id,
+			Quantity:   quantities[id],
+			UnitPrice:  float64(cents(product.Price)) / 100,
+			TotalPrice: float64(line) / 100,
+		})
+	}
+	order.TotalAmount = float64(total) / 100
+	return order, nil
+}
+
+// newOrderNumber returns a human-readable order number such as
+// ORD-20240612-1A2B3C4D.
+func newOrderNumber(now time.Time) string {
+	suffix := strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:8])
+	return "ORD-" + now.UTC().Format("20060102") + "-" + suffix
+}
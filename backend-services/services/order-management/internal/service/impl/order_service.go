@@ -0,0 +1,276 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-management/internal/domain"
+	repositories "order-management/internal/repository/interfaces"
+	services "order-management/internal/service/interfaces"
+	"shared/auth"
+	"time"
+)
+
+var (
+	_ services.OrderService = (*OrderService)(nil)
+	_ services.Sweeper      = (*OrderService)(nil)
+)
+
+// systemActor is recorded as the author of status changes made by the
+// sweeper.
+const systemActor = "system"
+
+// errStillValid stops the sweeper from cancelling an order that no longer
+// qualifies.
+var errStillValid = errors.New("order no longer expired")
+
+type OrderService struct {
+	orders    repositories.OrderRepository
+	history   repositories.StatusHistoryRepository
+	tx        repositories.Transactor
+	stores    services.StoreAccess
+	catalog   services.Catalog
+	inventory services.Inventory
+}
+
+func NewOrderService(orders repositories.OrderRepository, history repositories.StatusHistoryRepository, tx repositories.Transactor, stores services.StoreAccess, catalog services.Catalog, inventory services.Inventory) *OrderService {
+	return &OrderService{orders: orders, history: history, tx: tx, stores: stores, catalog: catalog, inventory: inventory}
+}
+
+func (s *OrderService) Create(ctx context.Context, claims auth.Claims, input services.OrderInput) (domain.Order, error) {
+	order, err := priceOrder(ctx, s.catalog, input)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	order.UserID = claims.ID
+	order.OrderNumber = newOrderNumber(time.Now())
+	// Payments are recorded against the order once it exists
+	order.Status = domain.Pending
+	// The order is saved before its stock is reserved, so that the
+	// sweeper finds its reservations if placing it goes no further
+	order.StockStatus = domain.StockReserving
+
+	err = s.tx.InTx(ctx, func(repos repositories.Repositories) error {
+		if err := repos.Orders.Create(ctx, &order); err != nil {
+			return err
+		}
+		return repos.History.Create(ctx, &domain.OrderStatusHistory{
+			OrderID:   order.ID,
+			ToStatus:  domain.Pending,
+			ChangedBy: claims.ID,
+			Reason:    "Order placed",
+		})
+	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	reserveErr := reserveStock(ctx, s.inventory, order)
+
+	// The caller may have gone by now, but the reservations made must be
+	// recorded or undone regardless
+	detachedCtx, cancel := detached(ctx)
+	defer cancel()
+	if reserveErr == nil {
+		err := s.orders.SetStockStatus(detachedCtx, order.ID, domain.StockReserving, domain.StockReserved)
+		if err == nil {
+			order.StockStatus = domain.StockReserved
+			return order, nil
+		}
+		if errors.Is(err, domain.ErrStockChanged) {
+			// The sweeper gave up on the order while its stock was being
+			// reserved, and has cancelled it. Reservations it released
+			// before they were made are blocked, but later ones are not.
+			if err := releaseStock(detachedCtx, s.inventory, order.ID, order.OrderItems); err != nil {
+				slog.ErrorContext(ctx, "Failed to release the stock of an abandoned order", "order_id", order.ID, "error", err)
+			}
+			return domain.Order{}, fmt.Errorf("%w: order was abandoned while its stock was reserved", domain.ErrInventory)
+		}
+		reserveErr = err
+	}
+
+	if err := s.abandon(detachedCtx, order.ID, "Stock could not be reserved"); err != nil {
+		slog.ErrorContext(ctx, "Failed to abandon an order whose stock could not be reserved, leaving it to the sweeper", "order_id", order.ID, "error", err)
+	}
+	return domain.Order{}, reserveErr
+}
+
+// abandon cancels a pending order whose stock reservation did not finish
+// and releases whatever was reserved for it.
+func (s *OrderService) abandon(ctx context.Context, id, reason string) error {
+	order, err := s.transition(ctx, id, func(repos repositories.Repositories, order *domain.Order) error {
+		if order.StockStatus != domain.StockReserving {
+			return errStillValid
+		}
+		if order.Status == domain.Cancelled {
+			return nil
+		}
+		return changeStatus(ctx, repos, order, domain.Cancelled, systemActor, reason)
+	})
+	if err != nil {
+		return err
+	}
+	return settleStock(ctx, s.orders, s.inventory, &order)
+}
+
+func (s *OrderService) ListMine(ctx context.Context, claims auth.Claims) ([]domain.Order, error) {
+	return s.orders.ListByUserID(ctx, claims.ID)
+}
+
+func (s *OrderService) Get(ctx context.Context, claims auth.Claims, id string) (domain.Order, error) {
+	return visibleOrder(ctx, s.orders, s.stores, claims, id)
+}
+
+func (s *OrderService) Confirm(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error) {
+	return s.sellerTransition(ctx, claims, id, domain.Confirmed, reason)
+}
+
+func (s *OrderService) Ship(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error) {
+	return s.sellerTransition(ctx, claims, id, domain.Shipped, reason)
+}
+
+func (s *OrderService) Deliver(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error) {
+	return s.sellerTransition(ctx, claims, id, domain.Delivered, reason)
+}
+
+func (s *OrderService) Cancel(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error) {
+	order, err := s.orders.FindByID(ctx, id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	seller, err := canManage(ctx, s.stores, claims, order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if !seller && order.UserID != claims.ID {
+		return domain.Order{}, domain.ErrNotFound
+	}
+
+	order, err = s.transition(ctx, id, func(repos repositories.Repositories, order *domain.Order) error {
+		if !seller && order.Status != domain.Pending {
+			return domain.ErrOrderNotPending
+		}
+		// Refunding the last payment cancels the order
+		if order.AmountPaid != 0 {
+			return domain.ErrOrderPaid
+		}
+		return changeStatus(ctx, repos, order, domain.Cancelled, claims.ID, reason)
+	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+	settleStockLater(ctx, s.orders, s.inventory, &order)
+	return order, nil
+}
+
+func (s *OrderService) History(ctx context.Context, claims auth.Claims, id string) ([]domain.OrderStatusHistory, error) {
+	if _, err := visibleOrder(ctx, s.orders, s.stores, claims, id); err != nil {
+		return nil, err
+	}
+	return s.history.ListByOrderID(ctx, id)
+}
+
+// sellerTransition moves an order the caller sells for to status.
+func (s *OrderService) sellerTransition(ctx context.Context, claims auth.Claims, id string, status domain.OrderStatus, reason string) (domain.Order, error) {
+	if _, err := managedOrder(ctx, s.orders, s.stores, claims, id); err != nil {
+		return domain.Order{}, err
+	}
+	order, err := s.transition(ctx, id, func(repos repositories.Repositories, order *domain.Order) error {
+		return changeStatus(ctx, repos, order, status, claims.ID, reason)
+	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+	settleStockLater(ctx, s.orders, s.inventory, &order)
+	return order, nil
+}
+
+func (s *OrderService) ExpireUnpaid(ctx context.Context, before time.Time) (int, error) {
+	expired, err := s.orders.ListExpired(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	cancelled := 0
+	for _, candidate := range expired {
+		order, err := s.transition(ctx, candidate.ID, func(repos repositories.Repositories, order *domain.Order) error {
+			// A payment may have been confirmed since the orders were listed
+			if order.Status != domain.Pending || order.AmountPaid != 0 {
+				return errStillValid
+			}
+			return changeStatus(ctx, repos, order, domain.Cancelled, systemActor, "Payment timed out")
+		})
+		if errors.Is(err, errStillValid) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cancelled++
+		settleStockLater(ctx, s.orders, s.inventory, &order)
+	}
+	return cancelled, errors.Join(errs...)
+}
+
+func (s *OrderService) AbandonReserving(ctx context.Context, before time.Time) (int, error) {
+	stuck, err := s.orders.ListReserving(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	abandoned := 0
+	for _, order := range stuck {
+		err := s.abandon(ctx, order.ID, "Stock reservation did not finish")
+		if errors.Is(err, errStillValid) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		abandoned++
+	}
+	return abandoned, errors.Join(errs...)
+}
+
+func (s *OrderService) SettleStock(ctx context.Context) (int, error) {
+	unsettled, err := s.orders.ListUnsettledStock(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	settled := 0
+	for i := range unsettled {
+		if err := settleStock(ctx, s.orders, s.inventory, &unsettled[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		settled++
+	}
+	return settled, errors.Join(errs...)
+}
+
+// transition applies change to the locked order and saves it.
+func (s *OrderService) transition(ctx context.Context, id string, change func(repositories.Repositories, *domain.Order) error) (domain.Order, error) {
+	var order domain.Order
+	err := s.tx.InTx(ctx, func(repos repositories.Repositories) error {
+		var err error
+		order, err = repos.Orders.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := change(repos, &order); err != nil {
+			return err
+		}
+		return repos.Orders.Update(ctx, &order)
+	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+	return order, nil
+}
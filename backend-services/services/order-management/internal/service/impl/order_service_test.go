@@ -0,0 +1,535 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"order-management/internal/domain"
+	"order-management/internal/repository/memory"
+	services "order-management/internal/service/interfaces"
+	"shared/catalog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Catalog products, all in store-a except the rug.
+const (
+	teapot  = "00000000-0000-0000-0000-00000000000a"
+	cup     = "00000000-0000-0000-0000-00000000000b"
+	retired = "00000000-0000-0000-0000-00000000000c"
+	rug     = "00000000-0000-0000-0000-00000000000d"
+	missing = "00000000-0000-0000-0000-00000000000e"
+	address = "00000000-0000-0000-0000-0000000000ad"
+)
+
+type fakeCatalog struct {
+	fail bool
+}
+
+func (f *fakeCatalog) Products(ctx context.Context, ids []string) ([]catalog.Product, error) {
+	if f.fail {
+		return nil, errors.New("product-catalog unavailable")
+	}
+	known := map[string]catalog.Product{
+		teapot:  {ID: teapot, StoreID: "store-a", Price: 120.5, IsActive: true},
+		cup:     {ID: cup, StoreID: "store-a", Price: 0.1, IsActive: true},
+		retired: {ID: retired, StoreID: "store-a", Price: 10},
+		rug:     {ID: rug, StoreID: "store-b", Price: 900, IsActive: true},
+	}
+	var products []catalog.Product
+	for _, id := range ids {
+		if p, ok := known[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+// fakeInventory keeps reservations the way product-catalog does: one per
+// order and product, which a release before reserving blocks.
+type fakeInventory struct {
+	available    map[string]int
+	failing      map[string]bool
+	reservations map[[2]string]*fakeReservation
+}
+
+type fakeReservation struct {
+	quantity int
+	status   string
+}
+
+// newFakeInventory has 5 teapots and 10 cups available.
+func newFakeInventory() *fakeInventory {
+	return &fakeInventory{
+		available:    map[string]int{teapot: 5, cup: 10},
+		failing:      make(map[string]bool),
+		reservations: make(map[[2]string]*fakeReservation),
+	}
+}
+
+func (f *fakeInventory) Reserve(ctx context.Context, productID, orderID string, quantity int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.failing[productID] {
+		return errors.New("product-catalog unavailable")
+	}
+	if r, ok := f.reservations[[2]string{orderID, productID}]; ok {
+		if r.status != "held" {
+			return errors.New("reservation ended")
+		}
+		return nil
+	}
+	if f.available[productID] < quantity {
+		return catalog.ErrInsufficientStock
+	}
+	f.available[productID] -= quantity
+	f.reservations[[2]string{orderID, productID}] = &fakeReservation{quantity: quantity, status: "held"}
+	return nil
+}
+
+func (f *fakeInventory) Release(ctx context.Context, productID, orderID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.failing[productID] {
+		return errors.New("product-catalog unavailable")
+	}
+	r, ok := f.reservations[[2]string{orderID, productID}]
+	switch {
+	case !ok:
+		f.reservations[[2]string{orderID, productID}] = &fakeReservation{status: "released"}
+	case r.status == "held":
+		f.available[productID] += r.quantity
+		r.status = "released"
+	case r.status == "committed":
+		return errors.New("reservation ended")
+	}
+	return nil
+}
+
+func (f *fakeInventory) Commit(ctx context.Context, productID, orderID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.failing[productID] {
+		return errors.New("product-catalog unavailable")
+	}
+	r, ok := f.reservations[[2]string{orderID, productID}]
+	if !ok || r.status == "released" {
+		return errors.New("no reservation to commit")
+	}
+	r.status = "committed"
+	return nil
+}
+
+// status returns the status of the order's reservation of the product.
+func (f *fakeInventory) status(orderID, productID string) string {
+	if r, ok := f.reservations[[2]string{orderID, productID}]; ok {
+		return r.status
+	}
+	return ""
+}
+
+// newOrderService returns a service with a pending order of one teapot
+// placed by dave with alice's store-a.
+func newOrderService(t *testing.T) (*OrderService, *fakeInventory, domain.Order) {
+	t.Helper()
+	orders := memory.NewOrderRepository()
+	history := memory.NewStatusHistoryRepository()
+	inventory := newFakeInventory()
+	s := NewOrderService(orders, history, memory.NewTransactor(orders, memory.NewPaymentRepository(), history), fakeStores{"alice": {"store-a"}}, &fakeCatalog{}, inventory)
+
+	order, err := s.Create(context.Background(), buyer, services.OrderInput{
+		ShippingAddressID: address,
+		Items:             []services.OrderItemInput{{ProductID: teapot, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, inventory, order
+}
+
+func TestCreatePricesFromCatalog(t *testing.T) {
+	s, _, _ := newOrderService(t)
+
+	order, err := s.Create(context.Background(), buyer, services.OrderInput{
+		ShippingAddressID: address,
+		Items: []services.OrderItemInput{
+			{ProductID: teapot, Quantity: 2},
+			{ProductID: cup, Quantity: 3},
+			{ProductID: teapot, Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.StoreID != "store-a" || order.TotalAmount != 361.8 || order.UserID != "dave" || order.Status != domain.Pending {
+		t.Errorf("order = %+v", order)
+	}
+	if !strings.HasPrefix(order.OrderNumber, "ORD-") || len(order.OrderNumber) != len("ORD-20240612-1A2B3C4D") {
+		t.Errorf("order number = %q", order.OrderNumber)
+	}
+	want := []domain.OrderItem{
+		{ProductID: teapot, Quantity: 3, UnitPrice: 120.5, TotalPrice: 361.5},
+		{ProductID: cup, Quantity: 3, UnitPrice: 0.1, TotalPrice: 0.3},
+	}
+	if len(order.OrderItems) != len(want) {
+		t.Fatalf("items = %+v", order.OrderItems)
+	}
+	for i, item := range order.OrderItems {
+		if item.ProductID != want[i].ProductID || item.Quantity != want[i].Quantity || item.UnitPrice != want[i].UnitPrice || item.TotalPrice != want[i].TotalPrice {
+			t.Errorf("item %d = %+v, want %+v", i, item, want[i])
+		}
+	}
+}
+
+func TestCreateRejectsInvalidOrders(t *testing.T) {
+	item := func(id string, quantity int) []services.OrderItemInput {
+		return []services.OrderItemInput{{ProductID: id, Quantity: quantity}}
+	}
+	tests := []struct {
+		name    string
+		input   services.OrderInput
+		failing bool
+		want    error
+	}{
+		{"no items", services.OrderInput{ShippingAddressID: address}, false, domain.ErrInvalidOrder},
+		{"no address", services.OrderInput{Items: item(teapot, 1)}, false, domain.ErrInvalidOrder},
+		{"zero quantity", services.OrderInput{ShippingAddressID: address, Items: item(teapot, 0)}, false, domain.ErrInvalidOrder},
+		{"product id is not a uuid", services.OrderInput{ShippingAddressID: address, Items: item("teapot", 1)}, false, domain.ErrInvalidOrder},
+		{"unknown product", services.OrderInput{ShippingAddressID: address, Items: item(missing, 1)}, false, domain.ErrProductNotFound},
+		{"inactive product", services.OrderInput{ShippingAddressID: address, Items: item(retired, 1)}, false, domain.ErrProductInactive},
+		{"two stores", services.OrderInput{ShippingAddressID: address, Items: append(item(teapot, 1), item(rug, 1)...)}, false, domain.ErrMixedStores},
+		{"catalog down", services.OrderInput{ShippingAddressID: address, Items: item(teapot, 1)}, true, domain.ErrCatalogLookup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := memory.NewOrderRepository()
+			history := memory.NewStatusHistoryRepository()
+			s := NewOrderService(orders, history, memory.NewTransactor(orders, memory.NewPaymentRepository(), history), fakeStores{}, &fakeCatalog{fail: tt.failing}, newFakeInventory())
+
+			if _, err := s.Create(context.Background(), buyer, tt.input); !errors.Is(err, tt.want) {
+				t.Errorf("Create = %v, want %v", err, tt.want)
+			}
+			if placed, _ := orders.ListByUserID(context.Background(), "dave"); len(placed) != 0 {
+				t.Errorf("rejected order was saved: %+v", placed)
+			}
+		})
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s, inventory, order := newOrderService(t)
+	if order.Status != domain.Pending || order.AmountPaid != 0 || order.StoreID != "store-a" {
+		t.Fatalf("created order = %+v", order)
+	}
+
+	if _, err := s.Ship(ctx, seller, order.ID, ""); !errors.Is(err, domain.ErrStatusTransition) {
+		t.Errorf("Ship pending order = %v, want ErrStatusTransition", err)
+	}
+	if _, err := s.Confirm(ctx, buyer, order.ID, ""); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Confirm by buyer = %v, want ErrForbidden", err)
+	}
+	if _, err := s.Confirm(ctx, seller, order.ID, "Stock checked"); err != nil {
+		t.Fatal(err)
+	}
+	if shipped, err := s.Ship(ctx, seller, order.ID, ""); err != nil || shipped.StockStatus != domain.StockCommitted {
+		t.Fatalf("Ship = %+v, %v", shipped, err)
+	}
+	if inventory.status(order.ID, teapot) != "committed" {
+		t.Errorf("shipped teapot reservation is %q", inventory.status(order.ID, teapot))
+	}
+	if delivered, err := s.Deliver(ctx, seller, order.ID, ""); err != nil || delivered.Status != domain.Delivered {
+		t.Fatalf("Deliver = %+v, %v", delivered, err)
+	}
+	if _, err := s.Cancel(ctx, seller, order.ID, ""); !errors.Is(err, domain.ErrStatusTransition) {
+		t.Errorf("Cancel delivered order = %v, want ErrStatusTransition", err)
+	}
+
+	history, err := s.History(ctx, buyer, order.ID)
+	if err != nil || len(history) != 4 {
+		t.Fatalf("History = %+v, %v", history, err)
+	}
+	want := []domain.OrderStatus{domain.Pending, domain.Confirmed, domain.Shipped, domain.Delivered}
+	for i, entry := range history {
+		if entry.ToStatus != want[i] || i > 0 && entry.FromStatus != want[i-1] {
+			t.Errorf("history[%d] = %+v, want move to %s", i, entry, want[i])
+		}
+	}
+	if history[0].ChangedBy != "dave" || history[1].ChangedBy != "alice" || history[1].Reason != "Stock checked" {
+		t.Errorf("history = %+v", history)
+	}
+	if _, err := s.History(ctx, stranger, order.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("History by stranger = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx := context.Background()
+
+	s, inventory, order := newOrderService(t)
+	if _, err := s.Cancel(ctx, stranger, order.ID, ""); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Cancel by stranger = %v, want ErrNotFound", err)
+	}
+	if cancelled, err := s.Cancel(ctx, buyer, order.ID, "Changed my mind"); err != nil || cancelled.Status != domain.Cancelled || cancelled.StockStatus != domain.StockReleased {
+		t.Errorf("Cancel pending order by buyer = %+v, %v", cancelled, err)
+	}
+	if inventory.available[teapot] != 5 {
+		t.Errorf("%d teapots available after cancelling, want 5", inventory.available[teapot])
+	}
+
+	s, _, order = newOrderService(t)
+	s.Confirm(ctx, seller, order.ID, "")
+	if _, err := s.Cancel(ctx, buyer, order.ID, ""); !errors.Is(err, domain.ErrOrderNotPending) {
+		t.Errorf("Cancel confirmed order by buyer = %v, want ErrOrderNotPending", err)
+	}
+	if cancelled, err := s.Cancel(ctx, seller, order.ID, "Out of stock"); err != nil || cancelled.Status != domain.Cancelled {
+		t.Errorf("Cancel confirmed order by seller = %+v, %v", cancelled, err)
+	}
+}
+
+func TestCreateReservesStock(t *testing.T) {
+	ctx := context.Background()
+	s, inventory, _ := newOrderService(t)
+
+	order, err := s.Create(ctx, buyer, services.OrderInput{
+		ShippingAddressID: address,
+		Items:             []services.OrderItemInput{{ProductID: teapot, Quantity: 2}, {ProductID: cup, Quantity: 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.StockStatus != domain.StockReserved || inventory.available[teapot] != 2 || inventory.available[cup] != 7 {
+		t.Errorf("stock %s, %d teapots and %d cups available", order.StockStatus, inventory.available[teapot], inventory.available[cup])
+	}
+}
+
+func TestCreateReleasesStockOnFailure(t *testing.T) {
+	tests := []struct {
+		name        string
+		cupQuantity int
+		failingCup  bool
+		want        error
+		wantStock   domain.StockStatus
+	}{
+		{"second item out of stock", 11, false, domain.ErrOutOfStock, domain.StockReleased},
+		// The cup cannot be released either, so the sweeper retries
+		{"second item fails", 1, true, domain.ErrInventory, domain.StockReserving},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			orders := memory.NewOrderRepository()
+			history := memory.NewStatusHistoryRepository()
+			inventory := newFakeInventory()
+			s := NewOrderService(orders, history, memory.NewTransactor(orders, memory.NewPaymentRepository(), history), fakeStores{}, &fakeCatalog{}, inventory)
+			inventory.failing[cup] = tt.failingCup
+
+			_, err := s.Create(ctx, buyer, services.OrderInput{
+				ShippingAddressID: address,
+				Items:             []services.OrderItemInput{{ProductID: teapot, Quantity: 2}, {ProductID: cup, Quantity: tt.cupQuantity}},
+			})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Create = %v, want %v", err, tt.want)
+			}
+			var productErr *domain.ProductError
+			if errors.Is(err, domain.ErrOutOfStock) && (!errors.As(err, &productErr) || productErr.ProductID != cup) {
+				t.Errorf("Create = %v, want the cup named", err)
+			}
+			if inventory.available[teapot] != 5 || inventory.available[cup] != 10 {
+				t.Errorf("%d teapots and %d cups available after failing, want 5 and 10", inventory.available[teapot], inventory.available[cup])
+			}
+			if placed, _ := orders.ListByUserID(ctx, "dave"); len(placed) != 1 || placed[0].Status != domain.Cancelled || placed[0].StockStatus != tt.wantStock {
+				t.Errorf("failed order = %+v, want it cancelled with stock %s", placed, tt.wantStock)
+			}
+		})
+	}
+}
+
+// cancellingInventory cancels the request once stock is reserved, as a
+// client hanging up would.
+type cancellingInventory struct {
+	*fakeInventory
+	cancel context.CancelFunc
+}
+
+func (c cancellingInventory) Reserve(ctx context.Context, productID, orderID string, quantity int) error {
+	defer c.cancel()
+	return c.fakeInventory.Reserve(ctx, productID, orderID, quantity)
+}
+
+// unrecordedStock fails to record that an order's stock was reserved.
+type unrecordedStock struct {
+	*memory.OrderRepository
+}
+
+func (u unrecordedStock) SetStockStatus(ctx context.Context, id string, from, to domain.StockStatus) error {
+	if to == domain.StockReserved {
+		return errors.New("database unavailable")
+	}
+	return u.OrderRepository.SetStockStatus(ctx, id, from, to)
+}
+
+func TestCreateCompensatesAfterRequestIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orders := memory.NewOrderRepository()
+	history := memory.NewStatusHistoryRepository()
+	inventory := newFakeInventory()
+	s := NewOrderService(unrecordedStock{orders}, history, memory.NewTransactor(orders, memory.NewPaymentRepository(), history), fakeStores{}, &fakeCatalog{}, cancellingInventory{inventory, cancel})
+
+	if _, err := s.Create(ctx, buyer, services.OrderInput{
+		ShippingAddressID: address,
+		Items:             []services.OrderItemInput{{ProductID: teapot, Quantity: 2}},
+	}); err == nil {
+		t.Fatal("Create succeeded without recording the reserved stock")
+	}
+	if ctx.Err() == nil {
+		t.Fatal("request was not cancelled")
+	}
+
+	placed, _ := orders.ListByUserID(context.Background(), "dave")
+	if len(placed) != 1 || placed[0].Status != domain.Cancelled || placed[0].StockStatus != domain.StockReleased {
+		t.Fatalf("failed order = %+v, want it cancelled with its stock released", placed)
+	}
+	if inventory.available[teapot] != 5 || inventory.status(placed[0].ID, teapot) != "released" {
+		t.Errorf("%d teapots available with the reservation %q", inventory.available[teapot], inventory.status(placed[0].ID, teapot))
+	}
+}
+
+// sweepingInventory runs the sweeper once stock is reserved, as if the
+// request had stalled past the reservation grace period.
+type sweepingInventory struct {
+	*fakeInventory
+	sweep func()
+}
+
+func (s sweepingInventory) Reserve(ctx context.Context, productID, orderID string, quantity int) error {
+	err := s.fakeInventory.Reserve(ctx, productID, orderID, quantity)
+	s.sweep()
+	return err
+}
+
+func TestCreateAfterSweeperAbandonedTheOrder(t *testing.T) {
+	ctx := context.Background()
+	orders := memory.NewOrderRepository()
+	history := memory.NewStatusHistoryRepository()
+	inventory := newFakeInventory()
+	var s *OrderService
+	sweeper := sweepingInventory{inventory, func() {
+		if abandoned, err := s.AbandonReserving(ctx, time.Now().Add(time.Second)); abandoned != 1 || err != nil {
+			t.Errorf("AbandonReserving = %d, %v", abandoned, err)
+		}
+	}}
+	s = NewOrderService(orders, history, memory.NewTransactor(orders, memory.NewPaymentRepository(), history), fakeStores{}, &fakeCatalog{}, sweeper)
+
+	_, err := s.Create(ctx, buyer, services.OrderInput{
+		ShippingAddressID: address,
+		Items:             []services.OrderItemInput{{ProductID: teapot, Quantity: 2}},
+	})
+	if !errors.Is(err, domain.ErrInventory) {
+		t.Fatalf("Create = %v, want %v", err, domain.ErrInventory)
+	}
+
+	placed, _ := orders.ListByUserID(ctx, "dave")
+	if len(placed) != 1 || placed[0].Status != domain.Cancelled || placed[0].StockStatus != domain.StockReleased {
+		t.Fatalf("abandoned order = %+v, want it cancelled with its stock released", placed)
+	}
+	if inventory.available[teapot] != 5 {
+		t.Errorf("%d teapots available, want 5", inventory.available[teapot])
+	}
+}
+
+func TestAbandonReserving(t *testing.T) {
+	ctx := context.Background()
+	s, inventory, placed := newOrderService(t)
+
+	// An order whose request died after reserving, before recording it
+	stuck := domain.Order{
+		UserID:      "dave",
+		Status:      domain.Pending,
+		StockStatus: domain.StockReserving,
+		OrderItems:  []domain.OrderItem{{ProductID: cup, Quantity: 2}},
+	}
+	if err := s.orders.Create(ctx, &stuck); err != nil {
+		t.Fatal(err)
+	}
+	if err := inventory.Reserve(ctx, cup, stuck.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if abandoned, err := s.AbandonReserving(ctx, stuck.CreatedAt); abandoned != 0 || err != nil {
+		t.Errorf("AbandonReserving before the order = %d, %v", abandoned, err)
+	}
+	if abandoned, err := s.AbandonReserving(ctx, time.Now().Add(time.Second)); abandoned != 1 || err != nil {
+		t.Fatalf("AbandonReserving = %d, %v", abandoned, err)
+	}
+	if order, _ := s.orders.FindByID(ctx, stuck.ID); order.Status != domain.Cancelled || order.StockStatus != domain.StockReleased || inventory.available[cup] != 10 {
+		t.Errorf("abandoned order = %+v with %d cups available", order, inventory.available[cup])
+	}
+	if order, _ := s.orders.FindByID(ctx, placed.ID); order.Status != domain.Pending || order.StockStatus != domain.StockReserved {
+		t.Errorf("placed order = %+v", order)
+	}
+}
+
+func TestSettleStockRetriesFailedRelease(t *testing.T) {
+	ctx := context.Background()
+	s, inventory, order := newOrderService(t)
+
+	inventory.failing[teapot] = true
+	cancelled, err := s.Cancel(ctx, buyer, order.ID, "")
+	if err != nil || cancelled.Status != domain.Cancelled || cancelled.StockStatus != domain.StockReserved {
+		t.Fatalf("Cancel with inventory down = %+v, %v", cancelled, err)
+	}
+	if settled, err := s.SettleStock(ctx); settled != 0 || err == nil {
+		t.Errorf("SettleStock with inventory down = %d, %v", settled, err)
+	}
+
+	inventory.failing[teapot] = false
+	if settled, err := s.SettleStock(ctx); settled != 1 || err != nil {
+		t.Fatalf("SettleStock = %d, %v", settled, err)
+	}
+	if order, _ := s.orders.FindByID(ctx, order.ID); order.StockStatus != domain.StockReleased || inventory.available[teapot] != 5 {
+		t.Errorf("after settling order = %+v with %d teapots available", order, inventory.available[teapot])
+	}
+	if settled, err := s.SettleStock(ctx); settled != 0 || err != nil {
+		t.Errorf("SettleStock with nothing left = %d, %v", settled, err)
+	}
+}
+
+func TestExpireUnpaid(t *testing.T) {
+	ctx := context.Background()
+	s, inventory, unpaid := newOrderService(t)
+	partlyPaid, err := s.Create(ctx, buyer, services.OrderInput{
+		ShippingAddressID: address,
+		Items:             []services.OrderItemInput{{ProductID: cup, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	partlyPaid.AmountPaid = 0.05
+	s.orders.Update(ctx, &partlyPaid)
+
+	if expired, err := s.ExpireUnpaid(ctx, unpaid.CreatedAt); expired != 0 || err != nil {
+		t.Errorf("ExpireUnpaid before any order = %d, %v", expired, err)
+	}
+	if expired, err := s.ExpireUnpaid(ctx, time.Now().Add(time.Second)); expired != 1 || err != nil {
+		t.Fatalf("ExpireUnpaid = %d, %v", expired, err)
+	}
+
+	order, _ := s.orders.FindByID(ctx, unpaid.ID)
+	if order.Status != domain.Cancelled || order.StockStatus != domain.StockReleased || inventory.available[teapot] != 5 {
+		t.Errorf("expired order = %+v with %d teapots available", order, inventory.available[teapot])
+	}
+	history, _ := s.History(ctx, buyer, unpaid.ID)
+	if last := history[len(history)-1]; last.ChangedBy != "system" || last.Reason != "Payment timed out" {
+		t.Errorf("expiry recorded as %+v", last)
+	}
+	if order, _ := s.orders.FindByID(ctx, partlyPaid.ID); order.Status != domain.Pending {
+		t.Errorf("partly paid order = %+v", order)
+	}
+}
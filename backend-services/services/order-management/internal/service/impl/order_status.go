@@ -0,0 +1,24 @@
+package impl
+
+import (
+	"context"
+	"order-management/internal/domain"
+	repositories "order-management/internal/repository/interfaces"
+)
+
+// changeStatus moves order to status if the state machine allows it and
+// records the change. The caller saves the order.
+func changeStatus(ctx context.Context, repos repositories.Repositories, order *domain.Order, status domain.OrderStatus, actor, reason string) error {
+	if !order.Status.CanTransitionTo(status) {
+		return domain.ErrStatusTransition
+	}
+	entry := domain.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: order.Status,
+		ToStatus:   status,
+		ChangedBy:  actor,
+		Reason:     reason,
+	}
+	order.Status = status
+	return repos.History.Create(ctx, &entry)
+}
@@ -0,0 +1,104 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-management/internal/domain"
+	repositories "order-management/internal/repository/interfaces"
+	services "order-management/internal/service/interfaces"
+	"shared/catalog"
+	"time"
+)
+
+// compensationTimeout bounds each call made to record or undo a stock
+// reservation once the request that asked for it may have gone.
+const compensationTimeout = 10 * time.Second
+
+// detached returns a context that outlives the cancellation of ctx, keeping
+// its values, for compensating calls that must run whatever happened to
+// the caller.
+func detached(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+}
+
+// reserveStock reserves every item of the order, stopping at the first
+// that cannot be reserved. The caller releases the order's items when it
+// fails, the failed one included in case product-catalog made the
+// reservation before the call failed.
+func reserveStock(ctx context.Context, inventory services.Inventory, order domain.Order) error {
+	for _, item := range order.OrderItems {
+		err := inventory.Reserve(ctx, item.ProductID, order.ID, item.Quantity)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, catalog.ErrInsufficientStock) {
+			return &domain.ProductError{ProductID: item.ProductID, Err: domain.ErrOutOfStock}
+		}
+		return fmt.Errorf("%w: %v", domain.ErrInventory, err)
+	}
+	return nil
+}
+
+// releaseStock releases the stock of items, carrying on past failures.
+func releaseStock(ctx context.Context, inventory services.Inventory, orderID string, items []domain.OrderItem) error {
+	var errs []error
+	for _, item := range items {
+		if err := inventory.Release(ctx, item.ProductID, orderID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// settleStock releases the reserved stock of a cancelled order and commits
+// that of a shipped or delivered one, then records it on the order. Other
+// orders are left alone, as are orders still reserving stock unless they
+// were cancelled.
+func settleStock(ctx context.Context, orders repositories.OrderRepository, inventory services.Inventory, order *domain.Order) error {
+	switch order.StockStatus {
+	case domain.StockReserved:
+	case domain.StockReserving:
+		if order.Status != domain.Cancelled {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	var settled domain.StockStatus
+	switch order.Status {
+	case domain.Cancelled:
+		settled = domain.StockReleased
+		if err := releaseStock(ctx, inventory, order.ID, order.OrderItems); err != nil {
+			return err
+		}
+	case domain.Shipped, domain.Delivered:
+		settled = domain.StockCommitted
+		for _, item := range order.OrderItems {
+			if err := inventory.Commit(ctx, item.ProductID, order.ID); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	if err := orders.SetStockStatus(ctx, order.ID, order.StockStatus, settled); err != nil {
+		return err
+	}
+	order.StockStatus = settled
+	return nil
+}
+
+// settleStockLater is settleStock for callers that have already changed
+// the order: it runs even if ctx is cancelled, failures are logged, and the
+// sweeper settles the stock later.
+func settleStockLater(ctx context.Context, orders repositories.OrderRepository, inventory services.Inventory, order *domain.Order) {
+	detachedCtx, cancel := detached(ctx)
+	defer cancel()
+	if err := settleStock(detachedCtx, orders, inventory, order); err != nil {
+		slog.WarnContext(ctx, "Failed to settle order stock, leaving it to the sweeper", "order_id", order.ID, "status", order.Status, "error", err)
+	}
+}
@@ -0,0 +1,179 @@
+package impl
+
+import (
+	"context"
+	"order-management/internal/domain"
+	repositories "order-management/internal/repository/interfaces"
+	services "order-management/internal/service/interfaces"
+	"shared/auth"
+)
+
+var _ services.PaymentService = (*PaymentService)(nil)
+
+type PaymentService struct {
+	orders    repositories.OrderRepository
+	payments  repositories.PaymentRepository
+	tx        repositories.Transactor
+	stores    services.StoreAccess
+	inventory services.Inventory
+}
+
+func NewPaymentService(orders repositories.OrderRepository, payments repositories.PaymentRepository, tx repositories.Transactor, stores services.StoreAccess, inventory services.Inventory) *PaymentService {
+	return &PaymentService{orders: orders, payments: payments, tx: tx, stores: stores, inventory: inventory}
+}
+
+func (s *PaymentService) Record(ctx context.Context, claims auth.Claims, orderID string, input services.PaymentInput) (domain.Payment, error) {
+	if cents(input.Amount) <= 0 || !input.Method.Valid() {
+		return domain.Payment{}, domain.ErrInvalidPayment
+	}
+	if _, err := visibleOrder(ctx, s.orders, s.stores, claims, orderID); err != nil {
+		return domain.Payment{}, err
+	}
+
+	payment := domain.Payment{
+		OrderID:       orderID,
+		Amount:        input.Amount,
+		Method:        input.Method,
+		Status:        domain.PaymentPending,
+		TransactionID: input.TransactionID,
+		Notes:         input.Notes,
+		RecordedBy:    claims.ID,
+	}
+	err := s.tx.InTx(ctx, func(repos repositories.Repositories) error {
+		order, err := repos.Orders.FindByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status == domain.Cancelled {
+			return domain.ErrOrderCancelled
+		}
+		// Pending attempts may still fail, so only completed payments
+		// reduce the balance
+		if cents(input.Amount) > cents(order.TotalAmount)-cents(order.AmountPaid) {
+			return domain.ErrOverpayment
+		}
+		return repos.Payments.Create(ctx, &payment)
+	})
+	if err != nil {
+		return domain.Payment{}, err
+	}
+	return payment, nil
+}
+
+func (s *PaymentService) List(ctx context.Context, claims auth.Claims, orderID string) ([]domain.Payment, error) {
+	if _, err := visibleOrder(ctx, s.orders, s.stores, claims, orderID); err != nil {
+		return nil, err
+	}
+	return s.payments.ListByOrderID(ctx, orderID)
+}
+
+func (s *PaymentService) Confirm(ctx context.Context, claims auth.Claims, orderID, paymentID, transactionID string) (domain.Payment, error) {
+	return s.settle(ctx, claims, orderID, paymentID, func(repos repositories.Repositories, order *domain.Order, payment *domain.Payment) error {
+		if payment.Status != domain.PaymentPending {
+			return domain.ErrPaymentTransition
+		}
+		if order.Status == domain.Cancelled {
+			return domain.ErrOrderCancelled
+		}
+		paid := cents(order.AmountPaid) + cents(payment.Amount)
+		if paid > cents(order.TotalAmount) {
+			return domain.ErrOverpayment
+		}
+
+		payment.Status = domain.PaymentCompleted
+		if transactionID != "" {
+			payment.TransactionID = transactionID
+		}
+		order.AmountPaid = float64(paid) / 100
+		if order.Status == domain.Pending && paid == cents(order.TotalAmount) {
+			return changeStatus(ctx, repos, order, domain.Confirmed, claims.ID, "Paid in full")
+		}
+		return nil
+	})
+}
+
+func (s *PaymentService) Fail(ctx context.Context, claims auth.Claims, orderID, paymentID, reason string) (domain.Payment, error) {
+	return s.settle(ctx, claims, orderID, paymentID, func(repos repositories.Repositories, order *domain.Order, payment *domain.Payment) error {
+		if payment.Status != domain.PaymentPending {
+			return domain.ErrPaymentTransition
+		}
+		payment.Status = domain.PaymentFailed
+		payment.Notes = appendNote(payment.Notes, reason)
+		return nil
+	})
+}
+
+func (s *PaymentService) Refund(ctx context.Context, claims auth.Claims, orderID, paymentID, reason string) (domain.Payment, error) {
+	return s.settle(ctx, claims, orderID, paymentID, func(repos repositories.Repositories, order *domain.Order, payment *domain.Payment) error {
+		if payment.Status != domain.PaymentCompleted {
+			return domain.ErrPaymentTransition
+		}
+		payment.Status = domain.PaymentRefunded
+		payment.Notes = appendNote(payment.Notes, reason)
+
+		paid := cents(order.AmountPaid) - cents(payment.Amount)
+		if paid < 0 {
+			paid = 0
+		}
+		order.AmountPaid = float64(paid) / 100
+		// An order confirmed on payment is called off once nothing of it
+		// is paid; shipped orders are left for the seller to resolve
+		if order.Status == domain.Confirmed && paid == 0 {
+			return changeStatus(ctx, repos, order, domain.Cancelled, claims.ID, "Payments refunded")
+		}
+		return nil
+	})
+}
+
+// settle applies change to a payment and its order, which the caller must
+// sell for, saving both together.
+func (s *PaymentService) settle(ctx context.Context, claims auth.Claims, orderID, paymentID string, change func(repositories.Repositories, *domain.Order, *domain.Payment) error) (domain.Payment, error) {
+	if _, err := managedOrder(ctx, s.orders, s.stores, claims, orderID); err != nil {
+		return domain.Payment{}, err
+	}
+
+	var (
+		order   domain.Order
+		payment domain.Payment
+	)
+	err := s.tx.InTx(ctx, func(repos repositories.Repositories) error {
+		var err error
+		order, err = repos.Orders.FindByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		payment, err = repos.Payments.FindByID(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+		if payment.OrderID != order.ID {
+			return domain.ErrNotFound
+		}
+
+		if err := change(repos, &order, &payment); err != nil {
+			return err
+		}
+		if err := repos.Payments.Update(ctx, &payment); err != nil {
+			return err
+		}
+		return repos.Orders.Update(ctx, &order)
+	})
+	if err != nil {
+		return domain.Payment{}, err
+	}
+	// A refund may have cancelled the order
+	settleStockLater(ctx, s.orders, s.inventory, &order)
+	return payment, nil
+}
+
+// appendNote adds note on a line of its own.
+func appendNote(notes, note string) string {
+	switch {
+	case note == "":
+		return notes
+	case notes == "":
+		return note
+	default:
+		return notes + "\n" + note
+	}
+}
@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"order-management/internal/domain"
+	"order-management/internal/repository/memory"
+	services "order-management/internal/service/interfaces"
+	"shared/auth"
+	"testing"
+)
+
+type fakeStores map[string][]string
+
+func (f fakeStores) CanManage(ctx context.Context, claims auth.Claims, storeID string) (bool, error) {
+	for _, id := range f[claims.ID] {
+		if id == storeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var (
+	seller   = auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+	buyer    = auth.Claims{ID: "dave", Role: auth.RoleCustomer}
+	stranger = auth.Claims{ID: "erin", Role: auth.RoleCustomer}
+)
+
+// newPaymentService returns a service over order-1, placed by dave with
+// alice's store-a for 100 and holding a reserved teapot.
+func newPaymentService(t *testing.T) (*PaymentService, *memory.OrderRepository, *fakeInventory) {
+	t.Helper()
+	ctx := context.Background()
+	orders := memory.NewOrderRepository()
+	payments := memory.NewPaymentRepository()
+	history := memory.NewStatusHistoryRepository()
+	inventory := newFakeInventory()
+	order := domain.Order{
+		ID: "order-1", UserID: "dave", StoreID: "store-a", OrderNumber: "ORD-1", TotalAmount: 100,
+		StockStatus: domain.StockReserved,
+		OrderItems:  []domain.OrderItem{{ProductID: teapot, Quantity: 1, UnitPrice: 100, TotalPrice: 100}},
+	}
+	if err := orders.Create(ctx, &order); err != nil {
+		t.Fatal(err)
+	}
+	if err := inventory.Reserve(ctx, teapot, "order-1", 1); err != nil {
+		t.Fatal(err)
+	}
+	return NewPaymentService(orders, payments, memory.NewTransactor(orders, payments, history), fakeStores{"alice": {"store-a"}}, inventory), orders, inventory
+}
+
+func TestPartialPaymentsConfirmOrder(t *testing.T) {
+	ctx := context.Background()
+	s, orders, _ := newPaymentService(t)
+
+	first, err := s.Record(ctx, buyer, "order-1", services.PaymentInput{Amount: 40, Method: domain.BankTransfer})
+	if err != nil || first.Status != domain.PaymentPending || first.RecordedBy != "dave" {
+		t.Fatalf("Record = %+v, %v", first, err)
+	}
+	if _, err := s.Fail(ctx, seller, "order-1", first.ID, "Transfer bounced"); err != nil {
+		t.Fatal(err)
+	}
+	// A failed attempt leaves the balance open for another
+	retry, _ := s.Record(ctx, buyer, "order-1", services.PaymentInput{Amount: 40, Method: domain.BankTransfer})
+	rest, _ := s.Record(ctx, seller, "order-1", services.PaymentInput{Amount: 60, Method: domain.CashOnDelivery})
+
+	if _, err := s.Confirm(ctx, seller, "order-1", retry.ID, "TX-1"); err != nil {
+		t.Fatal(err)
+	}
+	if order, _ := orders.FindByID(ctx, "order-1"); order.AmountPaid != 40 || order.Status != domain.Pending {
+		t.Errorf("after partial payment order = %+v", order)
+	}
+	if _, err := s.Record(ctx, buyer, "order-1", services.PaymentInput{Amount: 60.01, Method: domain.WhatsApp}); !errors.Is(err, domain.ErrOverpayment) {
+		t.Errorf("Record beyond balance = %v, want ErrOverpayment", err)
+	}
+	if _, err := s.Confirm(ctx, seller, "order-1", rest.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if order, _ := orders.FindByID(ctx, "order-1"); order.AmountPaid != 100 || order.Status != domain.Confirmed {
+		t.Errorf("after full payment order = %+v", order)
+	}
+
+	payments, err := s.List(ctx, buyer, "order-1")
+	if err != nil || len(payments) != 3 {
+		t.Fatalf("List = %+v, %v", payments, err)
+	}
+	if payments[0].Status != domain.PaymentFailed || payments[0].Notes != "Transfer bounced" || payments[1].TransactionID != "TX-1" {
+		t.Errorf("payments = %+v", payments)
+	}
+}
+
+func TestRefundCancelsUnpaidOrder(t *testing.T) {
+	ctx := context.Background()
+	s, orders, inventory := newPaymentService(t)
+
+	payment, _ := s.Record(ctx, buyer, "order-1", services.PaymentInput{Amount: 100, Method: domain.WhatsApp})
+	if _, err := s.Refund(ctx, seller, "order-1", payment.ID, ""); !errors.Is(err, domain.ErrPaymentTransition) {
+		t.Errorf("Refund of pending payment = %v, want ErrPaymentTransition", err)
+	}
+	s.Confirm(ctx, seller, "order-1", payment.ID, "")
+
+	refunded, err := s.Refund(ctx, seller, "order-1", payment.ID, "Out of stock")
+	if err != nil || refunded.Status != domain.PaymentRefunded {
+		t.Fatalf("Refund = %+v, %v", refunded, err)
+	}
+	if order, _ := orders.FindByID(ctx, "order-1"); order.AmountPaid != 0 || order.Status != domain.Cancelled || order.StockStatus != domain.StockReleased {
+		t.Errorf("after refund order = %+v", order)
+	}
+	if inventory.available[teapot] != 5 {
+		t.Errorf("%d teapots available after refund, want 5", inventory.available[teapot])
+	}
+	if _, err := s.Record(ctx, buyer, "order-1", services.PaymentInput{Amount: 10, Method: domain.WhatsApp}); !errors.Is(err, domain.ErrOrderCancelled) {
+		t.Errorf("Record on cancelled order = %v, want ErrOrderCancelled", err)
+	}
+}
+
+func TestPaymentAccess(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newPaymentService(t)
+	payment, _ := s.Record(ctx, buyer, "order-1", services.PaymentInput{Amount: 10, Method: domain.WhatsApp})
+
+	if _, err := s.Record(ctx, buyer, "order-1", services.PaymentInput{Amount: 10, Method: "card"}); !errors.Is(err, domain.ErrInvalidPayment) {
+		t.Errorf("Record with unknown method = %v, want ErrInvalidPayment", err)
+	}
+	if _, err := s.List(ctx, stranger, "order-1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("List by stranger = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Confirm(ctx, buyer, "order-1", payment.ID, ""); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Confirm by buyer = %v, want ErrForbidden", err)
+	}
+	if _, err := s.Confirm(ctx, auth.Claims{ID: "bob", Role: auth.RoleStoreOwner}, "order-1", payment.ID, ""); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Confirm by another store = %v, want ErrNotFound", err)
+	}
+}
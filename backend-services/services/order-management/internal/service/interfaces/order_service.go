@@ -0,0 +1,90 @@
+// Package interfaces declares the order-management business operations
+// used by the handlers and background jobs.
+package interfaces
+
+import (
+	"context"
+	"order-management/internal/domain"
+	"shared/auth"
+	"shared/catalog"
+	"time"
+)
+
+// StoreAccess resolves the stores a caller owns or staffs.
+type StoreAccess interface {
+	CanManage(ctx context.Context, claims auth.Claims, storeID string) (bool, error)
+}
+
+// Catalog looks up the current state of products in product-catalog.
+// Products that do not exist are left out.
+type Catalog interface {
+	Products(ctx context.Context, ids []string) ([]catalog.Product, error)
+}
+
+// Inventory reserves stock in product-catalog for an order. Every call
+// may be repeated safely.
+type Inventory interface {
+	// Reserve returns catalog.ErrInsufficientStock if the product does
+	// not have quantity available.
+	Reserve(ctx context.Context, productID, orderID string, quantity int) error
+	// Release returns the order's stock. Releasing stock that was never
+	// reserved stops a late reservation from being made.
+	Release(ctx context.Context, productID, orderID string) error
+	// Commit takes the order's stock off the shelf.
+	Commit(ctx context.Context, productID, orderID string) error
+}
+
+// OrderItemInput is a product and quantity being ordered.
+type OrderItemInput struct {
+	ProductID string
+	Quantity  int
+}
+
+// OrderInput describes an order being placed. Prices come from the
+// catalog, never from the customer.
+type OrderInput struct {
+	ShippingAddressID string
+	Items             []OrderItemInput
+}
+
+// OrderService places orders and moves them through their statuses.
+// Orders are visible to the customer who placed them and to the sellers
+// of their store; others are told they do not exist. Status changes that
+// skip or reverse a step return domain.ErrStatusTransition, and each
+// change is recorded in the order's history.
+type OrderService interface {
+	// Create places a pending, unpaid order of the caller, priced at the
+	// catalog's current prices. All items must be active products of the
+	// same store, which the order is placed with. Stock is reserved for
+	// every item, or for none of them, in which case the order is saved
+	// cancelled.
+	Create(ctx context.Context, claims auth.Claims, input OrderInput) (domain.Order, error)
+	// ListMine returns the orders the caller placed.
+	ListMine(ctx context.Context, claims auth.Claims) ([]domain.Order, error)
+	Get(ctx context.Context, claims auth.Claims, id string) (domain.Order, error)
+	// Confirm, Ship and Deliver are for the sellers of the order's store.
+	Confirm(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error)
+	Ship(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error)
+	Deliver(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error)
+	// Cancel calls off an order. Its customer may only cancel it while it
+	// is pending, and gets domain.ErrOrderNotPending afterwards. Orders
+	// with completed payments get domain.ErrOrderPaid until the payments
+	// are refunded.
+	Cancel(ctx context.Context, claims auth.Claims, id, reason string) (domain.Order, error)
+	// History returns the order's status changes, oldest first.
+	History(ctx context.Context, claims auth.Claims, id string) ([]domain.OrderStatusHistory, error)
+}
+
+// Sweeper is the order housekeeping run in the background. Both
+// operations return how many orders they handled.
+type Sweeper interface {
+	// ExpireUnpaid cancels the pending orders placed before the given
+	// time that nothing has been paid for, releasing their stock.
+	ExpireUnpaid(ctx context.Context, before time.Time) (int, error)
+	// AbandonReserving cancels the orders placed before the given time
+	// whose stock reservation never finished, releasing their stock.
+	AbandonReserving(ctx context.Context, before time.Time) (int, error)
+	// SettleStock releases or commits the stock of orders whose earlier
+	// attempt failed.
+	SettleStock(ctx context.Context) (int, error)
+}
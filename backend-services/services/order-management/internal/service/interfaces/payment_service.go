@@ -0,0 +1,35 @@
+package interfaces
+
+import (
+	"context"
+	"order-management/internal/domain"
+	"shared/auth"
+)
+
+// PaymentInput describes a payment being recorded.
+type PaymentInput struct {
+	Amount        float64
+	Method        domain.PaymentMethod
+	TransactionID string
+	Notes         string
+}
+
+// PaymentService records and settles payments for orders. An order's
+// customer may record and list its payments; settling them is for the
+// sellers of the order's store. Orders the caller may not see are not
+// found, and settling by anyone else returns domain.ErrForbidden.
+type PaymentService interface {
+	// Record adds a pending payment of at most the order's outstanding
+	// balance.
+	Record(ctx context.Context, claims auth.Claims, orderID string, input PaymentInput) (domain.Payment, error)
+	List(ctx context.Context, claims auth.Claims, orderID string) ([]domain.Payment, error)
+	// Confirm completes a pending payment, confirming a pending order
+	// once it is fully paid. transactionID, if set, replaces the
+	// payment's.
+	Confirm(ctx context.Context, claims auth.Claims, orderID, paymentID, transactionID string) (domain.Payment, error)
+	// Fail marks a pending payment as failed.
+	Fail(ctx context.Context, claims auth.Claims, orderID, paymentID, reason string) (domain.Payment, error)
+	// Refund returns a completed payment, cancelling a confirmed order
+	// that is no longer paid for at all and releasing its stock.
+	Refund(ctx context.Context, claims auth.Claims, orderID, paymentID, reason string) (domain.Payment, error)
+}
@@ -3,47 +3,81 @@ package routes
 import (
 	"log"
 	"product-catalog/internal/handlers"
-	"product-catalog/internal/middleware"
+	"product-catalog/internal/repository/postgres"
+	"product-catalog/internal/service/impl"
+	"product-catalog/internal/util"
+	"shared/auth"
+	"shared/stores"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
 
+// Handlers are the handlers served by the product catalog service.
+type Handlers struct {
+	Product   *handlers.ProductHandler
+	Image     *handlers.ImageHandler
+	Inventory *handlers.InventoryHandler
+}
+
 // SetupRoutes configures all the routes for the product catalog service
 func SetupRoutes(r *mux.Router, db *gorm.DB) {
-	// Initialize handlers
-	productHandler, err := handlers.NewProductHandler(db)
+	// Store ownership is resolved through store-management
+	storeResolver, err := stores.NewResolverFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to initialize product handler: %v", err)
+		log.Fatalf("Failed to initialize store resolver: %v", err)
 	}
-	imageHandler, err := handlers.NewImageHandler(db)
+	cloudinary, err := util.NewCloudinaryService()
 	if err != nil {
-		log.Fatalf("Failed to initialize image handler: %v", err)
+		log.Fatalf("Failed to initialize Cloudinary: %v", err)
 	}
-	inventoryHandler := handlers.NewInventoryHandler(db)
+	products := postgres.NewProductRepository(db)
+	images := postgres.NewImageRepository(db)
+	inventory := postgres.NewInventoryRepository(db)
+	movements := postgres.NewMovementRepository(db)
+	tx := postgres.NewTransactor(db)
 
 	// Initialize middleware
-	authMiddleware, err := middleware.NewAuthMiddleware()
+	authMiddleware, err := auth.NewMiddleware()
 	if err != nil {
 		log.Fatalf("Failed to initialize auth middleware: %v", err)
 	}
+	// Users owning a store act as store owners
+	authMiddleware.PromoteStoreOwners(storeResolver)
 
+	Register(r, authMiddleware, Handlers{
+		Product:   handlers.NewProductHandler(impl.NewProductService(products, images, cloudinary, storeResolver)),
+		Image:     handlers.NewImageHandler(impl.NewImageService(products, images, cloudinary, storeResolver)),
+		Inventory: handlers.NewInventoryHandler(impl.NewInventoryService(products, inventory, movements, tx, storeResolver)),
+	})
+}
+
+// Register adds the routes served by h, guarded by authMiddleware.
+func Register(r *mux.Router, authMiddleware *auth.Middleware, h Handlers) {
 	// Product routes
-	r.HandleFunc("/api/products", authMiddleware.ValidateToken(productHandler.CreateProduct)).Methods("POST")
-	r.HandleFunc("/api/products/{id}", productHandler.GetProduct).Methods("GET")
-	r.HandleFunc("/api/products/{id}", authMiddleware.ValidateToken(productHandler.UpdateProduct)).Methods("PUT")
-	r.HandleFunc("/api/products/{id}", authMiddleware.ValidateToken(productHandler.DeleteProduct)).Methods("DELETE")
+	r.HandleFunc("/api/products", authMiddleware.Require(auth.PermProductWrite, h.Product.CreateProduct)).Methods("POST")
+	r.HandleFunc("/api/products/{id}", h.Product.GetProduct).Methods("GET")
+	r.HandleFunc("/api/products/{id}", authMiddleware.Require(auth.PermProductWrite, h.Product.UpdateProduct)).Methods("PUT")
+	r.HandleFunc("/api/products/{id}", authMiddleware.Require(auth.PermProductWrite, h.Product.DeleteProduct)).Methods("DELETE")
 
 	// Product image routes (integrated with products)
-	r.HandleFunc("/api/products/{productId}/images", authMiddleware.ValidateToken(imageHandler.UploadImage)).Methods("POST")
-	r.HandleFunc("/api/products/{productId}/images/{imageId}", authMiddleware.ValidateToken(imageHandler.DeleteImage)).Methods("DELETE")
-	r.HandleFunc("/api/products/{productId}/images/{imageId}/primary", authMiddleware.ValidateToken(imageHandler.SetPrimaryImage)).Methods("PUT")
-	r.HandleFunc("/api/products/{productId}/images/{imageId}/alt-text", authMiddleware.ValidateToken(imageHandler.UpdateImageAltText)).Methods("PUT")
+	r.HandleFunc("/api/products/{productId}/images", authMiddleware.Require(auth.PermProductWrite, h.Image.UploadImage)).Methods("POST")
+	r.HandleFunc("/api/products/{productId}/images/{imageId}", authMiddleware.Require(auth.PermProductWrite, h.Image.DeleteImage)).Methods("DELETE")
+	r.HandleFunc("/api/products/{productId}/images/{imageId}/primary", authMiddleware.Require(auth.PermProductWrite, h.Image.SetPrimaryImage)).Methods("PUT")
+	r.HandleFunc("/api/products/{productId}/images/{imageId}/alt-text", authMiddleware.Require(auth.PermProductWrite, h.Image.UpdateImageAltText)).Methods("PUT")
 
 	// Store-specific product routes
-	r.HandleFunc("/api/stores/{storeId}/products", authMiddleware.ValidateToken(productHandler.GetProductsByStore)).Methods("GET")
+	r.HandleFunc("/api/stores/{storeId}/products", authMiddleware.Require(auth.PermProductRead, h.Product.GetProductsByStore)).Methods("GET")
 
 	// Inventory routes
-	r.HandleFunc("/api/products/{productId}/inventory", productHandler.GetProduct).Methods("GET")
-	r.HandleFunc("/api/products/{productId}/inventory", authMiddleware.ValidateToken(inventoryHandler.UpdateInventory)).Methods("PUT")
+	r.HandleFunc("/api/products/{productId}/inventory", h.Inventory.GetInventory).Methods("GET")
+	r.HandleFunc("/api/products/{productId}/inventory/receive", authMiddleware.Require(auth.PermInventoryWrite, h.Inventory.ReceiveStock)).Methods("POST")
+	r.HandleFunc("/api/products/{productId}/inventory/adjust", authMiddleware.Require(auth.PermInventoryWrite, h.Inventory.AdjustStock)).Methods("POST")
+	r.HandleFunc("/api/products/{productId}/inventory/movements", authMiddleware.Require(auth.PermInventoryWrite, h.Inventory.GetMovements)).Methods("GET")
+
+	// Internal routes, called by other services only
+	r.HandleFunc("/internal/products", h.Product.LookupProducts).Methods("GET")
+	r.HandleFunc("/internal/products/{productId}/reservations", h.Inventory.ReserveStock).Methods("POST")
+	r.HandleFunc("/internal/products/{productId}/reservations/{orderId}", h.Inventory.ReleaseStock).Methods("DELETE")
+	r.HandleFunc("/internal/products/{productId}/reservations/{orderId}/commit", h.Inventory.CommitStock).Methods("POST")
 }
@@ -0,0 +1,17 @@
+-- Databases set up by GORM's AutoMigrate before versioned migrations
+-- already have the initial tables, so these migrations only create what
+-- is missing.
+CREATE TABLE IF NOT EXISTS products (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id    text NOT NULL,
+    name        text NOT NULL,
+    description text,
+    category    text,
+    price       decimal(10,2),
+    sku         text,
+    is_active   boolean DEFAULT true,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_store_id ON products (store_id);
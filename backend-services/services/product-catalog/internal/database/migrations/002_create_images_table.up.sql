@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS images (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id uuid NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    url        text NOT NULL,
+    alt_text   text,
+    is_primary boolean DEFAULT false
+);
+
+CREATE INDEX IF NOT EXISTS idx_images_product_id ON images (product_id);
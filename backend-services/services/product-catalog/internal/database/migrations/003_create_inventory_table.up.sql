@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS inventories (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id uuid NOT NULL UNIQUE,
+    quantity   bigint NOT NULL DEFAULT 0,
+    reserved   bigint DEFAULT 0,
+    updated_at timestamptz
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS reviews (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid NOT NULL,
+    product_id uuid NOT NULL,
+    rating     bigint NOT NULL,
+    comment    text,
+    created_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_product_id ON reviews (product_id);
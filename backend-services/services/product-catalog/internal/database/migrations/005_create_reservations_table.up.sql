@@ -0,0 +1,14 @@
+-- Stock held for orders. A release that arrives before its reservation
+-- is kept as a released row with no quantity, so the late reservation is
+-- refused instead of holding stock for an abandoned order.
+CREATE TABLE reservations (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id   uuid NOT NULL,
+    product_id uuid NOT NULL,
+    quantity   bigint NOT NULL CHECK (quantity >= 0),
+    status     varchar(20) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE UNIQUE INDEX idx_reservations_order_product ON reservations (order_id, product_id);
@@ -0,0 +1,16 @@
+-- Every change to a product's stock is recorded here, newest read first.
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id     uuid NOT NULL,
+    type           varchar(20) NOT NULL,
+    quantity_delta bigint NOT NULL,
+    reserved_delta bigint NOT NULL,
+    quantity_after bigint NOT NULL,
+    reserved_after bigint NOT NULL,
+    reason         text,
+    actor          text NOT NULL,
+    order_id       uuid,
+    created_at     timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_product_created ON inventory_movements (product_id, created_at DESC);
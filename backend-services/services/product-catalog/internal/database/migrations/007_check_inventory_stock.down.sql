@@ -0,0 +1,2 @@
+ALTER TABLE inventories DROP CONSTRAINT IF EXISTS chk_inventories_stock;
+ALTER TABLE inventories ALTER COLUMN reserved DROP NOT NULL;
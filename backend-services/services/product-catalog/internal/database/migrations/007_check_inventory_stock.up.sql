@@ -0,0 +1,8 @@
+-- Stock only changes by deltas now, which must never take it negative or
+-- reserve more than is on hand. Rows written before are left as they are.
+-- Databases that ran an earlier 006 already have the constraint.
+UPDATE inventories SET reserved = 0 WHERE reserved IS NULL;
+ALTER TABLE inventories ALTER COLUMN reserved SET NOT NULL;
+ALTER TABLE inventories DROP CONSTRAINT IF EXISTS chk_inventories_stock;
+ALTER TABLE inventories ADD CONSTRAINT chk_inventories_stock
+    CHECK (quantity >= 0 AND reserved >= 0 AND reserved <= quantity) NOT VALID;
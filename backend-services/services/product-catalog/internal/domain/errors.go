@@ -0,0 +1,18 @@
+package domain
+
+import "errors"
+
+// Errors returned by repositories and services. Handlers map them to
+// HTTP statuses.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrForbidden          = errors.New("store access denied")
+	ErrStoreLookup        = errors.New("failed to verify store access")
+	ErrNoStore            = errors.New("no store to add the product to")
+	ErrStoreRequired      = errors.New("store_id is required when managing several stores")
+	ErrNoChanges          = errors.New("no valid fields to update")
+	ErrInvalidInventory   = errors.New("stock changes need a quantity, and adjustments a reason")
+	ErrOutOfStock         = errors.New("not enough stock available")
+	ErrInvalidReservation = errors.New("reservation needs an order id and a positive quantity")
+	ErrReservationEnded   = errors.New("reservation was already released or committed")
+)
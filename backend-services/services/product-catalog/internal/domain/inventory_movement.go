@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+)
+
+// MovementType is the kind of change made to a product's stock.
+type MovementType string
+
+const (
+	// MovementReceive adds delivered stock.
+	MovementReceive MovementType = "receive"
+	// MovementAdjust corrects the stock after a count, damage or loss.
+	MovementAdjust MovementType = "adjust"
+	// MovementReserve, MovementRelease and MovementCommit follow an
+	// order's reservation.
+	MovementReserve MovementType = "reserve"
+	MovementRelease MovementType = "release"
+	MovementCommit  MovementType = "commit"
+)
+
+// InventoryMovement is one entry of a product's stock ledger: the change
+// made to Quantity and Reserved, the levels it left them at, and who made
+// it and why.
+type InventoryMovement struct {
+	ID            string       `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID     string       `gorm:"type:uuid;not null;index:idx_inventory_movements_product_created,priority:1"`
+	Type          MovementType `gorm:"type:varchar(20);not null"`
+	QuantityDelta int          `gorm:"not null"`
+	ReservedDelta int          `gorm:"not null"`
+	QuantityAfter int          `gorm:"not null"`
+	ReservedAfter int          `gorm:"not null"`
+	Reason        string       `gorm:"type:text"`
+	// Actor is the user who made the change, or the service acting for
+	// an order.
+	Actor     string    `gorm:"not null"`
+	OrderID   *string   `gorm:"type:uuid"`
+	CreatedAt time.Time `gorm:"index:idx_inventory_movements_product_created,priority:2,sort:desc"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"time"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationHeld      ReservationStatus = "held"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationCommitted ReservationStatus = "committed"
+)
+
+// Reservation is the stock of one product held for an order. There is at
+// most one per order and product, which makes reserving, releasing and
+// committing safe to retry.
+type Reservation struct {
+	ID        string            `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID   string            `gorm:"type:uuid;not null;uniqueIndex:idx_reservations_order_product"`
+	ProductID string            `gorm:"type:uuid;not null;uniqueIndex:idx_reservations_order_product"`
+	Quantity  int               `gorm:"not null"`
+	Status    ReservationStatus `gorm:"type:varchar(20);not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
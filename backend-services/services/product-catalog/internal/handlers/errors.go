@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"product-catalog/internal/domain"
+	"shared/catalog"
+	"shared/response"
+)
+
+// Codes for the errors clients may handle specially.
+const (
+	codeStoreAccessDenied  response.Code = "store_access_denied"
+	codeNoStore            response.Code = "no_store"
+	codeStoreRequired      response.Code = "store_required"
+	codeNoChanges          response.Code = "no_changes"
+	codeInvalidInventory   response.Code = "invalid_inventory"
+	codeInvalidReservation response.Code = "invalid_reservation"
+	codeReservationEnded   response.Code = "reservation_ended"
+)
+
+// writeServiceError responds to an error returned by a service. notFound
+// and failed are the messages for a missing record and an unexpected
+// failure.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, notFound, failed string) {
+	var problem *response.Problem
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		problem = response.NewProblem(http.StatusNotFound, response.CodeNotFound, notFound)
+	case errors.Is(err, domain.ErrForbidden):
+		problem = response.NewProblem(http.StatusForbidden, codeStoreAccessDenied, "Forbidden - Store access denied")
+	case errors.Is(err, domain.ErrStoreLookup):
+		slog.ErrorContext(r.Context(), "Failed to resolve caller's stores", "error", err)
+		problem = response.NewProblem(http.StatusBadGateway, response.CodeBadGateway, "Failed to verify store access")
+	case errors.Is(err, domain.ErrNoStore):
+		problem = response.NewProblem(http.StatusForbidden, codeNoStore, "Forbidden - No store to add the product to")
+	case errors.Is(err, domain.ErrStoreRequired):
+		problem = response.NewProblem(http.StatusBadRequest, codeStoreRequired, "store_id is required when managing several stores")
+	case errors.Is(err, domain.ErrNoChanges):
+		problem = response.NewProblem(http.StatusBadRequest, codeNoChanges, "No valid fields to update")
+	case errors.Is(err, domain.ErrInvalidInventory):
+		problem = response.NewProblem(http.StatusBadRequest, codeInvalidInventory, "Received quantity must be positive, and adjustments need a non-zero quantity and a reason")
+	case errors.Is(err, domain.ErrInvalidReservation):
+		problem = response.NewProblem(http.StatusBadRequest, codeInvalidReservation, "order_id must be a UUID and quantity must be positive")
+	case errors.Is(err, domain.ErrOutOfStock):
+		problem = response.NewProblem(http.StatusConflict, catalog.CodeInsufficientStock, "Not enough stock available")
+	case errors.Is(err, domain.ErrReservationEnded):
+		problem = response.NewProblem(http.StatusConflict, codeReservationEnded, "Reservation was already released or committed")
+	default:
+		slog.ErrorContext(r.Context(), failed, "error", err)
+		problem = response.NewProblem(http.StatusInternalServerError, response.CodeInternal, failed)
+	}
+	response.WriteError(w, problem)
+}
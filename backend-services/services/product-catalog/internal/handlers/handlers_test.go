@@ -0,0 +1,171 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"product-catalog/api/routes"
+	"product-catalog/internal/domain"
+	"product-catalog/internal/handlers"
+	"product-catalog/internal/repository/memory"
+	"product-catalog/internal/service/impl"
+	"shared/auth"
+	"shared/auth/authtest"
+	"shared/response"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	alice    = auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+	bob      = auth.Claims{ID: "bob", Role: auth.RoleStoreOwner}
+	carol    = auth.Claims{ID: "carol", Role: auth.RoleStoreOwner}
+	customer = auth.Claims{ID: "dave", Role: auth.RoleCustomer}
+	admin    = auth.Claims{ID: "root", Role: auth.RoleAdmin}
+	nobody   = auth.Claims{}
+)
+
+// fakeStores gives alice store-a, bob store-a and store-b, and carol no
+// store.
+type fakeStores struct {
+	fail bool
+}
+
+func (f *fakeStores) StoreIDs(ctx context.Context, claims auth.Claims) ([]string, error) {
+	if f.fail {
+		return nil, errors.New("store-management unavailable")
+	}
+	return map[string][]string{"alice": {"store-a"}, "bob": {"store-a", "store-b"}}[claims.ID], nil
+}
+
+func (f *fakeStores) IsStoreOwner(ctx context.Context, userID string) (bool, error) {
+	ids, err := f.StoreIDs(ctx, auth.Claims{ID: userID})
+	return len(ids) > 0, err
+}
+
+func (f *fakeStores) CanManage(ctx context.Context, claims auth.Claims, storeID string) (bool, error) {
+	if claims.Can(auth.PermStoreManageAny) {
+		return true, nil
+	}
+	ids, err := f.StoreIDs(ctx, claims)
+	for _, id := range ids {
+		if id == storeID {
+			return true, nil
+		}
+	}
+	return false, err
+}
+
+type fakeImages struct {
+	fail     bool
+	uploaded []string
+}
+
+func (f *fakeImages) UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error) {
+	if f.fail {
+		return "", errors.New("upload failed")
+	}
+	f.uploaded = append(f.uploaded, publicID)
+	return "https://images.test/" + publicID, nil
+}
+
+// fixture serves the routes from in-memory repositories holding
+// product-a, in store-a with image-a, and product-b, in store-b with
+// image-b.
+type fixture struct {
+	products  *memory.ProductRepository
+	images    *memory.ImageRepository
+	inventory *memory.InventoryRepository
+	movements *memory.MovementRepository
+	reserved  *memory.ReservationRepository
+	stores    *fakeStores
+	uploads   *fakeImages
+	issuer    *authtest.Issuer
+	router    *mux.Router
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	f := &fixture{
+		images:    memory.NewImageRepository(),
+		inventory: memory.NewInventoryRepository(),
+		movements: memory.NewMovementRepository(),
+		reserved:  memory.NewReservationRepository(),
+		stores:    &fakeStores{},
+		uploads:   &fakeImages{},
+		issuer:    authtest.NewIssuer(t),
+		router:    mux.NewRouter(),
+	}
+	f.products = memory.NewProductRepository(f.images)
+	for _, p := range []domain.Product{
+		{ID: "product-a", StoreID: "store-a", Name: "Teapot", Price: 120, IsActive: true, Images: []domain.Image{{ID: "image-a", URL: "https://images.test/a", IsPrimary: true}}},
+		{ID: "product-b", StoreID: "store-b", Name: "Rug", Price: 900, IsActive: true, Images: []domain.Image{{ID: "image-b", URL: "https://images.test/b", IsPrimary: true}}},
+	} {
+		if err := f.products.Create(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	routes.Register(f.router, f.issuer.Middleware().PromoteStoreOwners(f.stores), routes.Handlers{
+		Product:   handlers.NewProductHandler(impl.NewProductService(f.products, f.images, f.uploads, f.stores)),
+		Image:     handlers.NewImageHandler(impl.NewImageService(f.products, f.images, f.uploads, f.stores)),
+		Inventory: handlers.NewInventoryHandler(impl.NewInventoryService(f.products, f.inventory, f.movements, memory.NewTransactor(f.inventory, f.movements, f.reserved), f.stores)),
+	})
+	return f
+}
+
+// serve sends req through the router, authenticated as claims unless
+// they are empty.
+func (f *fixture) serve(t *testing.T, req *http.Request, claims auth.Claims) *httptest.ResponseRecorder {
+	t.Helper()
+	if claims.ID != "" {
+		f.issuer.Authorize(t, req, claims)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeData decodes the data of a success envelope into v.
+func decodeData(rec *httptest.ResponseRecorder, v interface{}) error {
+	return json.NewDecoder(rec.Body).Decode(&response.Envelope{Data: v})
+}
+
+func jsonRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// formRequest builds a multipart request with fields and, if withImage,
+// an image file.
+func formRequest(t *testing.T, method, path string, fields map[string]string, withImage bool) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	if withImage {
+		part, err := mw.CreateFormFile("image", "image.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte("image bytes"))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
@@ -2,132 +2,61 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"product-catalog/internal/domain"
-	"product-catalog/internal/middleware"
-	"product-catalog/internal/util"
-	"time"
+	services "product-catalog/internal/service/interfaces"
+	"shared/auth"
+	"shared/response"
 
 	"github.com/gorilla/mux"
-	"gorm.io/gorm"
 )
 
 type ImageHandler struct {
-	db         *gorm.DB
-	cloudinary *util.CloudinaryService
+	images services.ImageService
 }
 
-func NewImageHandler(db *gorm.DB) (*ImageHandler, error) {
-	cloudinary, err := util.NewCloudinaryService()
-	if err != nil {
-		return nil, err
-	}
-	return &ImageHandler{db: db, cloudinary: cloudinary}, nil
+func NewImageHandler(images services.ImageService) *ImageHandler {
+	return &ImageHandler{images: images}
 }
 
-// Update the UploadImage function
 func (h *ImageHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized - Store access required", http.StatusUnauthorized)
-		return
-	}
-
-	vars := mux.Vars(r)
-	productID := vars["productId"]
-
-	// Verify product belongs to the store
-	var product domain.Product
-	if err := h.db.First(&product, "id = ?", productID).Error; err != nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
-		return
-	}
-
-	if product.StoreID != claims.ID {
-		http.Error(w, "Forbidden - Product belongs to different store", http.StatusForbidden)
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized - Store access required")
 		return
 	}
 
 	// Parse multipart form
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		response.Error(w, http.StatusBadRequest, "Failed to parse form")
 		return
 	}
 
 	file, _, err := r.FormFile("image")
 	if err != nil {
-		http.Error(w, "No image file provided", http.StatusBadRequest)
+		response.Error(w, http.StatusBadRequest, "No image file provided")
 		return
 	}
 	defer file.Close()
 
-	// Upload to Cloudinary with a unique public ID
-	publicID := fmt.Sprintf("product-%s-image-%d", productID, time.Now().UnixNano())
-	url, err := h.cloudinary.UploadImage(r.Context(), file, publicID)
+	image, err := h.images.Upload(r.Context(), claims, mux.Vars(r)["productId"], file, r.FormValue("altText"))
 	if err != nil {
-		http.Error(w, "Failed to upload image", http.StatusInternalServerError)
-		return
-	}
-
-	// Create image record
-	image := &domain.Image{
-		ProductID: productID,
-		URL:       url,
-		AltText:   r.FormValue("altText"),
-		IsPrimary: false,
-	}
-
-	if err := h.db.Create(image).Error; err != nil {
-		http.Error(w, "Failed to save image record", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Product not found", "Failed to upload image")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(image)
+	response.JSON(w, http.StatusCreated, image)
 }
 
 func (h *ImageHandler) SetPrimaryImage(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized - Store access required", http.StatusUnauthorized)
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized - Store access required")
 		return
 	}
 
 	vars := mux.Vars(r)
-	imageID := vars["id"]
-	productID := vars["productId"]
-
-	// Verify product belongs to the store
-	var product domain.Product
-	if err := h.db.First(&product, "id = ?", productID).Error; err != nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
-		return
-	}
-
-	if product.StoreID != claims.ID {
-		http.Error(w, "Forbidden - Product belongs to different store", http.StatusForbidden)
-		return
-	}
-
-	// Start a transaction
-	err := h.db.Transaction(func(tx *gorm.DB) error {
-		// First, set all images for this product as non-primary
-		if err := tx.Model(&domain.Image{}).Where("product_id = ?", productID).Update("is_primary", false).Error; err != nil {
-			return err
-		}
-
-		// Then set the specified image as primary
-		if err := tx.Model(&domain.Image{}).Where("id = ?", imageID).Update("is_primary", true).Error; err != nil {
-			return err
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		http.Error(w, "Failed to set primary image", http.StatusInternalServerError)
+	if err := h.images.SetPrimary(r.Context(), claims, vars["productId"], vars["imageId"]); err != nil {
+		writeServiceError(w, r, err, "Image not found", "Failed to set primary image")
 		return
 	}
 
@@ -135,39 +64,23 @@ func (h *ImageHandler) SetPrimaryImage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ImageHandler) UpdateImageAltText(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized - Store access required", http.StatusUnauthorized)
-		return
-	}
-
-	vars := mux.Vars(r)
-	imageID := vars["id"]
-	productID := vars["productId"]
-
-	// Verify product belongs to the store
-	var product domain.Product
-	if err := h.db.First(&product, "id = ?", productID).Error; err != nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
-		return
-	}
-
-	if product.StoreID != claims.ID {
-		http.Error(w, "Forbidden - Product belongs to different store", http.StatusForbidden)
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized - Store access required")
 		return
 	}
 
 	var req struct {
 		AltText string `json:"altText"`
 	}
-
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if err := h.db.Model(&domain.Image{}).Where("id = ?", imageID).Update("alt_text", req.AltText).Error; err != nil {
-		http.Error(w, "Failed to update alt text", http.StatusInternalServerError)
+	vars := mux.Vars(r)
+	if err := h.images.UpdateAltText(r.Context(), claims, vars["productId"], vars["imageId"], req.AltText); err != nil {
+		writeServiceError(w, r, err, "Image not found", "Failed to update alt text")
 		return
 	}
 
@@ -175,30 +88,15 @@ func (h *ImageHandler) UpdateImageAltText(w http.ResponseWriter, r *http.Request
 }
 
 func (h *ImageHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized - Store access required", http.StatusUnauthorized)
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized - Store access required")
 		return
 	}
 
 	vars := mux.Vars(r)
-	imageID := vars["id"]
-	productID := vars["productId"]
-
-	// Verify product belongs to the store
-	var product domain.Product
-	if err := h.db.First(&product, "id = ?", productID).Error; err != nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
-		return
-	}
-
-	if product.StoreID != claims.ID {
-		http.Error(w, "Forbidden - Product belongs to different store", http.StatusForbidden)
-		return
-	}
-
-	if err := h.db.Delete(&domain.Image{}, "id = ?", imageID).Error; err != nil {
-		http.Error(w, "Failed to delete image", http.StatusInternalServerError)
+	if err := h.images.Delete(r.Context(), claims, vars["productId"], vars["imageId"]); err != nil {
+		writeServiceError(w, r, err, "Image not found", "Failed to delete image")
 		return
 	}
 
@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"testing"
+)
+
+func TestUploadImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    auth.Claims
+		product   string
+		withImage bool
+		failing   bool
+		want      int
+	}{
+		{"own product", alice, "product-a", true, false, http.StatusCreated},
+		{"no image", alice, "product-a", false, false, http.StatusBadRequest},
+		{"another store's product", alice, "product-b", true, false, http.StatusForbidden},
+		{"missing product", alice, "missing", true, false, http.StatusNotFound},
+		{"upload fails", alice, "product-a", true, true, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.uploads.fail = tt.failing
+
+			req := formRequest(t, http.MethodPost, "/api/products/"+tt.product+"/images", map[string]string{"altText": "Side view"}, tt.withImage)
+			rec := f.serve(t, req, tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			product, _ := f.products.FindByID(context.Background(), "product-a")
+			wantImages := 1
+			if tt.want == http.StatusCreated {
+				wantImages = 2
+			}
+			if len(product.Images) != wantImages {
+				t.Fatalf("product-a has %d images, want %d", len(product.Images), wantImages)
+			}
+			if added := product.Images[len(product.Images)-1]; wantImages == 2 && (added.IsPrimary || added.AltText != "Side view") {
+				t.Errorf("uploaded image = %+v", added)
+			}
+		})
+	}
+}
+
+func TestImageRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims auth.Claims
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"set primary", alice, http.MethodPut, "/api/products/product-a/images/image-a/primary", "", http.StatusOK},
+		{"set primary of another product's image", admin, http.MethodPut, "/api/products/product-a/images/image-b/primary", "", http.StatusNotFound},
+		{"set primary in another store", alice, http.MethodPut, "/api/products/product-b/images/image-b/primary", "", http.StatusForbidden},
+		{"update alt text", alice, http.MethodPut, "/api/products/product-a/images/image-a/alt-text", `{"altText":"Front"}`, http.StatusOK},
+		{"update alt text of another product's image", admin, http.MethodPut, "/api/products/product-a/images/image-b/alt-text", `{"altText":"Front"}`, http.StatusNotFound},
+		{"update alt text with invalid body", alice, http.MethodPut, "/api/products/product-a/images/image-a/alt-text", `{`, http.StatusBadRequest},
+		{"delete", alice, http.MethodDelete, "/api/products/product-a/images/image-a", "", http.StatusNoContent},
+		{"delete another product's image", admin, http.MethodDelete, "/api/products/product-a/images/image-b", "", http.StatusNotFound},
+		{"delete in another store", alice, http.MethodDelete, "/api/products/product-b/images/image-b", "", http.StatusForbidden},
+		{"customer", customer, http.MethodDelete, "/api/products/product-a/images/image-a", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			rec := f.serve(t, jsonRequest(tt.method, tt.path, tt.body), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			// Refused requests leave image-b alone
+			if image, err := f.images.FindByID(context.Background(), "image-b"); err != nil || image.AltText != "" || !image.IsPrimary {
+				t.Errorf("image-b = %+v, %v", image, err)
+			}
+		})
+	}
+}
+
+func TestAltTextIsSaved(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.serve(t, jsonRequest(http.MethodPut, "/api/products/product-a/images/image-a/alt-text", `{"altText":"Front"}`), alice)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if image, _ := f.images.FindByID(context.Background(), "image-a"); image.AltText != "Front" {
+		t.Errorf("alt text = %q, want Front", image.AltText)
+	}
+
+	rec = f.serve(t, httptest.NewRequest(http.MethodDelete, "/api/products/product-a/images/image-a", nil), alice)
+	if _, err := f.images.FindByID(context.Background(), "image-a"); rec.Code != http.StatusNoContent || err == nil {
+		t.Errorf("delete status = %d, image still found: %v", rec.Code, err == nil)
+	}
+}
@@ -1,116 +1,146 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"product-catalog/internal/domain"
-	"product-catalog/internal/middleware"
+	services "product-catalog/internal/service/interfaces"
+	"shared/auth"
+	"shared/response"
+	"strconv"
 
 	"github.com/gorilla/mux"
-	"gorm.io/gorm"
 )
 
 type InventoryHandler struct {
-	db *gorm.DB
+	inventory services.InventoryService
 }
 
-func NewInventoryHandler(db *gorm.DB) *InventoryHandler {
-	return &InventoryHandler{db: db}
+func NewInventoryHandler(inventory services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{inventory: inventory}
 }
 
 func (h *InventoryHandler) GetInventory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	productID := vars["productId"]
-
-	var inventory domain.Inventory
-	if err := h.db.First(&inventory, "product_id = ?", productID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Inventory not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to fetch inventory", http.StatusInternalServerError)
-		}
+	inventory, err := h.inventory.Get(r.Context(), mux.Vars(r)["productId"])
+	if err != nil {
+		writeServiceError(w, r, err, "Inventory not found", "Failed to fetch inventory")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(inventory)
+	response.JSON(w, http.StatusOK, inventory)
+}
+
+// Limits on the number of stock movements returned at once.
+const (
+	defaultMovements = 50
+	maxMovements     = 200
+)
+
+// ReceiveStock adds delivered stock to a product.
+func (h *InventoryHandler) ReceiveStock(w http.ResponseWriter, r *http.Request) {
+	h.moveStock(w, r, h.inventory.Receive, "Failed to receive stock")
+}
+
+// AdjustStock corrects a product's stock up or down.
+func (h *InventoryHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	h.moveStock(w, r, h.inventory.Adjust, "Failed to adjust stock")
 }
 
-func (h *InventoryHandler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+// moveStock applies move with the quantity and reason from the request
+// body and responds with the updated inventory.
+func (h *InventoryHandler) moveStock(w http.ResponseWriter, r *http.Request, move func(ctx context.Context, claims auth.Claims, productID string, quantity int, reason string) (domain.Inventory, error), failed string) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	vars := mux.Vars(r)
-	productID := vars["productId"]
-
-	// Verify product belongs to the store
-	var product domain.Product
-	if err := h.db.First(&product, "id = ?", productID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Product not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Database error", http.StatusInternalServerError)
-		}
+	var req struct {
+		Quantity int    `json:"quantity"`
+		Reason   string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	inventory, err := move(r.Context(), claims, mux.Vars(r)["productId"], req.Quantity, req.Reason)
+	if err != nil {
+		writeServiceError(w, r, err, "Product not found", failed)
 		return
 	}
 
-	if product.StoreID != claims.ID {
-		http.Error(w, "Forbidden - Product belongs to different store", http.StatusForbidden)
+	response.JSON(w, http.StatusOK, inventory)
+}
+
+// GetMovements returns a product's latest stock changes, newest first.
+func (h *InventoryHandler) GetMovements(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Parse update data
-	var updateData struct {
-		Quantity int `json:"quantity"`
-		Reserved int `json:"reserved"`
+	limit := defaultMovements
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxMovements {
+			response.Error(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxMovements))
+			return
+		}
+		limit = n
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	movements, err := h.inventory.Movements(r.Context(), claims, mux.Vars(r)["productId"], limit)
+	if err != nil {
+		writeServiceError(w, r, err, "Product not found", "Failed to fetch stock movements")
 		return
 	}
 
-	// Start transaction
-	tx := h.db.Begin()
-	if tx.Error != nil {
-		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+	response.JSON(w, http.StatusOK, movements)
+}
+
+// ReserveStock holds stock for an order. It is called by order-management.
+func (h *InventoryHandler) ReserveStock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OrderID  string `json:"order_id"`
+		Quantity int    `json:"quantity"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Get or create inventory
-	var inventory domain.Inventory
-	result := tx.FirstOrCreate(&inventory, domain.Inventory{ProductID: productID})
-	if result.Error != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to get/create inventory", http.StatusInternalServerError)
+
+	reservation, err := h.inventory.Reserve(r.Context(), mux.Vars(r)["productId"], req.OrderID, req.Quantity)
+	if err != nil {
+		writeServiceError(w, r, err, "Product not found", "Failed to reserve stock")
 		return
 	}
 
-	// Update inventory
-	inventory.Quantity = updateData.Quantity
-	inventory.Reserved = updateData.Reserved
+	response.JSON(w, http.StatusOK, reservation)
+}
 
-	if err := tx.Save(&inventory).Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to update inventory", http.StatusInternalServerError)
+// ReleaseStock returns the stock held for an order.
+func (h *InventoryHandler) ReleaseStock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reservation, err := h.inventory.Release(r.Context(), vars["productId"], vars["orderId"])
+	if err != nil {
+		writeServiceError(w, r, err, "Reservation not found", "Failed to release stock")
 		return
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+	response.JSON(w, http.StatusOK, reservation)
+}
+
+// CommitStock takes the stock held for a shipped order off the shelf.
+func (h *InventoryHandler) CommitStock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reservation, err := h.inventory.Commit(r.Context(), vars["productId"], vars["orderId"])
+	if err != nil {
+		writeServiceError(w, r, err, "Reservation not found", "Failed to commit stock")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(inventory)
-}
\ No newline at end of file
+	response.JSON(w, http.StatusOK, reservation)
+}
@@ -0,0 +1,264 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"shared/catalog"
+	"shared/response"
+	"sync"
+	"testing"
+)
+
+func TestMoveStock(t *testing.T) {
+	tests := []struct {
+		name         string
+		claims       auth.Claims
+		id           string
+		op           string
+		body         string
+		want         int
+		wantQuantity int
+	}{
+		{"receive", alice, "product-a", "receive", `{"quantity":5,"reason":"PO-12"}`, http.StatusOK, 15},
+		{"receive nothing", alice, "product-a", "receive", `{"quantity":0}`, http.StatusBadRequest, 10},
+		{"receive a negative quantity", alice, "product-a", "receive", `{"quantity":-1}`, http.StatusBadRequest, 10},
+		{"adjust down", alice, "product-a", "adjust", `{"quantity":-3,"reason":"Broken in storage"}`, http.StatusOK, 7},
+		{"adjust up", alice, "product-a", "adjust", `{"quantity":2,"reason":"Recount"}`, http.StatusOK, 12},
+		{"adjust without a reason", alice, "product-a", "adjust", `{"quantity":-3}`, http.StatusBadRequest, 10},
+		{"adjust into reserved stock", alice, "product-a", "adjust", `{"quantity":-9,"reason":"Recount"}`, http.StatusConflict, 10},
+		{"invalid body", alice, "product-a", "receive", `{"quantity":"ten"}`, http.StatusBadRequest, 10},
+		{"another store's product", alice, "product-b", "receive", `{"quantity":10}`, http.StatusForbidden, 0},
+		{"missing product", alice, "missing", "receive", `{"quantity":10}`, http.StatusNotFound, 0},
+		{"customer", customer, "product-a", "receive", `{"quantity":10}`, http.StatusForbidden, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			stockUp(t, f)
+
+			rec := f.serve(t, jsonRequest(http.MethodPost, "/api/products/"+tt.id+"/inventory/"+tt.op, tt.body), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			inventory, _ := f.inventory.FindByProductID(context.Background(), tt.id)
+			if inventory.Quantity != tt.wantQuantity || tt.id == "product-a" && inventory.Reserved != 2 {
+				t.Errorf("inventory = %+v, want quantity %d", inventory, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestConcurrentAdjustmentsNeverOversell(t *testing.T) {
+	f := newFixture(t)
+	stockUp(t, f)
+
+	// 8 of the 10 are not reserved, so 8 of the 20 adjustments fit
+	var wg sync.WaitGroup
+	codes := make(chan int, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- f.serve(t, jsonRequest(http.MethodPost, "/api/products/product-a/inventory/adjust", `{"quantity":-1,"reason":"Sold in store"}`), alice).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	succeeded := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			succeeded++
+		}
+	}
+	inventory, _ := f.inventory.FindByProductID(context.Background(), "product-a")
+	if succeeded != 8 || inventory.Quantity != 2 || inventory.Reserved != 2 {
+		t.Errorf("%d adjustments succeeded leaving %+v", succeeded, inventory)
+	}
+	if movements, _ := f.movements.ListByProductID(context.Background(), "product-a", 100); len(movements) != 2+8 {
+		t.Errorf("%d movements recorded, want 10", len(movements))
+	}
+}
+
+func TestReceiveCreatesInventory(t *testing.T) {
+	f := newFixture(t)
+
+	if rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/products/product-b/inventory", nil), auth.Claims{}); rec.Code != http.StatusNotFound {
+		t.Fatalf("inventory before receiving status = %d, want 404", rec.Code)
+	}
+	f.serve(t, jsonRequest(http.MethodPost, "/api/products/product-b/inventory/receive", `{"quantity":4}`), bob)
+	f.serve(t, jsonRequest(http.MethodPost, "/api/products/product-b/inventory/receive", `{"quantity":3}`), bob)
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/products/product-b/inventory", nil), auth.Claims{})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var inventory struct {
+		ProductID string
+		Quantity  int
+		Reserved  int
+	}
+	decodeData(rec, &inventory)
+	if inventory.ProductID != "product-b" || inventory.Quantity != 7 || inventory.Reserved != 0 {
+		t.Errorf("inventory = %+v", inventory)
+	}
+}
+
+func TestGetMovements(t *testing.T) {
+	f := newFixture(t)
+	stockUp(t, f)
+	f.serve(t, jsonRequest(http.MethodPost, "/api/products/product-a/inventory/adjust", `{"quantity":-1,"reason":"Chipped"}`), alice)
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/products/product-a/inventory/movements", nil), alice)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var movements []struct {
+		Type                         string
+		QuantityDelta, ReservedDelta int
+		QuantityAfter, ReservedAfter int
+		Reason, Actor                string
+		OrderID                      *string
+	}
+	decodeData(rec, &movements)
+	if len(movements) != 3 {
+		t.Fatalf("movements = %+v", movements)
+	}
+	adjust, reserve, receive := movements[0], movements[1], movements[2]
+	if adjust.Type != "adjust" || adjust.QuantityDelta != -1 || adjust.QuantityAfter != 9 || adjust.ReservedAfter != 2 || adjust.Reason != "Chipped" || adjust.Actor != "alice" || adjust.OrderID != nil {
+		t.Errorf("adjustment = %+v", adjust)
+	}
+	if reserve.Type != "reserve" || reserve.ReservedDelta != 2 || reserve.Actor != "order-management" || reserve.OrderID == nil || *reserve.OrderID != otherOrder {
+		t.Errorf("reservation = %+v", reserve)
+	}
+	if receive.Type != "receive" || receive.QuantityDelta != 10 || receive.QuantityAfter != 10 {
+		t.Errorf("receipt = %+v", receive)
+	}
+
+	rec = f.serve(t, httptest.NewRequest(http.MethodGet, "/api/products/product-a/inventory/movements?limit=1", nil), alice)
+	if decodeData(rec, &movements); len(movements) != 1 || movements[0].Type != "adjust" {
+		t.Errorf("limited movements = %+v", movements)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		claims auth.Claims
+		query  string
+		want   int
+	}{
+		{"limit too large", alice, "?limit=201", http.StatusBadRequest},
+		{"another seller of the store", bob, "", http.StatusOK},
+		{"customer", customer, "", http.StatusForbidden},
+	} {
+		rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/products/product-a/inventory/movements"+tt.query, nil), tt.claims)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+	if rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/products/product-b/inventory/movements", nil), alice); rec.Code != http.StatusForbidden {
+		t.Errorf("another store's product: status = %d, want 403", rec.Code)
+	}
+}
+
+// otherOrder holds 2 of product-a's stock after stockUp.
+const otherOrder = "0b7e6a52-3f1d-4c8e-9a2b-5d6f7e8a9b0c"
+
+// stockUp receives 10 of product-a and reserves 2 of them for otherOrder.
+func stockUp(t *testing.T, f *fixture) {
+	t.Helper()
+	if rec := f.serve(t, jsonRequest(http.MethodPost, "/api/products/product-a/inventory/receive", `{"quantity":10}`), alice); rec.Code != http.StatusOK {
+		t.Fatalf("receive status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := f.serve(t, jsonRequest(http.MethodPost, "/internal/products/product-a/reservations", `{"order_id":"`+otherOrder+`","quantity":2}`), nobody); rec.Code != http.StatusOK {
+		t.Fatalf("reserve status = %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestReservations(t *testing.T) {
+	const order = "6f1c4a3e-2b7d-4e0a-9c1f-3d5b7e9a1c2f"
+	reserve := func(quantity string) *http.Request {
+		return jsonRequest(http.MethodPost, "/internal/products/product-a/reservations", `{"order_id":"`+order+`","quantity":`+quantity+`}`)
+	}
+	release := func() *http.Request {
+		return httptest.NewRequest(http.MethodDelete, "/internal/products/product-a/reservations/"+order, nil)
+	}
+	commit := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/internal/products/product-a/reservations/"+order+"/commit", nil)
+	}
+
+	type step struct {
+		req  func() *http.Request
+		want int
+	}
+	tests := []struct {
+		name         string
+		steps        []step
+		wantQuantity int
+		wantReserved int
+	}{
+		{"reserve", []step{{func() *http.Request { return reserve("3") }, http.StatusOK}}, 10, 5},
+		{"reserve twice", []step{
+			{func() *http.Request { return reserve("3") }, http.StatusOK},
+			{func() *http.Request { return reserve("3") }, http.StatusOK},
+		}, 10, 5},
+		{"reserve more than available", []step{{func() *http.Request { return reserve("9") }, http.StatusConflict}}, 10, 2},
+		{"reserve nothing", []step{{func() *http.Request { return reserve("0") }, http.StatusBadRequest}}, 10, 2},
+		{"release", []step{
+			{func() *http.Request { return reserve("3") }, http.StatusOK},
+			{release, http.StatusOK},
+			{release, http.StatusOK},
+		}, 10, 2},
+		{"release before reserving", []step{
+			{release, http.StatusOK},
+			{func() *http.Request { return reserve("3") }, http.StatusConflict},
+		}, 10, 2},
+		{"commit", []step{
+			{func() *http.Request { return reserve("3") }, http.StatusOK},
+			{commit, http.StatusOK},
+			{commit, http.StatusOK},
+			{release, http.StatusConflict},
+		}, 7, 2},
+		{"commit without reserving", []step{{commit, http.StatusNotFound}}, 10, 2},
+		{"commit after release", []step{
+			{func() *http.Request { return reserve("3") }, http.StatusOK},
+			{release, http.StatusOK},
+			{commit, http.StatusConflict},
+		}, 10, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			stockUp(t, f)
+
+			for i, s := range tt.steps {
+				if rec := f.serve(t, s.req(), nobody); rec.Code != s.want {
+					t.Fatalf("step %d status = %d, want %d: %s", i, rec.Code, s.want, rec.Body.String())
+				}
+			}
+
+			inventory, _ := f.inventory.FindByProductID(context.Background(), "product-a")
+			if inventory.Quantity != tt.wantQuantity || inventory.Reserved != tt.wantReserved {
+				t.Errorf("inventory = %d reserved of %d, want %d of %d", inventory.Reserved, inventory.Quantity, tt.wantReserved, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestReserveWithoutInventory(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.serve(t, jsonRequest(http.MethodPost, "/internal/products/product-b/reservations", `{"order_id":"6f1c4a3e-2b7d-4e0a-9c1f-3d5b7e9a1c2f","quantity":1}`), nobody)
+	var problem response.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict || problem.Code != catalog.CodeInsufficientStock {
+		t.Errorf("%d response: %+v", rec.Code, problem)
+	}
+}
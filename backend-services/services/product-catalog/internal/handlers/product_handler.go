@@ -2,40 +2,36 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"product-catalog/internal/domain"
-	"product-catalog/internal/middleware"
-	"product-catalog/internal/util"
+	"product-catalog/internal/metrics"
+	services "product-catalog/internal/service/interfaces"
+	"shared/auth"
+	"shared/catalog"
+	"shared/response"
 	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
-	"gorm.io/gorm"
 )
 
 type ProductHandler struct {
-	db         *gorm.DB
-	cloudinary *util.CloudinaryService
+	products services.ProductService
 }
 
-func NewProductHandler(db *gorm.DB) (*ProductHandler, error) {
-	cloudinary, err := util.NewCloudinaryService()
-	if err != nil {
-		return nil, err
-	}
-	return &ProductHandler{db: db, cloudinary: cloudinary}, nil
+func NewProductHandler(products services.ProductService) *ProductHandler {
+	return &ProductHandler{products: products}
 }
 
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized - Store access required", http.StatusUnauthorized)
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized - Store access required")
 		return
 	}
 
 	var product domain.Product
-	var hasFile bool
+	var image *services.ImageUpload
 
 	// Check content type to determine how to parse the request
 	contentType := r.Header.Get("Content-Type")
@@ -43,7 +39,7 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	if strings.Contains(contentType, "multipart/form-data") {
 		// Handle multipart form data
 		if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB max
-			http.Error(w, "Failed to parse form", http.StatusBadRequest)
+			response.Error(w, http.StatusBadRequest, "Failed to parse form")
 			return
 		}
 
@@ -52,7 +48,7 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		if productData != "" {
 			// Product data is provided as JSON string in form field
 			if err := json.Unmarshal([]byte(productData), &product); err != nil {
-				http.Error(w, "Invalid product JSON in form field", http.StatusBadRequest)
+				response.Error(w, http.StatusBadRequest, "Invalid product JSON in form field")
 				return
 			}
 		} else {
@@ -61,6 +57,7 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 			product.Description = r.FormValue("description")
 			product.Category = r.FormValue("category")
 			product.SKU = r.FormValue("sku")
+			product.StoreID = r.FormValue("store_id")
 
 			// Parse price
 			if priceStr := r.FormValue("price"); priceStr != "" {
@@ -70,296 +67,157 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 			}
 
 			// Parse isActive
-			if isActiveStr := r.FormValue("isActive"); isActiveStr != "" {
-				if isActive, err := strconv.ParseBool(isActiveStr); err == nil {
-					product.IsActive = isActive
-				} else {
-					product.IsActive = true // default
-				}
-			} else {
-				product.IsActive = true // default
+			product.IsActive = true // default
+			if isActive, err := strconv.ParseBool(r.FormValue("isActive")); err == nil {
+				product.IsActive = isActive
 			}
 
 			// Validate required fields
 			if product.Name == "" {
-				http.Error(w, "Product name is required", http.StatusBadRequest)
+				response.Error(w, http.StatusBadRequest, "Product name is required")
 				return
 			}
 		}
 
-		// Check if there's a file
-		if _, _, err := r.FormFile("image"); err == nil {
-			hasFile = true
+		// The image, if any, becomes the primary image
+		if file, _, err := r.FormFile("image"); err == nil {
+			defer file.Close()
+			image = &services.ImageUpload{File: file, AltText: r.FormValue("altText")}
 		}
-
 	} else {
 		// Handle JSON request body
 		if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-			http.Error(w, "Invalid JSON request body", http.StatusBadRequest)
+			response.Error(w, http.StatusBadRequest, "Invalid JSON request body")
 			return
 		}
 	}
 
-	// Set the store ID from claims
-	product.StoreID = claims.ID
-
-	// Start transaction
-	tx := h.db.Begin()
-	if tx.Error != nil {
-		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
-		return
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Create product first to get the ID
-	if err := tx.Create(&product).Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to create product", http.StatusInternalServerError)
-		return
-	}
-
-	// Handle image upload if present (only for multipart requests)
-	if hasFile {
-		file, _, err := r.FormFile("image")
-		if err == nil && file != nil {
-			defer file.Close()
-
-			// Upload to Cloudinary
-			publicID := fmt.Sprintf("product-%s-image", product.ID)
-			imageURL, err := h.cloudinary.UploadImage(r.Context(), file, publicID)
-			if err != nil {
-				tx.Rollback()
-				http.Error(w, "Failed to upload image", http.StatusInternalServerError)
-				return
-			}
-
-			// Create image record
-			image := domain.Image{
-				ProductID: product.ID,
-				URL:       imageURL,
-				AltText:   r.FormValue("altText"),
-				IsPrimary: true, // First image is primary
-			}
-
-			if err := tx.Create(&image).Error; err != nil {
-				tx.Rollback()
-				http.Error(w, "Failed to save image record", http.StatusInternalServerError)
-				return
-			}
-		}
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+	created, err := h.products.Create(r.Context(), claims, product, image)
+	if err != nil {
+		writeServiceError(w, r, err, "Product not found", "Failed to create product")
 		return
 	}
+	metrics.ProductsCreated.Inc()
 
-	// Return success response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":    true,
-		"message":    "Product created successfully",
-		"product_id": product.ID,
-	})
+	response.JSON(w, http.StatusCreated, created)
 }
 
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	productID := vars["id"]
-
-	var product domain.Product
-	if err := h.db.Preload("Images").First(&product, "id = ?", productID).Error; err != nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
+	product, err := h.products.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err, "Product not found", "Failed to fetch product")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	response.JSON(w, http.StatusOK, product)
 }
 
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized - Store access required", http.StatusUnauthorized)
-		return
-	}
-
-	vars := mux.Vars(r)
-	productID := vars["id"]
-
-	// Verify product belongs to the store
-	var existingProduct domain.Product
-	if err := h.db.First(&existingProduct, "id = ?", productID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Product not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Database error", http.StatusInternalServerError)
-		}
-		return
-	}
-
-	if existingProduct.StoreID != claims.ID {
-		http.Error(w, "Forbidden - Product belongs to different store", http.StatusForbidden)
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized - Store access required")
 		return
 	}
 
-	// Parse the update data
-	var updateData map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// Remove fields that shouldn't be updated
-	delete(updateData, "id")
-	delete(updateData, "store_id")
-	delete(updateData, "created_at")
-
-	// Validate and clean the update data
-	allowedFields := map[string]bool{
-		"name":        true,
-		"description": true,
-		"category":    true,
-		"price":       true,
-		"sku":         true,
-		"is_active":   true,
-		"isActive":    true, // Handle both snake_case and camelCase
-	}
-
-	cleanedData := make(map[string]interface{})
-	for key, value := range updateData {
-		if allowedFields[key] {
-			// Handle camelCase to snake_case conversion
-			if key == "isActive" {
-				cleanedData["is_active"] = value
-			} else {
-				cleanedData[key] = value
-			}
-		}
+	// Only these fields may be updated; is_active is also accepted in
+	// camelCase
+	var req struct {
+		Name          *string  `json:"name"`
+		Description   *string  `json:"description"`
+		Category      *string  `json:"category"`
+		Price         *float64 `json:"price"`
+		SKU           *string  `json:"sku"`
+		IsActive      *bool    `json:"is_active"`
+		IsActiveCamel *bool    `json:"isActive"`
 	}
-
-	// Check if there's anything to update
-	if len(cleanedData) == 0 {
-		http.Error(w, "No valid fields to update", http.StatusBadRequest)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-
-	// Perform selective update using Updates()
-	if err := h.db.Model(&existingProduct).Updates(cleanedData).Error; err != nil {
-		http.Error(w, "Failed to update product", http.StatusInternalServerError)
-		return
+	if req.IsActive == nil {
+		req.IsActive = req.IsActiveCamel
 	}
 
-	// Fetch the updated product to return
-	var updatedProduct domain.Product
-	if err := h.db.First(&updatedProduct, "id = ?", productID).Error; err != nil {
-		http.Error(w, "Failed to fetch updated product", http.StatusInternalServerError)
+	product, err := h.products.Update(r.Context(), claims, mux.Vars(r)["id"], services.ProductChanges{
+		Name:        req.Name,
+		Description: req.Description,
+		Category:    req.Category,
+		Price:       req.Price,
+		SKU:         req.SKU,
+		IsActive:    req.IsActive,
+	})
+	if err != nil {
+		writeServiceError(w, r, err, "Product not found", "Failed to update product")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Product updated successfully",
-		"product": updatedProduct,
-	})
+	response.JSON(w, http.StatusOK, product)
 }
 
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized - Store access required", http.StatusUnauthorized)
-		return
-	}
-
-	vars := mux.Vars(r)
-	productID := vars["id"]
-
-	// Verify product belongs to the store
-	var existingProduct domain.Product
-	if err := h.db.First(&existingProduct, "id = ?", productID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Product not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Database error", http.StatusInternalServerError)
-		}
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized - Store access required")
 		return
 	}
 
-	if existingProduct.StoreID != claims.ID {
-		http.Error(w, "Forbidden - Product belongs to different store", http.StatusForbidden)
+	if err := h.products.Delete(r.Context(), claims, mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, r, err, "Product not found", "Failed to delete product")
 		return
 	}
 
-	// Use transaction to delete product and its images
-	tx := h.db.Begin()
-	if tx.Error != nil {
-		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
-		return
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// First, delete all associated images
-	if err := tx.Where("product_id = ?", productID).Delete(&domain.Image{}).Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to delete product images", http.StatusInternalServerError)
-		return
-	}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	// Then delete the product
-	if err := tx.Delete(&domain.Product{}, "id = ?", productID).Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to delete product", http.StatusInternalServerError)
+func (h *ProductHandler) GetProductsByStore(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized - Store access required")
 		return
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to commit deletion", http.StatusInternalServerError)
+	products, err := h.products.ListByStore(r.Context(), claims, mux.Vars(r)["storeId"])
+	if err != nil {
+		writeServiceError(w, r, err, "Store not found", "Failed to fetch products")
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Product deleted successfully",
-	})
+	response.JSON(w, http.StatusOK, products)
 }
 
-func (h *ProductHandler) GetProductsByStore(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
-	if !ok || claims.ID == "" {
-		http.Error(w, "Unauthorized - Store access required", http.StatusUnauthorized)
-		return
+// maxLookupIDs caps the products looked up in one internal request.
+const maxLookupIDs = 100
+
+// LookupProducts returns the price, store and status of the products in
+// the comma-separated ids query parameter. It is called by other services
+// to price orders and is not routed by the gateway.
+func (h *ProductHandler) LookupProducts(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
 	}
-
-	vars := mux.Vars(r)
-	storeID := vars["storeId"]
-
-	// Verify user has access to the store
-	if storeID != claims.ID {
-		http.Error(w, "Forbidden - Access to store denied", http.StatusForbidden)
+	if len(ids) > maxLookupIDs {
+		response.Error(w, http.StatusBadRequest, "Too many product ids")
 		return
 	}
 
-	var products []domain.Product
-	if err := h.db.Preload("Images").Where("store_id = ?", storeID).Find(&products).Error; err != nil {
-		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
+	products, err := h.products.Lookup(r.Context(), ids)
+	if err != nil {
+		writeServiceError(w, r, err, "Product not found", "Failed to look up products")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	body := catalog.Products{Products: make([]catalog.Product, 0, len(products))}
+	for _, p := range products {
+		body.Products = append(body.Products, catalog.Product{
+			ID:       p.ID,
+			StoreID:  p.StoreID,
+			Name:     p.Name,
+			Price:    p.Price,
+			IsActive: p.IsActive,
+		})
+	}
+	response.JSON(w, http.StatusOK, body)
 }
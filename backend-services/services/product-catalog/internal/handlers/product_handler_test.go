@@ -0,0 +1,341 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"shared/catalog"
+	"shared/response"
+	"strings"
+	"testing"
+)
+
+func TestCreateProduct(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    auth.Claims
+		request   func(t *testing.T) *http.Request
+		setup     func(*fixture)
+		want      int
+		wantStore string
+	}{
+		{
+			name:   "anonymous",
+			claims: nobody,
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp","StoreID":"store-a"}`)
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:   "customer",
+			claims: customer,
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp","StoreID":"store-a"}`)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "json into own store",
+			claims: alice,
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp","StoreID":"store-a"}`)
+			},
+			want:      http.StatusCreated,
+			wantStore: "store-a",
+		},
+		{
+			// Tokens only carry the user role; owning a store is enough
+			name:   "user owning the store",
+			claims: auth.Claims{ID: "alice", Role: "user"},
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp","StoreID":"store-a"}`)
+			},
+			want:      http.StatusCreated,
+			wantStore: "store-a",
+		},
+		{
+			name:   "json into another store",
+			claims: alice,
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp","StoreID":"store-b"}`)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "admin into any store",
+			claims: admin,
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp","StoreID":"store-b"}`)
+			},
+			want:      http.StatusCreated,
+			wantStore: "store-b",
+		},
+		{
+			name:   "form defaults to the only store",
+			claims: alice,
+			request: func(t *testing.T) *http.Request {
+				return formRequest(t, http.MethodPost, "/api/products", map[string]string{"name": "Lamp", "price": "45.5"}, true)
+			},
+			want:      http.StatusCreated,
+			wantStore: "store-a",
+		},
+		{
+			name:   "form without a name",
+			claims: alice,
+			request: func(t *testing.T) *http.Request {
+				return formRequest(t, http.MethodPost, "/api/products", map[string]string{"price": "45.5"}, false)
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name:   "no store to default to",
+			claims: carol,
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp"}`)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "several stores to choose from",
+			claims: bob,
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp"}`)
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name:   "store lookup fails",
+			claims: alice,
+			request: func(t *testing.T) *http.Request {
+				return jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp","StoreID":"store-a"}`)
+			},
+			setup: func(f *fixture) { f.stores.fail = true },
+			want:  http.StatusBadGateway,
+		},
+		{
+			name:   "image upload fails",
+			claims: alice,
+			request: func(t *testing.T) *http.Request {
+				return formRequest(t, http.MethodPost, "/api/products", map[string]string{"name": "Lamp"}, true)
+			},
+			setup: func(f *fixture) { f.uploads.fail = true },
+			want:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+
+			rec := f.serve(t, tt.request(t), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			products, _ := f.products.FindByStoreID(context.Background(), "store-a")
+			if tt.want != http.StatusCreated {
+				if len(products) != 1 {
+					t.Errorf("failed create left %d products in store-a", len(products))
+				}
+				return
+			}
+
+			var created struct{ ID string }
+			decodeData(rec, &created)
+			product, err := f.products.FindByID(context.Background(), created.ID)
+			if err != nil || product.StoreID != tt.wantStore || product.Name != "Lamp" {
+				t.Fatalf("created %+v, %v", product, err)
+			}
+			if len(f.uploads.uploaded) != len(product.Images) {
+				t.Errorf("uploaded %v, product has images %+v", f.uploads.uploaded, product.Images)
+			}
+			if len(product.Images) == 1 && !product.Images[0].IsPrimary {
+				t.Errorf("first image is not primary: %+v", product.Images[0])
+			}
+		})
+	}
+}
+
+func TestGetProduct(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/products/product-a", nil), nobody)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var product struct {
+		Name   string
+		Images []struct{ ID string }
+	}
+	decodeData(rec, &product)
+	if product.Name != "Teapot" || len(product.Images) != 1 || product.Images[0].ID != "image-a" {
+		t.Errorf("product = %+v", product)
+	}
+
+	rec = f.serve(t, httptest.NewRequest(http.MethodGet, "/api/products/missing", nil), nobody)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing product status = %d, want 404", rec.Code)
+	}
+}
+
+func TestUpdateProduct(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   auth.Claims
+		id       string
+		body     string
+		want     int
+		wantName string
+	}{
+		{"own product", alice, "product-a", `{"name":"Kettle","isActive":false}`, http.StatusOK, "Kettle"},
+		{"no known fields", alice, "product-a", `{"store_id":"store-b"}`, http.StatusBadRequest, "Teapot"},
+		{"invalid body", alice, "product-a", `{"price":"free"}`, http.StatusBadRequest, "Teapot"},
+		{"another store's product", alice, "product-b", `{"name":"Kettle"}`, http.StatusForbidden, "Rug"},
+		{"missing product", alice, "missing", `{"name":"Kettle"}`, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			rec := f.serve(t, jsonRequest(http.MethodPut, "/api/products/"+tt.id, tt.body), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			product, _ := f.products.FindByID(context.Background(), tt.id)
+			if product.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", product.Name, tt.wantName)
+			}
+			if tt.want == http.StatusOK && (product.IsActive || product.StoreID != "store-a") {
+				t.Errorf("product after update = %+v", product)
+			}
+		})
+	}
+}
+
+func TestDeleteProduct(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims auth.Claims
+		id     string
+		want   int
+	}{
+		{"own product", alice, "product-a", http.StatusNoContent},
+		{"another store's product", alice, "product-b", http.StatusForbidden},
+		{"admin", admin, "product-b", http.StatusNoContent},
+		{"missing product", alice, "missing", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			rec := f.serve(t, httptest.NewRequest(http.MethodDelete, "/api/products/"+tt.id, nil), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			_, err := f.products.FindByID(context.Background(), tt.id)
+			if deleted := err != nil; deleted != (tt.want != http.StatusForbidden) {
+				t.Errorf("product deleted = %v with status %d", deleted, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGetProductsByStore(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims auth.Claims
+		store  string
+		want   int
+		count  int
+	}{
+		{"own store", alice, "store-a", http.StatusOK, 1},
+		{"another store", alice, "store-b", http.StatusForbidden, 0},
+		{"admin", admin, "store-b", http.StatusOK, 1},
+		{"anonymous", nobody, "store-a", http.StatusUnauthorized, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/stores/"+tt.store+"/products", nil), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			var products []struct{ StoreID string }
+			decodeData(rec, &products)
+			if len(products) != tt.count || products[0].StoreID != tt.store {
+				t.Errorf("products = %+v", products)
+			}
+		})
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims auth.Claims
+		req    *http.Request
+		want   response.Code
+	}{
+		{"several stores", bob, jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp"}`), "store_required"},
+		{"no store", carol, jsonRequest(http.MethodPost, "/api/products", `{"Name":"Lamp"}`), "no_store"},
+		{"another store", alice, jsonRequest(http.MethodPut, "/api/products/product-b", `{"name":"Kettle"}`), "store_access_denied"},
+		{"no changes", alice, jsonRequest(http.MethodPut, "/api/products/product-a", `{}`), "no_changes"},
+		{"negative stock received", alice, jsonRequest(http.MethodPost, "/api/products/product-a/inventory/receive", `{"quantity":-1}`), "invalid_inventory"},
+		{"more stock removed than held", alice, jsonRequest(http.MethodPost, "/api/products/product-a/inventory/adjust", `{"quantity":-1,"reason":"Lost"}`), "insufficient_stock"},
+		{"missing product", nobody, httptest.NewRequest(http.MethodGet, "/api/products/missing", nil), response.CodeNotFound},
+		{"anonymous", nobody, jsonRequest(http.MethodDelete, "/api/products/product-a", ""), response.CodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newFixture(t).serve(t, tt.req, tt.claims)
+
+			var problem response.Problem
+			if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Header().Get("Content-Type") != response.ProblemContentType || problem.Code != tt.want || problem.Status != rec.Code {
+				t.Errorf("%d %s response: %+v, want code %s", rec.Code, rec.Header().Get("Content-Type"), problem, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupProducts(t *testing.T) {
+	f := newFixture(t)
+	f.serve(t, jsonRequest(http.MethodPut, "/api/products/product-b", `{"price":80,"isActive":false}`), bob)
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/internal/products?ids=product-a,missing,product-b", nil), nobody)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var body catalog.Products
+	decodeData(rec, &body)
+	want := []catalog.Product{
+		{ID: "product-a", StoreID: "store-a", Name: "Teapot", Price: 120, IsActive: true},
+		{ID: "product-b", StoreID: "store-b", Name: "Rug", Price: 80, IsActive: false},
+	}
+	if len(body.Products) != len(want) || body.Products[0] != want[0] || body.Products[1] != want[1] {
+		t.Errorf("products = %+v, want %+v", body.Products, want)
+	}
+
+	ids := strings.Repeat("product-a,", 101)
+	if rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/internal/products?ids="+ids, nil), nobody); rec.Code != http.StatusBadRequest {
+		t.Errorf("101 ids status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+)
+
+// ImageRepository stores product images.
+type ImageRepository interface {
+	Create(ctx context.Context, image *domain.Image) error
+	FindByID(ctx context.Context, id string) (domain.Image, error)
+	Update(ctx context.Context, image *domain.Image) error
+	// SetPrimary makes imageID the only primary image of productID.
+	SetPrimary(ctx context.Context, productID, imageID string) error
+	Delete(ctx context.Context, id string) error
+}
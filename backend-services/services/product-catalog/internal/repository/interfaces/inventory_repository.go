@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+)
+
+// InventoryRepository stores each product's stock level. Stock is only
+// changed within a transaction, on inventory locked by FindByProductID.
+type InventoryRepository interface {
+	// FindByProductID returns the product's inventory. Within a
+	// transaction it is locked until the transaction ends.
+	FindByProductID(ctx context.Context, productID string) (domain.Inventory, error)
+	// CreateIfMissing gives the product an empty inventory unless it
+	// already has one.
+	CreateIfMissing(ctx context.Context, productID string) error
+	// Save creates or replaces the inventory of inventory.ProductID.
+	Save(ctx context.Context, inventory *domain.Inventory) error
+}
+
+// MovementRepository stores the stock ledger.
+type MovementRepository interface {
+	Create(ctx context.Context, movement *domain.InventoryMovement) error
+	// ListByProductID returns the product's latest movements, newest
+	// first.
+	ListByProductID(ctx context.Context, productID string, limit int) ([]domain.InventoryMovement, error)
+}
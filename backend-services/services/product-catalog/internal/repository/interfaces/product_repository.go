@@ -0,0 +1,23 @@
+// Package interfaces declares the storage the product-catalog services
+// depend on. Lookups return domain.ErrNotFound when nothing matches.
+package interfaces
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+)
+
+// ProductRepository stores products. Products are returned with their
+// images.
+type ProductRepository interface {
+	Create(ctx context.Context, product *domain.Product) error
+	FindByID(ctx context.Context, id string) (domain.Product, error)
+	FindByStoreID(ctx context.Context, storeID string) ([]domain.Product, error)
+	// FindByIDs returns the products among ids that exist, without their
+	// images.
+	FindByIDs(ctx context.Context, ids []string) ([]domain.Product, error)
+	// Update saves the product's own fields, leaving its images alone.
+	Update(ctx context.Context, product *domain.Product) error
+	// Delete removes the product and its images.
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+)
+
+// ReservationRepository stores the stock held for orders.
+type ReservationRepository interface {
+	Find(ctx context.Context, orderID, productID string) (domain.Reservation, error)
+	Create(ctx context.Context, reservation *domain.Reservation) error
+	Update(ctx context.Context, reservation *domain.Reservation) error
+}
+
+// Repositories are the repositories bound to one transaction.
+type Repositories struct {
+	Inventory    InventoryRepository
+	Movements    MovementRepository
+	Reservations ReservationRepository
+}
+
+// Transactor runs units of work that must succeed or fail together.
+type Transactor interface {
+	// InTx runs fn in a transaction, committing it if fn returns nil
+	// and rolling it back otherwise. Inventory found within the
+	// transaction stays locked until it ends.
+	InTx(ctx context.Context, fn func(Repositories) error) error
+}
@@ -0,0 +1,114 @@
+// Package memory implements the repositories in memory, for tests and
+// local runs without a database.
+package memory
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type ImageRepository struct {
+	mu     sync.RWMutex
+	images map[string]domain.Image
+	order  []string
+}
+
+func NewImageRepository() *ImageRepository {
+	return &ImageRepository{images: make(map[string]domain.Image)}
+}
+
+func (r *ImageRepository) Create(ctx context.Context, image *domain.Image) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if image.ID == "" {
+		image.ID = uuid.NewString()
+	}
+	r.images[image.ID] = withoutProduct(*image)
+	r.order = append(r.order, image.ID)
+	return nil
+}
+
+func (r *ImageRepository) FindByID(ctx context.Context, id string) (domain.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	image, ok := r.images[id]
+	if !ok {
+		return domain.Image{}, domain.ErrNotFound
+	}
+	return image, nil
+}
+
+func (r *ImageRepository) Update(ctx context.Context, image *domain.Image) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.images[image.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	r.images[image.ID] = withoutProduct(*image)
+	return nil
+}
+
+func (r *ImageRepository) SetPrimary(ctx context.Context, productID, imageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if image, ok := r.images[imageID]; !ok || image.ProductID != productID {
+		return domain.ErrNotFound
+	}
+	for id, image := range r.images {
+		if image.ProductID == productID {
+			image.IsPrimary = id == imageID
+			r.images[id] = image
+		}
+	}
+	return nil
+}
+
+func (r *ImageRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.images[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.images, id)
+	return nil
+}
+
+// forProduct returns a product's images in the order they were added.
+func (r *ImageRepository) forProduct(productID string) []domain.Image {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	images := []domain.Image{}
+	for _, id := range r.order {
+		if image, ok := r.images[id]; ok && image.ProductID == productID {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+func (r *ImageRepository) deleteForProduct(productID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, image := range r.images {
+		if image.ProductID == productID {
+			delete(r.images, id)
+		}
+	}
+}
+
+// withoutProduct drops the loaded product, as the database does not store
+// it with the image.
+func withoutProduct(image domain.Image) domain.Image {
+	image.Product = domain.Product{}
+	return image
+}
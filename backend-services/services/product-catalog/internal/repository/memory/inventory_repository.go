@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InventoryRepository struct {
+	mu          sync.RWMutex
+	inventories map[string]domain.Inventory // by product id
+}
+
+func NewInventoryRepository() *InventoryRepository {
+	return &InventoryRepository{inventories: make(map[string]domain.Inventory)}
+}
+
+func (r *InventoryRepository) FindByProductID(ctx context.Context, productID string) (domain.Inventory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	inventory, ok := r.inventories[productID]
+	if !ok {
+		return domain.Inventory{}, domain.ErrNotFound
+	}
+	return inventory, nil
+}
+
+func (r *InventoryRepository) CreateIfMissing(ctx context.Context, productID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.inventories[productID]; !ok {
+		r.inventories[productID] = domain.Inventory{ID: uuid.NewString(), ProductID: productID, UpdatedAt: time.Now()}
+	}
+	return nil
+}
+
+func (r *InventoryRepository) Save(ctx context.Context, inventory *domain.Inventory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.inventories[inventory.ProductID]; ok {
+		inventory.ID = existing.ID
+	} else if inventory.ID == "" {
+		inventory.ID = uuid.NewString()
+	}
+	inventory.UpdatedAt = time.Now()
+	r.inventories[inventory.ProductID] = *inventory
+	return nil
+}
+
+type MovementRepository struct {
+	mu        sync.RWMutex
+	movements []domain.InventoryMovement
+}
+
+func NewMovementRepository() *MovementRepository {
+	return &MovementRepository{}
+}
+
+func (r *MovementRepository) Create(ctx context.Context, movement *domain.InventoryMovement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if movement.ID == "" {
+		movement.ID = uuid.NewString()
+	}
+	movement.CreatedAt = time.Now()
+	r.movements = append(r.movements, *movement)
+	return nil
+}
+
+func (r *MovementRepository) ListByProductID(ctx context.Context, productID string, limit int) ([]domain.InventoryMovement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	movements := []domain.InventoryMovement{}
+	for i := len(r.movements) - 1; i >= 0 && len(movements) < limit; i-- {
+		if r.movements[i].ProductID == productID {
+			movements = append(movements, r.movements[i])
+		}
+	}
+	return movements, nil
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProductRepository struct {
+	images *ImageRepository
+
+	mu       sync.RWMutex
+	products map[string]domain.Product
+}
+
+// NewProductRepository returns a ProductRepository keeping product images
+// in images.
+func NewProductRepository(images *ImageRepository) *ProductRepository {
+	return &ProductRepository{images: images, products: make(map[string]domain.Product)}
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *domain.Product) error {
+	r.mu.Lock()
+	if product.ID == "" {
+		product.ID = uuid.NewString()
+	}
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = product.CreatedAt
+	stored := *product
+	stored.Images = nil
+	r.products[product.ID] = stored
+	r.mu.Unlock()
+
+	for i := range product.Images {
+		product.Images[i].ProductID = product.ID
+		if err := r.images.Create(ctx, &product.Images[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id string) (domain.Product, error) {
+	r.mu.RLock()
+	product, ok := r.products[id]
+	r.mu.RUnlock()
+	if !ok {
+		return domain.Product{}, domain.ErrNotFound
+	}
+	product.Images = r.images.forProduct(id)
+	return product, nil
+}
+
+func (r *ProductRepository) FindByStoreID(ctx context.Context, storeID string) ([]domain.Product, error) {
+	r.mu.RLock()
+	products := []domain.Product{}
+	for _, product := range r.products {
+		if product.StoreID == storeID {
+			products = append(products, product)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(products, func(i, j int) bool { return products[i].CreatedAt.Before(products[j].CreatedAt) })
+	for i := range products {
+		products[i].Images = r.images.forProduct(products[i].ID)
+	}
+	return products, nil
+}
+
+func (r *ProductRepository) FindByIDs(ctx context.Context, ids []string) ([]domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := []domain.Product{}
+	for _, id := range ids {
+		if product, ok := r.products[id]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, product *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[product.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	product.UpdatedAt = time.Now()
+	stored := *product
+	stored.Images = nil
+	r.products[product.ID] = stored
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	if _, ok := r.products[id]; !ok {
+		r.mu.Unlock()
+		return domain.ErrNotFound
+	}
+	delete(r.products, id)
+	r.mu.Unlock()
+
+	r.images.deleteForProduct(id)
+	return nil
+}
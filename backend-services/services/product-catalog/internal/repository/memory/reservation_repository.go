@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+	repositories "product-catalog/internal/repository/interfaces"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReservationRepository struct {
+	mu           sync.RWMutex
+	reservations map[[2]string]domain.Reservation // by order and product id
+}
+
+func NewReservationRepository() *ReservationRepository {
+	return &ReservationRepository{reservations: make(map[[2]string]domain.Reservation)}
+}
+
+func (r *ReservationRepository) Find(ctx context.Context, orderID, productID string) (domain.Reservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reservation, ok := r.reservations[[2]string{orderID, productID}]
+	if !ok {
+		return domain.Reservation{}, domain.ErrNotFound
+	}
+	return reservation, nil
+}
+
+func (r *ReservationRepository) Create(ctx context.Context, reservation *domain.Reservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reservation.ID == "" {
+		reservation.ID = uuid.NewString()
+	}
+	reservation.CreatedAt = time.Now()
+	reservation.UpdatedAt = reservation.CreatedAt
+	r.reservations[[2]string{reservation.OrderID, reservation.ProductID}] = *reservation
+	return nil
+}
+
+func (r *ReservationRepository) Update(ctx context.Context, reservation *domain.Reservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{reservation.OrderID, reservation.ProductID}
+	if _, ok := r.reservations[key]; !ok {
+		return domain.ErrNotFound
+	}
+	reservation.UpdatedAt = time.Now()
+	r.reservations[key] = *reservation
+	return nil
+}
+
+// Transactor serializes units of work over the in-memory repositories and
+// undoes the changes of those that fail.
+type Transactor struct {
+	mu           sync.Mutex
+	inventory    *InventoryRepository
+	movements    *MovementRepository
+	reservations *ReservationRepository
+}
+
+func NewTransactor(inventory *InventoryRepository, movements *MovementRepository, reservations *ReservationRepository) *Transactor {
+	return &Transactor{inventory: inventory, movements: movements, reservations: reservations}
+}
+
+func (t *Transactor) InTx(ctx context.Context, fn func(repositories.Repositories) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inventory.mu.RLock()
+	inventories := make(map[string]domain.Inventory, len(t.inventory.inventories))
+	for id, inventory := range t.inventory.inventories {
+		inventories[id] = inventory
+	}
+	t.inventory.mu.RUnlock()
+	t.reservations.mu.RLock()
+	reservations := make(map[[2]string]domain.Reservation, len(t.reservations.reservations))
+	for key, reservation := range t.reservations.reservations {
+		reservations[key] = reservation
+	}
+	t.reservations.mu.RUnlock()
+	t.movements.mu.RLock()
+	movements := len(t.movements.movements)
+	t.movements.mu.RUnlock()
+
+	if err := fn(repositories.Repositories{Inventory: t.inventory, Movements: t.movements, Reservations: t.reservations}); err != nil {
+		t.inventory.mu.Lock()
+		t.inventory.inventories = inventories
+		t.inventory.mu.Unlock()
+		t.reservations.mu.Lock()
+		t.reservations.reservations = reservations
+		t.reservations.mu.Unlock()
+		// Movements are only ever appended
+		t.movements.mu.Lock()
+		t.movements.movements = t.movements.movements[:movements]
+		t.movements.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ImageRepository struct {
+	db *gorm.DB
+}
+
+func NewImageRepository(db *gorm.DB) *ImageRepository {
+	return &ImageRepository{db: db}
+}
+
+func (r *ImageRepository) Create(ctx context.Context, image *domain.Image) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(image).Error
+}
+
+func (r *ImageRepository) FindByID(ctx context.Context, id string) (domain.Image, error) {
+	var image domain.Image
+	err := r.db.WithContext(ctx).First(&image, "id = ?", id).Error
+	return image, notFound(err)
+}
+
+func (r *ImageRepository) Update(ctx context.Context, image *domain.Image) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(image).Error
+}
+
+func (r *ImageRepository) SetPrimary(ctx context.Context, productID, imageID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Image{}).Where("product_id = ?", productID).Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&domain.Image{}).Where("id = ? AND product_id = ?", imageID, productID).Update("is_primary", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *ImageRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&domain.Image{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InventoryRepository struct {
+	db *gorm.DB
+	// lock is set for repositories bound to a transaction, where lookups
+	// lock the rows they return.
+	lock bool
+}
+
+func NewInventoryRepository(db *gorm.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+func (r *InventoryRepository) FindByProductID(ctx context.Context, productID string) (domain.Inventory, error) {
+	var inventory domain.Inventory
+	query := r.db.WithContext(ctx)
+	if r.lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	err := query.First(&inventory, "product_id = ?", productID).Error
+	return inventory, notFound(err)
+}
+
+func (r *InventoryRepository) CreateIfMissing(ctx context.Context, productID string) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}},
+		DoNothing: true,
+	}).Create(&domain.Inventory{ProductID: productID}).Error
+}
+
+func (r *InventoryRepository) Save(ctx context.Context, inventory *domain.Inventory) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "reserved", "updated_at"}),
+	}, clause.Returning{}).Create(inventory).Error
+}
+
+type MovementRepository struct {
+	db *gorm.DB
+}
+
+func NewMovementRepository(db *gorm.DB) *MovementRepository {
+	return &MovementRepository{db: db}
+}
+
+func (r *MovementRepository) Create(ctx context.Context, movement *domain.InventoryMovement) error {
+	return r.db.WithContext(ctx).Create(movement).Error
+}
+
+func (r *MovementRepository) ListByProductID(ctx context.Context, productID string, limit int) ([]domain.InventoryMovement, error) {
+	movements := []domain.InventoryMovement{}
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("created_at DESC").Limit(limit).Find(&movements).Error
+	return movements, err
+}
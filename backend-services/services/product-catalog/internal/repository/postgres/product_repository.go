@@ -0,0 +1,80 @@
+// Package postgres implements the repositories with GORM.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"product-catalog/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductRepository struct {
+	db *gorm.DB
+}
+
+func NewProductRepository(db *gorm.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *domain.Product) error {
+	return r.db.WithContext(ctx).Create(product).Error
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id string) (domain.Product, error) {
+	var product domain.Product
+	err := r.db.WithContext(ctx).Preload("Images").First(&product, "id = ?", id).Error
+	return product, notFound(err)
+}
+
+func (r *ProductRepository) FindByStoreID(ctx context.Context, storeID string) ([]domain.Product, error) {
+	var products []domain.Product
+	err := r.db.WithContext(ctx).Preload("Images").Where("store_id = ?", storeID).Find(&products).Error
+	return products, err
+}
+
+func (r *ProductRepository) FindByIDs(ctx context.Context, ids []string) ([]domain.Product, error) {
+	// An id that is not a UUID cannot match and would fail the query
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err == nil {
+			valid = append(valid, id)
+		}
+	}
+	products := []domain.Product{}
+	if len(valid) == 0 {
+		return products, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", valid).Find(&products).Error
+	return products, err
+}
+
+func (r *ProductRepository) Update(ctx context.Context, product *domain.Product) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(product).Error
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", id).Delete(&domain.Image{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&domain.Product{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// notFound translates GORM's missing record error into the domain's.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
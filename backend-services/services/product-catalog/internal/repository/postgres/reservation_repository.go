@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+	repositories "product-catalog/internal/repository/interfaces"
+
+	"gorm.io/gorm"
+)
+
+type ReservationRepository struct {
+	db *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+func (r *ReservationRepository) Find(ctx context.Context, orderID, productID string) (domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := r.db.WithContext(ctx).First(&reservation, "order_id = ? AND product_id = ?", orderID, productID).Error
+	return reservation, notFound(err)
+}
+
+func (r *ReservationRepository) Create(ctx context.Context, reservation *domain.Reservation) error {
+	return r.db.WithContext(ctx).Create(reservation).Error
+}
+
+func (r *ReservationRepository) Update(ctx context.Context, reservation *domain.Reservation) error {
+	return r.db.WithContext(ctx).Save(reservation).Error
+}
+
+// Transactor runs units of work in database transactions.
+type Transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+func (t *Transactor) InTx(ctx context.Context, fn func(repositories.Repositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(repositories.Repositories{
+			Inventory:    &InventoryRepository{db: tx, lock: true},
+			Movements:    NewMovementRepository(tx),
+			Reservations: NewReservationRepository(tx),
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"product-catalog/internal/domain"
+	repositories "product-catalog/internal/repository/interfaces"
+	services "product-catalog/internal/service/interfaces"
+	"shared/auth"
+	"time"
+)
+
+var _ services.ImageService = (*ImageService)(nil)
+
+type ImageService struct {
+	products repositories.ProductRepository
+	images   repositories.ImageRepository
+	uploads  services.ImageStore
+	stores   services.StoreAccess
+}
+
+func NewImageService(products repositories.ProductRepository, images repositories.ImageRepository, uploads services.ImageStore, stores services.StoreAccess) *ImageService {
+	return &ImageService{products: products, images: images, uploads: uploads, stores: stores}
+}
+
+func (s *ImageService) Upload(ctx context.Context, claims auth.Claims, productID string, file io.Reader, altText string) (domain.Image, error) {
+	if _, err := managedProduct(ctx, s.products, s.stores, claims, productID); err != nil {
+		return domain.Image{}, err
+	}
+
+	// A unique public id keeps earlier images of the product
+	publicID := fmt.Sprintf("product-%s-image-%d", productID, time.Now().UnixNano())
+	url, err := s.uploads.UploadImage(ctx, file, publicID)
+	if err != nil {
+		return domain.Image{}, err
+	}
+
+	image := domain.Image{ProductID: productID, URL: url, AltText: altText}
+	if err := s.images.Create(ctx, &image); err != nil {
+		return domain.Image{}, err
+	}
+	return image, nil
+}
+
+func (s *ImageService) SetPrimary(ctx context.Context, claims auth.Claims, productID, imageID string) error {
+	if _, err := managedProduct(ctx, s.products, s.stores, claims, productID); err != nil {
+		return err
+	}
+	return s.images.SetPrimary(ctx, productID, imageID)
+}
+
+func (s *ImageService) UpdateAltText(ctx context.Context, claims auth.Claims, productID, imageID, altText string) error {
+	image, err := s.productImage(ctx, claims, productID, imageID)
+	if err != nil {
+		return err
+	}
+	image.AltText = altText
+	return s.images.Update(ctx, &image)
+}
+
+func (s *ImageService) Delete(ctx context.Context, claims auth.Claims, productID, imageID string) error {
+	if _, err := s.productImage(ctx, claims, productID, imageID); err != nil {
+		return err
+	}
+	return s.images.Delete(ctx, imageID)
+}
+
+// productImage returns the image if it belongs to productID and the
+// caller may manage the product's store.
+func (s *ImageService) productImage(ctx context.Context, claims auth.Claims, productID, imageID string) (domain.Image, error) {
+	if _, err := managedProduct(ctx, s.products, s.stores, claims, productID); err != nil {
+		return domain.Image{}, err
+	}
+	image, err := s.images.FindByID(ctx, imageID)
+	if err != nil {
+		return domain.Image{}, err
+	}
+	if image.ProductID != productID {
+		return domain.Image{}, domain.ErrNotFound
+	}
+	return image, nil
+}
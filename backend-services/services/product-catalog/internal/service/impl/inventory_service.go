@@ -0,0 +1,229 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"product-catalog/internal/domain"
+	repositories "product-catalog/internal/repository/interfaces"
+	services "product-catalog/internal/service/interfaces"
+	"shared/auth"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var _ services.InventoryService = (*InventoryService)(nil)
+
+// orderActor is recorded as the author of stock changes made for orders.
+const orderActor = "order-management"
+
+type InventoryService struct {
+	products  repositories.ProductRepository
+	inventory repositories.InventoryRepository
+	movements repositories.MovementRepository
+	tx        repositories.Transactor
+	stores    services.StoreAccess
+}
+
+func NewInventoryService(products repositories.ProductRepository, inventory repositories.InventoryRepository, movements repositories.MovementRepository, tx repositories.Transactor, stores services.StoreAccess) *InventoryService {
+	return &InventoryService{products: products, inventory: inventory, movements: movements, tx: tx, stores: stores}
+}
+
+func (s *InventoryService) Get(ctx context.Context, productID string) (domain.Inventory, error) {
+	return s.inventory.FindByProductID(ctx, productID)
+}
+
+func (s *InventoryService) Receive(ctx context.Context, claims auth.Claims, productID string, quantity int, reason string) (domain.Inventory, error) {
+	if quantity <= 0 {
+		return domain.Inventory{}, domain.ErrInvalidInventory
+	}
+	return s.move(ctx, claims, domain.InventoryMovement{
+		ProductID:     productID,
+		Type:          domain.MovementReceive,
+		QuantityDelta: quantity,
+		Reason:        strings.TrimSpace(reason),
+	})
+}
+
+func (s *InventoryService) Adjust(ctx context.Context, claims auth.Claims, productID string, delta int, reason string) (domain.Inventory, error) {
+	reason = strings.TrimSpace(reason)
+	if delta == 0 || reason == "" {
+		return domain.Inventory{}, domain.ErrInvalidInventory
+	}
+	return s.move(ctx, claims, domain.InventoryMovement{
+		ProductID:     productID,
+		Type:          domain.MovementAdjust,
+		QuantityDelta: delta,
+		Reason:        reason,
+	})
+}
+
+func (s *InventoryService) Movements(ctx context.Context, claims auth.Claims, productID string, limit int) ([]domain.InventoryMovement, error) {
+	if _, err := managedProduct(ctx, s.products, s.stores, claims, productID); err != nil {
+		return nil, err
+	}
+	return s.movements.ListByProductID(ctx, productID, limit)
+}
+
+// move applies a seller's movement to the stock of a product of theirs.
+func (s *InventoryService) move(ctx context.Context, claims auth.Claims, movement domain.InventoryMovement) (domain.Inventory, error) {
+	if _, err := managedProduct(ctx, s.products, s.stores, claims, movement.ProductID); err != nil {
+		return domain.Inventory{}, err
+	}
+	movement.Actor = claims.ID
+
+	var inventory domain.Inventory
+	err := s.tx.InTx(ctx, func(repos repositories.Repositories) error {
+		if err := repos.Inventory.CreateIfMissing(ctx, movement.ProductID); err != nil {
+			return err
+		}
+		var err error
+		inventory, err = repos.Inventory.FindByProductID(ctx, movement.ProductID)
+		if err != nil {
+			return err
+		}
+		return applyMovement(ctx, repos, &inventory, movement)
+	})
+	if err != nil {
+		return domain.Inventory{}, err
+	}
+	return inventory, nil
+}
+
+func (s *InventoryService) Reserve(ctx context.Context, productID, orderID string, quantity int) (domain.Reservation, error) {
+	if _, err := uuid.Parse(orderID); err != nil || quantity <= 0 {
+		return domain.Reservation{}, domain.ErrInvalidReservation
+	}
+	return s.reservation(ctx, productID, orderID, func(repos repositories.Repositories, inventory *domain.Inventory, reservation *domain.Reservation) error {
+		switch reservation.Status {
+		case domain.ReservationHeld:
+			return nil
+		case "":
+		default:
+			return domain.ErrReservationEnded
+		}
+		if inventory == nil {
+			return domain.ErrOutOfStock
+		}
+
+		*reservation = domain.Reservation{OrderID: orderID, ProductID: productID, Quantity: quantity, Status: domain.ReservationHeld}
+		err := applyMovement(ctx, repos, inventory, orderMovement(domain.MovementReserve, *reservation, 0, quantity, "Reserved for order"))
+		if err != nil {
+			return err
+		}
+		return repos.Reservations.Create(ctx, reservation)
+	})
+}
+
+func (s *InventoryService) Release(ctx context.Context, productID, orderID string) (domain.Reservation, error) {
+	if _, err := uuid.Parse(orderID); err != nil {
+		return domain.Reservation{}, domain.ErrInvalidReservation
+	}
+	return s.reservation(ctx, productID, orderID, func(repos repositories.Repositories, inventory *domain.Inventory, reservation *domain.Reservation) error {
+		switch reservation.Status {
+		case "":
+			*reservation = domain.Reservation{OrderID: orderID, ProductID: productID, Status: domain.ReservationReleased}
+			return repos.Reservations.Create(ctx, reservation)
+		case domain.ReservationReleased:
+			return nil
+		case domain.ReservationCommitted:
+			return domain.ErrReservationEnded
+		}
+
+		// Held stock was reserved from this inventory
+		if inventory == nil {
+			return domain.ErrNotFound
+		}
+		err := applyMovement(ctx, repos, inventory, orderMovement(domain.MovementRelease, *reservation, 0, -reservation.Quantity, "Released by order"))
+		if err != nil {
+			return err
+		}
+		reservation.Status = domain.ReservationReleased
+		return repos.Reservations.Update(ctx, reservation)
+	})
+}
+
+func (s *InventoryService) Commit(ctx context.Context, productID, orderID string) (domain.Reservation, error) {
+	if _, err := uuid.Parse(orderID); err != nil {
+		return domain.Reservation{}, domain.ErrInvalidReservation
+	}
+	return s.reservation(ctx, productID, orderID, func(repos repositories.Repositories, inventory *domain.Inventory, reservation *domain.Reservation) error {
+		switch reservation.Status {
+		case "":
+			return domain.ErrNotFound
+		case domain.ReservationCommitted:
+			return nil
+		case domain.ReservationReleased:
+			return domain.ErrReservationEnded
+		}
+
+		if inventory == nil {
+			return domain.ErrNotFound
+		}
+		err := applyMovement(ctx, repos, inventory, orderMovement(domain.MovementCommit, *reservation, -reservation.Quantity, -reservation.Quantity, "Shipped with order"))
+		if err != nil {
+			return err
+		}
+		reservation.Status = domain.ReservationCommitted
+		return repos.Reservations.Update(ctx, reservation)
+	})
+}
+
+// reservation runs change in a transaction on the product's locked
+// inventory, nil if it has none, and the order's reservation, zero if
+// there is none yet.
+func (s *InventoryService) reservation(ctx context.Context, productID, orderID string, change func(repositories.Repositories, *domain.Inventory, *domain.Reservation) error) (domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := s.tx.InTx(ctx, func(repos repositories.Repositories) error {
+		var inventory *domain.Inventory
+		found, err := repos.Inventory.FindByProductID(ctx, productID)
+		switch {
+		case err == nil:
+			inventory = &found
+		case !errors.Is(err, domain.ErrNotFound):
+			return err
+		}
+
+		reservation, err = repos.Reservations.Find(ctx, orderID, productID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		return change(repos, inventory, &reservation)
+	})
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	return reservation, nil
+}
+
+// orderMovement describes a change to the stock reserved for an order.
+func orderMovement(kind domain.MovementType, reservation domain.Reservation, quantityDelta, reservedDelta int, reason string) domain.InventoryMovement {
+	orderID := reservation.OrderID
+	return domain.InventoryMovement{
+		ProductID:     reservation.ProductID,
+		Type:          kind,
+		QuantityDelta: quantityDelta,
+		ReservedDelta: reservedDelta,
+		Reason:        reason,
+		Actor:         orderActor,
+		OrderID:       &orderID,
+	}
+}
+
+// applyMovement changes the locked inventory by the movement's deltas and
+// records the movement, refusing changes that would leave the stock
+// negative or reserve more than there is.
+func applyMovement(ctx context.Context, repos repositories.Repositories, inventory *domain.Inventory, movement domain.InventoryMovement) error {
+	quantity := inventory.Quantity + movement.QuantityDelta
+	reserved := inventory.Reserved + movement.ReservedDelta
+	if quantity < 0 || reserved < 0 || reserved > quantity {
+		return domain.ErrOutOfStock
+	}
+
+	inventory.Quantity, inventory.Reserved = quantity, reserved
+	if err := repos.Inventory.Save(ctx, inventory); err != nil {
+		return err
+	}
+	movement.QuantityAfter, movement.ReservedAfter = quantity, reserved
+	return repos.Movements.Create(ctx, &movement)
+}
@@ -0,0 +1,124 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"product-catalog/internal/domain"
+	repositories "product-catalog/internal/repository/interfaces"
+	services "product-catalog/internal/service/interfaces"
+	"shared/auth"
+)
+
+var _ services.ProductService = (*ProductService)(nil)
+
+type ProductService struct {
+	products repositories.ProductRepository
+	images   repositories.ImageRepository
+	uploads  services.ImageStore
+	stores   services.StoreAccess
+}
+
+func NewProductService(products repositories.ProductRepository, images repositories.ImageRepository, uploads services.ImageStore, stores services.StoreAccess) *ProductService {
+	return &ProductService{products: products, images: images, uploads: uploads, stores: stores}
+}
+
+func (s *ProductService) Create(ctx context.Context, claims auth.Claims, product domain.Product, image *services.ImageUpload) (domain.Product, error) {
+	// Default to the caller's store when they have exactly one
+	if product.StoreID == "" {
+		storeIDs, err := s.stores.StoreIDs(ctx, claims)
+		if err != nil {
+			return domain.Product{}, fmt.Errorf("%w: %v", domain.ErrStoreLookup, err)
+		}
+		switch len(storeIDs) {
+		case 0:
+			return domain.Product{}, domain.ErrNoStore
+		case 1:
+			product.StoreID = storeIDs[0]
+		default:
+			return domain.Product{}, domain.ErrStoreRequired
+		}
+	} else if err := requireStore(ctx, s.stores, claims, product.StoreID); err != nil {
+		return domain.Product{}, err
+	}
+
+	product.ID = ""
+	product.Images = nil
+	if err := s.products.Create(ctx, &product); err != nil {
+		return domain.Product{}, err
+	}
+	if image == nil {
+		return product, nil
+	}
+
+	// The product is created first, as its id names the image
+	url, err := s.uploads.UploadImage(ctx, image.File, fmt.Sprintf("product-%s-image", product.ID))
+	if err == nil {
+		primary := domain.Image{ProductID: product.ID, URL: url, AltText: image.AltText, IsPrimary: true}
+		if err = s.images.Create(ctx, &primary); err == nil {
+			product.Images = []domain.Image{primary}
+			return product, nil
+		}
+	}
+	if err := s.products.Delete(ctx, product.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to roll back product after image upload failed", "product_id", product.ID, "error", err)
+	}
+	return domain.Product{}, fmt.Errorf("failed to add image: %w", err)
+}
+
+func (s *ProductService) Get(ctx context.Context, id string) (domain.Product, error) {
+	return s.products.FindByID(ctx, id)
+}
+
+func (s *ProductService) Update(ctx context.Context, claims auth.Claims, id string, changes services.ProductChanges) (domain.Product, error) {
+	product, err := managedProduct(ctx, s.products, s.stores, claims, id)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	updated := false
+	set := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+			updated = true
+		}
+	}
+	set(&product.Name, changes.Name)
+	set(&product.Description, changes.Description)
+	set(&product.Category, changes.Category)
+	set(&product.SKU, changes.SKU)
+	if changes.Price != nil {
+		product.Price = *changes.Price
+		updated = true
+	}
+	if changes.IsActive != nil {
+		product.IsActive = *changes.IsActive
+		updated = true
+	}
+	if !updated {
+		return domain.Product{}, domain.ErrNoChanges
+	}
+
+	if err := s.products.Update(ctx, &product); err != nil {
+		return domain.Product{}, err
+	}
+	return product, nil
+}
+
+func (s *ProductService) Delete(ctx context.Context, claims auth.Claims, id string) error {
+	if _, err := managedProduct(ctx, s.products, s.stores, claims, id); err != nil {
+		return err
+	}
+	return s.products.Delete(ctx, id)
+}
+
+func (s *ProductService) ListByStore(ctx context.Context, claims auth.Claims, storeID string) ([]domain.Product, error) {
+	if err := requireStore(ctx, s.stores, claims, storeID); err != nil {
+		return nil, err
+	}
+	return s.products.FindByStoreID(ctx, storeID)
+}
+
+func (s *ProductService) Lookup(ctx context.Context, ids []string) ([]domain.Product, error) {
+	return s.products.FindByIDs(ctx, ids)
+}
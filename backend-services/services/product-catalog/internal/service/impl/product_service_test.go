@@ -0,0 +1,120 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"product-catalog/internal/domain"
+	"product-catalog/internal/repository/memory"
+	services "product-catalog/internal/service/interfaces"
+	"shared/auth"
+	"strings"
+	"testing"
+)
+
+type fakeStores map[string][]string
+
+func (f fakeStores) StoreIDs(ctx context.Context, claims auth.Claims) ([]string, error) {
+	return f[claims.ID], nil
+}
+
+func (f fakeStores) CanManage(ctx context.Context, claims auth.Claims, storeID string) (bool, error) {
+	for _, id := range f[claims.ID] {
+		if id == storeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeImages struct {
+	fail bool
+}
+
+func (f *fakeImages) UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error) {
+	if f.fail {
+		return "", errors.New("upload failed")
+	}
+	return "https://images.test/" + publicID, nil
+}
+
+var alice = auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+
+func newServices() (*ProductService, *ImageService, *memory.ProductRepository, *fakeImages) {
+	images := memory.NewImageRepository()
+	products := memory.NewProductRepository(images)
+	uploads := &fakeImages{}
+	stores := fakeStores{"alice": {"store-a"}}
+	return NewProductService(products, images, uploads, stores), NewImageService(products, images, uploads, stores), products, uploads
+}
+
+func TestProductLifecycle(t *testing.T) {
+	ctx := context.Background()
+	productService, imageService, products, _ := newServices()
+
+	product, err := productService.Create(ctx, alice, domain.Product{Name: "Teapot"}, &services.ImageUpload{File: strings.NewReader("png"), AltText: "Front"})
+	if err != nil || product.StoreID != "store-a" || len(product.Images) != 1 {
+		t.Fatalf("Create = %+v, %v", product, err)
+	}
+	if !strings.HasSuffix(product.Images[0].URL, "product-"+product.ID+"-image") || !product.Images[0].IsPrimary {
+		t.Errorf("primary image = %+v", product.Images[0])
+	}
+
+	if _, err := productService.Update(ctx, alice, product.ID, services.ProductChanges{}); !errors.Is(err, domain.ErrNoChanges) {
+		t.Errorf("empty Update = %v, want ErrNoChanges", err)
+	}
+	price := 80.0
+	updated, err := productService.Update(ctx, alice, product.ID, services.ProductChanges{Price: &price})
+	if err != nil || updated.Price != 80 || updated.Name != "Teapot" {
+		t.Errorf("Update = %+v, %v", updated, err)
+	}
+
+	second, err := imageService.Upload(ctx, alice, product.ID, strings.NewReader("png"), "Side")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := imageService.SetPrimary(ctx, alice, product.ID, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	product, _ = products.FindByID(ctx, product.ID)
+	if product.Images[0].IsPrimary || !product.Images[1].IsPrimary {
+		t.Errorf("images after SetPrimary = %+v", product.Images)
+	}
+
+	if err := productService.Delete(ctx, alice, product.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := productService.Get(ctx, product.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreateRollsBackWhenUploadFails(t *testing.T) {
+	ctx := context.Background()
+	productService, _, products, uploads := newServices()
+	uploads.fail = true
+
+	if _, err := productService.Create(ctx, alice, domain.Product{Name: "Teapot"}, &services.ImageUpload{File: strings.NewReader("png")}); err == nil {
+		t.Fatal("Create succeeded despite the failed upload")
+	}
+	if left, _ := products.FindByStoreID(ctx, "store-a"); len(left) != 0 {
+		t.Errorf("failed Create left %+v", left)
+	}
+}
+
+func TestOtherStoresAreForbidden(t *testing.T) {
+	ctx := context.Background()
+	productService, imageService, products, _ := newServices()
+	rug := domain.Product{StoreID: "store-b", Name: "Rug"}
+	products.Create(ctx, &rug)
+
+	if _, err := productService.Create(ctx, alice, domain.Product{StoreID: "store-b", Name: "Lamp"}, nil); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Create = %v, want ErrForbidden", err)
+	}
+	if err := productService.Delete(ctx, alice, rug.ID); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Delete = %v, want ErrForbidden", err)
+	}
+	if _, err := imageService.Upload(ctx, alice, rug.ID, strings.NewReader("png"), ""); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Upload = %v, want ErrForbidden", err)
+	}
+}
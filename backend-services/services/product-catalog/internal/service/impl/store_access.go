@@ -0,0 +1,36 @@
+// Package impl implements the product-catalog services on top of the
+// repositories.
+package impl
+
+import (
+	"context"
+	"fmt"
+	"product-catalog/internal/domain"
+	repositories "product-catalog/internal/repository/interfaces"
+	services "product-catalog/internal/service/interfaces"
+	"shared/auth"
+)
+
+// requireStore returns nil if the caller may manage storeID.
+func requireStore(ctx context.Context, stores services.StoreAccess, claims auth.Claims, storeID string) error {
+	ok, err := stores.CanManage(ctx, claims, storeID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrStoreLookup, err)
+	}
+	if !ok {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// managedProduct returns the product if the caller may manage its store.
+func managedProduct(ctx context.Context, products repositories.ProductRepository, stores services.StoreAccess, claims auth.Claims, id string) (domain.Product, error) {
+	product, err := products.FindByID(ctx, id)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	if err := requireStore(ctx, stores, claims, product.StoreID); err != nil {
+		return domain.Product{}, err
+	}
+	return product, nil
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+	"product-catalog/internal/domain"
+	"shared/auth"
+)
+
+// ImageService manages product images. Images are addressed through their
+// product, and an image of another product is not found.
+type ImageService interface {
+	Upload(ctx context.Context, claims auth.Claims, productID string, file io.Reader, altText string) (domain.Image, error)
+	SetPrimary(ctx context.Context, claims auth.Claims, productID, imageID string) error
+	UpdateAltText(ctx context.Context, claims auth.Claims, productID, imageID, altText string) error
+	Delete(ctx context.Context, claims auth.Claims, productID, imageID string) error
+}
@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	"context"
+	"product-catalog/internal/domain"
+	"shared/auth"
+)
+
+// InventoryService manages stock levels. Stock reserved for orders is
+// counted in Reserved until the order ships and it is committed, taking it
+// off Quantity, or until it is released. Reservations are made by
+// order-management and are safe to retry.
+//
+// Stock only changes by amounts, never to a level set by the caller, and
+// every change is recorded as a domain.InventoryMovement. Changes that
+// would take Quantity or Reserved below zero, or reserve more than
+// Quantity, fail with domain.ErrOutOfStock.
+type InventoryService interface {
+	Get(ctx context.Context, productID string) (domain.Inventory, error)
+	// Receive adds quantity to a product's stock, creating its inventory
+	// if needed.
+	Receive(ctx context.Context, claims auth.Claims, productID string, quantity int, reason string) (domain.Inventory, error)
+	// Adjust corrects a product's stock by delta, up or down, for the
+	// given reason.
+	Adjust(ctx context.Context, claims auth.Claims, productID string, delta int, reason string) (domain.Inventory, error)
+	// Movements returns up to limit of the product's latest stock
+	// changes, newest first.
+	Movements(ctx context.Context, claims auth.Claims, productID string, limit int) ([]domain.InventoryMovement, error)
+	// Reserve holds quantity of the product for the order, failing with
+	// domain.ErrOutOfStock if less is available.
+	Reserve(ctx context.Context, productID, orderID string, quantity int) (domain.Reservation, error)
+	// Release returns the order's reserved stock. Releasing an unknown
+	// reservation records it as released, so that it cannot be made later.
+	Release(ctx context.Context, productID, orderID string) (domain.Reservation, error)
+	// Commit takes the order's reserved stock off the shelf.
+	Commit(ctx context.Context, productID, orderID string) (domain.Reservation, error)
+}
@@ -0,0 +1,54 @@
+// Package interfaces declares the product-catalog business operations
+// used by the handlers. Changes to a store's catalog the caller may not
+// manage return domain.ErrForbidden.
+package interfaces
+
+import (
+	"context"
+	"io"
+	"product-catalog/internal/domain"
+	"shared/auth"
+)
+
+// StoreAccess resolves the stores a caller owns or staffs.
+type StoreAccess interface {
+	StoreIDs(ctx context.Context, claims auth.Claims) ([]string, error)
+	CanManage(ctx context.Context, claims auth.Claims, storeID string) (bool, error)
+}
+
+// ImageStore hosts product images.
+type ImageStore interface {
+	UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error)
+}
+
+// ImageUpload is an image sent along with a request.
+type ImageUpload struct {
+	File    io.Reader
+	AltText string
+}
+
+// ProductChanges holds the product fields to update; nil fields are left
+// unchanged.
+type ProductChanges struct {
+	Name        *string
+	Description *string
+	Category    *string
+	Price       *float64
+	SKU         *string
+	IsActive    *bool
+}
+
+// ProductService manages products.
+type ProductService interface {
+	// Create adds product to product.StoreID, or to the caller's store when
+	// it is empty and they have exactly one. image, if not nil, becomes the
+	// primary image.
+	Create(ctx context.Context, claims auth.Claims, product domain.Product, image *ImageUpload) (domain.Product, error)
+	Get(ctx context.Context, id string) (domain.Product, error)
+	Update(ctx context.Context, claims auth.Claims, id string, changes ProductChanges) (domain.Product, error)
+	Delete(ctx context.Context, claims auth.Claims, id string) error
+	ListByStore(ctx context.Context, claims auth.Claims, storeID string) ([]domain.Product, error)
+	// Lookup returns the products among ids that exist, for other
+	// services.
+	Lookup(ctx context.Context, ids []string) ([]domain.Product, error)
+}
@@ -3,7 +3,7 @@ package util
 import (
 	"context"
 	"fmt"
-	"mime/multipart"
+	"io"
 	"os"
 
 	"github.com/cloudinary/cloudinary-go/v2"
@@ -30,7 +30,7 @@ func NewCloudinaryService() (*CloudinaryService, error) {
 }
 
 // UploadImage uploads an image file to Cloudinary and returns the secure URL
-func (s *CloudinaryService) UploadImage(ctx context.Context, file multipart.File, publicID string) (string, error) {
+func (s *CloudinaryService) UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error) {
 	uploadResult, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
 		Folder:   "product_images",
 		PublicID: publicID,
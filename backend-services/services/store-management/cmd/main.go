@@ -1,12 +1,19 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"store-management/internal/database"
-	"store-management/internal/middleware"
+	"shared/database"
+	"shared/logging"
+	"shared/metrics"
+	"shared/middleware"
+	"shared/server"
+	"shared/signing"
+	"shared/tracing"
+	"store-management/internal/database/migrations"
 	"store-management/internal/routes"
 
 	"github.com/gorilla/mux"
@@ -14,35 +21,70 @@ import (
 )
 
 func main() {
+	logging.Init("store-management")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found")
+		slog.Warn(".env file not found")
+	}
+
+	// "migrate [up|down [steps]|status]" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := database.MigrateCommand("store-management", migrations.FS, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
 	}
 
 	// Initialize database connection
-	dbConn, err := database.InitDB()
+	dbConn, err := database.InitDB("store-management", migrations.FS)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer dbConn.Close()
-	log.Println("Database connected")
+	slog.Info("Database connected")
+	metrics.RegisterDB(dbConn.SQLDB, "store-management")
+
+	// Export spans when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Init(context.Background(), "store-management")
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Create router
 	router := mux.NewRouter()
 
-	// Add middleware
+	// Link logs and spans to the gateway's request
+	router.Use(middleware.RequestIDMiddleware)
+	router.Use(tracing.Middleware(middleware.MuxRoutes))
 	router.Use(middleware.LoggingMiddleware)
+	router.Use(metrics.Middleware(middleware.MuxRoutes))
 
 	// Setup routes before starting the server
 	routes.SetupRoutes(router, dbConn.GormDB)
-	// In each service's main.go
-	router.Use(middleware.ServiceAuthMiddleware)
+	// Only the gateway and other services holding a signing key may call in
+	serviceAuth, err := signing.NewVerifierFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize request signing: %v", err)
+	}
+	router.Use(serviceAuth.Middleware)
+
+	// Metrics are scraped and health probed directly, without a request
+	// signature
+	handler := http.NewServeMux()
+	handler.Handle("/metrics", metrics.Handler())
+	handler.Handle("/health", database.HealthHandler(dbConn.SQLDB))
+	handler.Handle("/", router)
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8001"
 	}
 
-	fmt.Printf("Server starting on port %s\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, router))
+	// Serve until SIGINT or SIGTERM, then drain in-flight requests
+	if err := server.Run(":"+port, handler); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
 }
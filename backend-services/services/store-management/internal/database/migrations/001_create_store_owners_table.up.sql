@@ -0,0 +1,11 @@
+-- Databases set up by GORM's AutoMigrate before versioned migrations
+-- already have the initial tables, so these migrations only create what
+-- is missing.
+CREATE TABLE IF NOT EXISTS store_owners (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       text NOT NULL UNIQUE,
+    business_name text NOT NULL,
+    phone         text NOT NULL,
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS stores (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_owner_id uuid NOT NULL REFERENCES store_owners (id),
+    name           text NOT NULL,
+    description    text,
+    street         text NOT NULL,
+    city           text NOT NULL,
+    state          text NOT NULL,
+    latitude       decimal,
+    longitude      decimal,
+    logo_url       text,
+    is_active      boolean DEFAULT true,
+    created_at     timestamptz,
+    updated_at     timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_stores_store_owner_id ON stores (store_owner_id);
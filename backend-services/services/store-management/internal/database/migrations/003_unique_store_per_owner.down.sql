@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_stores_store_owner_id;
+CREATE INDEX IF NOT EXISTS idx_stores_store_owner_id ON stores (store_owner_id);
@@ -0,0 +1,5 @@
+-- Each store owner has at most one store. The service checks this before
+-- creating a store, and this index stops concurrent requests both passing
+-- the check.
+DROP INDEX IF EXISTS idx_stores_store_owner_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stores_store_owner_id ON stores (store_owner_id);
@@ -0,0 +1,10 @@
+// Package migrations embeds the service's SQL migrations, applied by
+// database.InitDB at startup and by the migrate subcommand.
+package migrations
+
+import "embed"
+
+// FS holds the <version>_<name>.up.sql and .down.sql files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,14 @@
+package domain
+
+import "errors"
+
+// Errors returned by repositories and services. Handlers map them to
+// HTTP statuses.
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrForbidden      = errors.New("forbidden")
+	ErrOwnerExists    = errors.New("user already has a store owner profile")
+	ErrOwnerHasStores = errors.New("store owner still has stores")
+	ErrNotStoreOwner  = errors.New("must be a store owner to create a store")
+	ErrStoreExists    = errors.New("store owner already has a store")
+)
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"shared/response"
+	"store-management/internal/domain"
+)
+
+// Codes for the errors clients may handle specially.
+const (
+	codeNotStoreOwner  response.Code = "not_store_owner"
+	codeOwnerExists    response.Code = "owner_exists"
+	codeStoreExists    response.Code = "store_exists"
+	codeOwnerHasStores response.Code = "owner_has_stores"
+)
+
+// writeServiceError responds to an error returned by a service. notFound
+// and failed are the messages for a missing record and an unexpected
+// failure.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, notFound, failed string) {
+	var problem *response.Problem
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		problem = response.NewProblem(http.StatusNotFound, response.CodeNotFound, notFound)
+	case errors.Is(err, domain.ErrForbidden):
+		problem = response.NewProblem(http.StatusForbidden, response.CodeForbidden, "Forbidden")
+	case errors.Is(err, domain.ErrNotStoreOwner):
+		problem = response.NewProblem(http.StatusForbidden, codeNotStoreOwner, "Must be a store owner to create a store")
+	case errors.Is(err, domain.ErrOwnerExists):
+		problem = response.NewProblem(http.StatusConflict, codeOwnerExists, "User already has a store owner profile")
+	case errors.Is(err, domain.ErrStoreExists):
+		problem = response.NewProblem(http.StatusConflict, codeStoreExists, "Store owner already has a store")
+	case errors.Is(err, domain.ErrOwnerHasStores):
+		problem = response.NewProblem(http.StatusConflict, codeOwnerHasStores, "Store owner still has stores")
+	default:
+		slog.ErrorContext(r.Context(), failed, "error", err)
+		problem = response.NewProblem(http.StatusInternalServerError, response.CodeInternal, failed)
+	}
+	response.WriteError(w, problem)
+}
@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"shared/auth"
+	"shared/auth/authtest"
+	"shared/response"
+	"store-management/internal/domain"
+	"store-management/internal/handlers"
+	"store-management/internal/policy"
+	"store-management/internal/repository/memory"
+	"store-management/internal/routes"
+	"store-management/internal/service/impl"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	alice    = auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+	bob      = auth.Claims{ID: "bob", Role: auth.RoleStoreOwner}
+	customer = auth.Claims{ID: "dave", Role: auth.RoleCustomer}
+	admin    = auth.Claims{ID: "root", Role: auth.RoleAdmin}
+)
+
+type fakeImages struct {
+	fail    bool
+	deleted []string
+}
+
+func (f *fakeImages) UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error) {
+	if f.fail {
+		return "", errors.New("upload failed")
+	}
+	return "https://images.test/" + publicID, nil
+}
+
+func (f *fakeImages) DeleteImage(ctx context.Context, publicID string) error {
+	f.deleted = append(f.deleted, publicID)
+	return nil
+}
+
+// fixture serves the routes from in-memory repositories holding alice's
+// profile, owner-a, and her store, store-a.
+type fixture struct {
+	owners *memory.StoreOwnerRepository
+	stores *memory.StoreRepository
+	images *fakeImages
+	issuer *authtest.Issuer
+	router *mux.Router
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	f := &fixture{
+		owners: memory.NewStoreOwnerRepository(),
+		images: &fakeImages{},
+		issuer: authtest.NewIssuer(t),
+		router: mux.NewRouter(),
+	}
+	f.stores = memory.NewStoreRepository(f.owners)
+	if err := f.owners.Create(ctx, &domain.StoreOwner{ID: "owner-a", UserID: "alice", BusinessName: "Alice Crafts", Phone: "0600000000"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.stores.Create(ctx, &domain.Store{ID: "store-a", StoreOwnerID: "owner-a", Name: "Atlas", Street: "1 Rue", City: "Fes", State: "Fes-Meknes"}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := policy.NewFromRepositories(f.stores, f.owners)
+	ownerService := impl.NewStoreOwnerService(f.owners, f.stores, p)
+	routes.Register(f.router, f.issuer.Middleware().PromoteStoreOwners(ownerService), routes.Handlers{
+		StoreOwner: handlers.NewStoreOwnerHandler(ownerService),
+		Store:      handlers.NewStoreHandler(impl.NewStoreService(f.stores, f.owners, f.images, p)),
+	})
+	return f
+}
+
+// serve sends req through the router, authenticated as claims unless
+// they are empty.
+func (f *fixture) serve(t *testing.T, req *http.Request, claims auth.Claims) *httptest.ResponseRecorder {
+	t.Helper()
+	if claims.ID != "" {
+		f.issuer.Authorize(t, req, claims)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// formRequest builds a multipart request with fields and, if logoType is
+// not empty, a logo of that content type.
+func formRequest(t *testing.T, method, path string, fields map[string]string, logoType string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	if logoType != "" {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="logo"; filename="logo"`)
+		header.Set("Content-Type", logoType)
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte("logo bytes"))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// decodeData decodes the data of a success envelope into v.
+func decodeData(rec *httptest.ResponseRecorder, v interface{}) error {
+	return json.NewDecoder(rec.Body).Decode(&response.Envelope{Data: v})
+}
+
+// decodeProblem decodes an error response.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) response.Problem {
+	t.Helper()
+	var problem response.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Header().Get("Content-Type") != response.ProblemContentType || problem.Status != rec.Code {
+		t.Errorf("%d %s response: %+v", rec.Code, rec.Header().Get("Content-Type"), problem)
+	}
+	return problem
+}
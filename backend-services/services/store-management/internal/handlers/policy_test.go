@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"strings"
+	"testing"
+)
+
+func TestCrossTenantMutationsAreRejected(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		claims auth.Claims
+		form   bool
+		want   int
+	}{
+		{"update another tenant's store", http.MethodPut, "/api/stores/store-a", bob, true, http.StatusForbidden},
+		{"delete another tenant's store", http.MethodDelete, "/api/stores/store-a", bob, false, http.StatusForbidden},
+		{"update another tenant's profile", http.MethodPut, "/api/store-owners/owner-a", bob, false, http.StatusForbidden},
+		{"delete another tenant's profile", http.MethodDelete, "/api/store-owners/owner-a", bob, false, http.StatusForbidden},
+		{"read another tenant's profile", http.MethodGet, "/api/store-owners/owner-a", bob, false, http.StatusForbidden},
+		{"staff cannot delete the store", http.MethodDelete, "/api/stores/store-a", auth.Claims{ID: "alice", Role: auth.RoleStoreStaff}, false, http.StatusForbidden},
+		{"update missing store", http.MethodPut, "/api/stores/store-x", bob, true, http.StatusNotFound},
+		{"delete missing profile", http.MethodDelete, "/api/store-owners/owner-x", bob, false, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Store updates are multipart forms, profile updates JSON
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"BusinessName":"taken over"}`))
+			if tt.form {
+				req = formRequest(t, tt.method, tt.path, map[string]string{"name": "taken over"}, "")
+			}
+			rec := f.serve(t, req, tt.claims)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	// Nothing was changed along the way
+	store, err := f.stores.FindByID(context.Background(), "store-a")
+	if err != nil || store.Name != "Atlas" {
+		t.Errorf("store-a = %+v, %v after rejected mutations", store, err)
+	}
+	owner, err := f.owners.FindByID(context.Background(), "owner-a")
+	if err != nil || owner.BusinessName != "Alice Crafts" {
+		t.Errorf("owner-a = %+v, %v after rejected mutations", owner, err)
+	}
+}
+
+func TestMutationsRequireClaims(t *testing.T) {
+	f := newFixture(t)
+
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodPost, "/api/stores"},
+		{http.MethodPut, "/api/stores/store-a"},
+		{http.MethodDelete, "/api/stores/store-a"},
+		{http.MethodPost, "/api/store-owners"},
+		{http.MethodPut, "/api/store-owners/owner-a"},
+		{http.MethodDelete, "/api/store-owners/owner-a"},
+	} {
+		rec := f.serve(t, httptest.NewRequest(tt.method, tt.path, nil), auth.Claims{})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: status = %d, want 401", tt.method, tt.path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"store-management/internal/domain"
+	"strings"
+	"testing"
+)
+
+// TestStoreOwnerOnboarding registers a store owner profile and creates a
+// store through the routes, with the only role tokens carry.
+func TestStoreOwnerOnboarding(t *testing.T) {
+	f := newFixture(t)
+	carol := auth.Claims{ID: "carol", Role: "user"}
+
+	createStore := func() *httptest.ResponseRecorder {
+		return f.serve(t, formRequest(t, http.MethodPost, "/api/stores", map[string]string{"name": "Carol Ceramics", "city": "Safi"}, ""), carol)
+	}
+
+	if rec := createStore(); rec.Code != http.StatusForbidden {
+		t.Fatalf("store without a profile: status = %d, want 403", rec.Code)
+	}
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodPost, "/api/store-owners", strings.NewReader(`{"BusinessName":"Carol Ceramics","Phone":"0622222222"}`)), carol)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("profile: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = createStore()
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("store: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var store domain.Store
+	decodeData(rec, &store)
+
+	rec = f.serve(t, httptest.NewRequest(http.MethodGet, "/api/stores", nil), carol)
+	var listed []domain.Store
+	decodeData(rec, &listed)
+	if rec.Code != http.StatusOK || len(listed) != 1 || listed[0].ID != store.ID {
+		t.Errorf("stores: status = %d, listed %+v, want %s", rec.Code, listed, store.ID)
+	}
+}
+
+// TestCustomersBrowseOwnerStores lists a seller's stores with a customer
+// token.
+func TestCustomersBrowseOwnerStores(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/store-owners/owner-a/stores", nil), customer)
+	var listed []domain.Store
+	decodeData(rec, &listed)
+	if rec.Code != http.StatusOK || len(listed) != 1 || listed[0].ID != "store-a" {
+		t.Errorf("status = %d, listed %+v, want store-a", rec.Code, listed)
+	}
+}
@@ -0,0 +1,167 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"store-management/internal/domain"
+	"testing"
+)
+
+func TestCreateStore(t *testing.T) {
+	fields := map[string]string{"name": "Bab", "street": "2 Derb", "city": "Rabat", "state": "Rabat-Sale"}
+
+	tests := []struct {
+		name     string
+		claims   auth.Claims
+		logoType string
+		setup    func(*fixture)
+		want     int
+	}{
+		{"owner without a store", bob, "", registerBob, http.StatusCreated},
+		{"with a logo", bob, "image/png", registerBob, http.StatusCreated},
+		{"logo is not an image", bob, "text/plain", registerBob, http.StatusBadRequest},
+		{"logo upload fails", bob, "image/png", func(f *fixture) { registerBob(f); f.images.fail = true }, http.StatusInternalServerError},
+		{"not a store owner", bob, "", nil, http.StatusForbidden},
+		{"one store per owner", alice, "", nil, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+
+			rec := f.serve(t, formRequest(t, http.MethodPost, "/api/stores", fields, tt.logoType), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			stores, _ := f.stores.FindByOwnerID(context.Background(), "owner-b")
+			if tt.want != http.StatusCreated {
+				if len(stores) != 0 {
+					t.Errorf("failed create left %d stores", len(stores))
+				}
+				return
+			}
+			var store domain.Store
+			decodeData(rec, &store)
+			if len(stores) != 1 || stores[0].ID != store.ID || store.Name != "Bab" {
+				t.Errorf("created %+v, stored %+v", store, stores)
+			}
+			if (tt.logoType != "") != (store.LogoURL != "") {
+				t.Errorf("LogoURL = %q with logo type %q", store.LogoURL, tt.logoType)
+			}
+		})
+	}
+}
+
+func TestUpdateStore(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.serve(t, formRequest(t, http.MethodPut, "/api/stores/store-a", map[string]string{"name": "Atlas Souk"}, "image/jpeg"), alice)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	store, _ := f.stores.FindByID(context.Background(), "store-a")
+	if store.Name != "Atlas Souk" || store.City != "Fes" || store.LogoURL == "" {
+		t.Errorf("store after update = %+v", store)
+	}
+}
+
+func TestAdminManagesAnyStore(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.serve(t, formRequest(t, http.MethodPut, "/api/stores/store-a", map[string]string{"city": "Meknes"}, ""), admin)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = f.serve(t, httptest.NewRequest(http.MethodDelete, "/api/stores/store-a", nil), admin)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := f.stores.FindByID(context.Background(), "store-a"); err != domain.ErrNotFound {
+		t.Errorf("store-a still exists: %v", err)
+	}
+}
+
+func TestDeleteStoreRemovesLogo(t *testing.T) {
+	f := newFixture(t)
+	store, _ := f.stores.FindByID(context.Background(), "store-a")
+	store.LogoURL = "https://images.test/store-store-a-logo"
+	f.stores.Update(context.Background(), &store)
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodDelete, "/api/stores/store-a", nil), alice)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(f.images.deleted) != 1 || f.images.deleted[0] != "store-store-a-logo" {
+		t.Errorf("deleted images = %v", f.images.deleted)
+	}
+}
+
+func TestListStores(t *testing.T) {
+	f := newFixture(t)
+	registerBob(f)
+	f.stores.Create(context.Background(), &domain.Store{ID: "store-b", StoreOwnerID: "owner-b", Name: "Bab"})
+
+	tests := []struct {
+		name   string
+		claims auth.Claims
+		want   int
+		count  int
+	}{
+		{"owner sees their own", alice, http.StatusOK, 1},
+		{"admin sees all", admin, http.StatusOK, 2},
+		{"not a store owner", auth.Claims{ID: "carol", Role: auth.RoleStoreOwner}, http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/stores", nil), tt.claims)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			var stores []domain.Store
+			decodeData(rec, &stores)
+			if len(stores) != tt.count {
+				t.Errorf("got %d stores, want %d", len(stores), tt.count)
+			}
+		})
+	}
+}
+
+func TestListUserStoreIDs(t *testing.T) {
+	f := newFixture(t)
+
+	for user, want := range map[string]int{"alice": 1, "nobody": 0} {
+		rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/internal/users/"+user+"/stores", nil), auth.Claims{})
+		var body struct {
+			StoreIDs []string `json:"store_ids"`
+		}
+		if err := decodeData(rec, &body); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, %v", user, rec.Code, err)
+		}
+		if body.StoreIDs == nil || len(body.StoreIDs) != want {
+			t.Errorf("%s: store_ids = %v, want %d", user, body.StoreIDs, want)
+		}
+	}
+}
+
+func registerBob(f *fixture) {
+	f.owners.Create(context.Background(), &domain.StoreOwner{ID: "owner-b", UserID: "bob", BusinessName: "Bob Tiles", Phone: "0611111111"})
+}
+
+func TestErrorsAreProblems(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.serve(t, formRequest(t, http.MethodPost, "/api/stores", map[string]string{"name": "Bab"}, ""), alice)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", rec.Code)
+	}
+	if problem := decodeProblem(t, rec); problem.Code != "store_exists" {
+		t.Errorf("code = %s, want store_exists", problem.Code)
+	}
+}
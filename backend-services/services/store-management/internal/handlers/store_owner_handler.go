@@ -4,118 +4,100 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"shared/auth"
+	"shared/response"
 	"store-management/internal/domain"
-	"store-management/internal/middleware"
+	services "store-management/internal/service/interfaces"
 
 	"github.com/gorilla/mux"
-	"gorm.io/gorm"
 )
 
 type StoreOwnerHandler struct {
-	db *gorm.DB
+	owners services.StoreOwnerService
 }
 
-func NewStoreOwnerHandler(db *gorm.DB) *StoreOwnerHandler {
-	return &StoreOwnerHandler{db: db}
+func NewStoreOwnerHandler(owners services.StoreOwnerService) *StoreOwnerHandler {
+	return &StoreOwnerHandler{owners: owners}
 }
 
 func (h *StoreOwnerHandler) CreateStoreOwner(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
+	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	// Check if user already has a store owner profile
-	var existingOwner domain.StoreOwner
-	result := h.db.Where("user_id = ?", claims.ID).First(&existingOwner)
-	if result.Error == nil {
-		http.Error(w, "User already has a store owner profile", http.StatusConflict)
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	var storeOwner domain.StoreOwner
 	if err := json.NewDecoder(r.Body).Decode(&storeOwner); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	// Set the user_id from the token
-	storeOwner.UserID = claims.ID
-
-	result = h.db.Create(&storeOwner)
-	if result.Error != nil {
-		http.Error(w, "Failed to create store owner", http.StatusInternalServerError)
+	storeOwner, err := h.owners.Create(r.Context(), claims, storeOwner)
+	if err != nil {
+		writeServiceError(w, r, err, "Store owner not found", "Failed to create store owner")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(storeOwner)
+	response.JSON(w, http.StatusCreated, storeOwner)
 }
 
 func (h *StoreOwnerHandler) GetStoreOwner(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
+	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var storeOwner domain.StoreOwner
-	result := h.db.First(&storeOwner, "id = ?", id)
-	if result.Error != nil {
-		http.Error(w, "Store owner not found", http.StatusNotFound)
+	storeOwner, err := h.owners.Get(r.Context(), claims, id)
+	if err != nil {
+		writeServiceError(w, r, err, "Store owner not found", "Failed to fetch store owner")
 		return
 	}
 
-	// Check if user is admin or the owner
-	if claims.Role != "admin" && storeOwner.UserID != claims.ID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(storeOwner)
+	response.JSON(w, http.StatusOK, storeOwner)
 }
 
 func (h *StoreOwnerHandler) UpdateStoreOwner(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var storeOwner domain.StoreOwner
-	if err := json.NewDecoder(r.Body).Decode(&storeOwner); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var changes domain.StoreOwner
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	result := h.db.Model(&domain.StoreOwner{}).Where("id = ?", id).Updates(storeOwner)
-	if result.Error != nil {
-		http.Error(w, "Failed to update store owner", http.StatusInternalServerError)
-		return
-	}
-	if result.RowsAffected == 0 {
-		http.Error(w, "Store owner not found", http.StatusNotFound)
+	storeOwner, err := h.owners.Update(r.Context(), claims, id, changes)
+	if err != nil {
+		writeServiceError(w, r, err, "Store owner not found", "Failed to update store owner")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(storeOwner)
+	response.JSON(w, http.StatusOK, storeOwner)
 }
 
 func (h *StoreOwnerHandler) DeleteStoreOwner(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	result := h.db.Delete(&domain.StoreOwner{}, "id = ?", id)
-	if result.Error != nil {
-		http.Error(w, "Failed to delete store owner", http.StatusInternalServerError)
-		return
-	}
-	if result.RowsAffected == 0 {
-		http.Error(w, "Store owner not found", http.StatusNotFound)
+	if err := h.owners.Delete(r.Context(), claims, id); err != nil {
+		writeServiceError(w, r, err, "Store owner not found", "Failed to delete store owner")
 		return
 	}
 
@@ -123,28 +105,17 @@ func (h *StoreOwnerHandler) DeleteStoreOwner(w http.ResponseWriter, r *http.Requ
 }
 
 func (h *StoreOwnerHandler) ListStoreOwners(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value("claims").(middleware.Claims)
+	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	var storeOwners []domain.StoreOwner
-	var result *gorm.DB
-
-	if claims.Role == "admin" {
-		// Admin can see all store owners
-		result = h.db.Find(&storeOwners)
-	} else {
-		// Regular users can only see their own store owner profile
-		result = h.db.Where("user_id = ?", claims.ID).Find(&storeOwners)
-	}
-
-	if result.Error != nil {
-		http.Error(w, "Failed to fetch store owners", http.StatusInternalServerError)
+	storeOwners, err := h.owners.List(r.Context(), claims)
+	if err != nil {
+		writeServiceError(w, r, err, "Store owner not found", "Failed to fetch store owners")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(storeOwners)
+	response.JSON(w, http.StatusOK, storeOwners)
 }
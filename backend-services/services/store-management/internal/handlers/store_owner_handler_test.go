@@ -0,0 +1,86 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"store-management/internal/domain"
+	"strings"
+	"testing"
+)
+
+func TestCreateStoreOwner(t *testing.T) {
+	f := newFixture(t)
+	body := `{"ID":"chosen","UserID":"alice","BusinessName":"Bob Tiles","Phone":"0611111111"}`
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodPost, "/api/store-owners", strings.NewReader(body)), bob)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var owner domain.StoreOwner
+	decodeData(rec, &owner)
+	if owner.UserID != "bob" || owner.ID == "chosen" {
+		t.Errorf("created %+v, want a new profile for bob", owner)
+	}
+
+	rec = f.serve(t, httptest.NewRequest(http.MethodPost, "/api/store-owners", strings.NewReader(body)), bob)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("second profile: status = %d, want 409", rec.Code)
+	}
+
+	rec = f.serve(t, httptest.NewRequest(http.MethodPost, "/api/store-owners", strings.NewReader("{")), auth.Claims{ID: "carol", Role: auth.RoleCustomer})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid body: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestUpdateStoreOwnerKeepsOwnership(t *testing.T) {
+	f := newFixture(t)
+	body := `{"ID":"owner-z","UserID":"bob","Phone":"0622222222"}`
+
+	rec := f.serve(t, httptest.NewRequest(http.MethodPut, "/api/store-owners/owner-a", strings.NewReader(body)), alice)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	owner, _ := f.owners.FindByID(context.Background(), "owner-a")
+	if owner.UserID != "alice" || owner.Phone != "0622222222" || owner.BusinessName != "Alice Crafts" {
+		t.Errorf("owner after update = %+v", owner)
+	}
+}
+
+func TestDeleteStoreOwner(t *testing.T) {
+	f := newFixture(t)
+	del := func() int {
+		return f.serve(t, httptest.NewRequest(http.MethodDelete, "/api/store-owners/owner-a", nil), alice).Code
+	}
+
+	if code := del(); code != http.StatusConflict {
+		t.Fatalf("with a store: status = %d, want 409", code)
+	}
+	f.stores.Delete(context.Background(), "store-a")
+	if code := del(); code != http.StatusNoContent {
+		t.Fatalf("without stores: status = %d, want 204", code)
+	}
+	if code := del(); code != http.StatusNotFound {
+		t.Errorf("deleted twice: status = %d, want 404", code)
+	}
+}
+
+func TestListStoreOwners(t *testing.T) {
+	f := newFixture(t)
+	registerBob(f)
+
+	for _, tt := range []struct {
+		claims auth.Claims
+		want   int
+	}{{alice, 1}, {admin, 2}, {auth.Claims{ID: "carol", Role: auth.RoleCustomer}, 0}} {
+		rec := f.serve(t, httptest.NewRequest(http.MethodGet, "/api/store-owners", nil), tt.claims)
+		var owners []domain.StoreOwner
+		decodeData(rec, &owners)
+		if rec.Code != http.StatusOK || len(owners) != tt.want {
+			t.Errorf("%s: status %d with %d owners, want %d", tt.claims.ID, rec.Code, len(owners), tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// Package metrics exposes the service's business metrics. The HTTP and
+// database metrics come from shared/metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// StoresCreated counts successful creations.
+var StoresCreated = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "stores_created_total",
+	Help: "Stores created.",
+})
@@ -1,20 +0,0 @@
-package middleware
-
-import (
-    "log"
-    "net/http"
-)
-
-func LoggingMiddleware(next http.Handler) http.Handler {
-    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-        log.Printf("%s %s", r.Method, r.RequestURI)
-        next.ServeHTTP(w, r)
-    })
-}
-
-func AuthMiddleware(next http.Handler) http.Handler {
-    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-        // Auth logic will be implemented here
-        next.ServeHTTP(w, r)
-    })
-}
\ No newline at end of file
@@ -0,0 +1,83 @@
+// Package policy decides who may change stores and store owner profiles.
+// Handlers load records through it before any mutation.
+package policy
+
+import (
+	"context"
+	"shared/auth"
+	"store-management/internal/domain"
+	"store-management/internal/repository/interfaces"
+)
+
+var (
+	ErrNotFound  = domain.ErrNotFound
+	ErrForbidden = domain.ErrForbidden
+)
+
+// Loader fetches the records policies are checked against. Store must
+// populate Store.StoreOwner.
+type Loader interface {
+	Store(ctx context.Context, id string) (domain.Store, error)
+	StoreOwner(ctx context.Context, id string) (domain.StoreOwner, error)
+}
+
+// Policy checks the caller owns a record, or holds the permission to manage
+// everyone's.
+type Policy struct {
+	loader Loader
+}
+
+func New(loader Loader) *Policy {
+	return &Policy{loader: loader}
+}
+
+// NewFromRepositories returns a Policy loading records from the
+// repositories.
+func NewFromRepositories(stores interfaces.StoreRepository, owners interfaces.StoreOwnerRepository) *Policy {
+	return New(repositoryLoader{stores: stores, owners: owners})
+}
+
+// Store returns the store if the caller may change it. It returns
+// ErrNotFound or ErrForbidden otherwise.
+func (p *Policy) Store(ctx context.Context, claims auth.Claims, id string) (domain.Store, error) {
+	store, err := p.loader.Store(ctx, id)
+	if err != nil {
+		return domain.Store{}, err
+	}
+	if claims.Can(auth.PermStoreManageAny) {
+		return store, nil
+	}
+	if !claims.Can(auth.PermStoreWrite) || store.StoreOwner.UserID != claims.ID {
+		return domain.Store{}, ErrForbidden
+	}
+	return store, nil
+}
+
+// StoreOwner returns the store owner profile if it is the caller's. It
+// returns ErrNotFound or ErrForbidden otherwise.
+func (p *Policy) StoreOwner(ctx context.Context, claims auth.Claims, id string) (domain.StoreOwner, error) {
+	owner, err := p.loader.StoreOwner(ctx, id)
+	if err != nil {
+		return domain.StoreOwner{}, err
+	}
+	if claims.Can(auth.PermStoreOwnerManageAny) {
+		return owner, nil
+	}
+	if owner.UserID != claims.ID {
+		return domain.StoreOwner{}, ErrForbidden
+	}
+	return owner, nil
+}
+
+type repositoryLoader struct {
+	stores interfaces.StoreRepository
+	owners interfaces.StoreOwnerRepository
+}
+
+func (l repositoryLoader) Store(ctx context.Context, id string) (domain.Store, error) {
+	return l.stores.FindByID(ctx, id)
+}
+
+func (l repositoryLoader) StoreOwner(ctx context.Context, id string) (domain.StoreOwner, error) {
+	return l.owners.FindByID(ctx, id)
+}
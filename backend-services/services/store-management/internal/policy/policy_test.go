@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"shared/auth"
+	"store-management/internal/domain"
+	"testing"
+)
+
+type fakeLoader struct {
+	stores map[string]domain.Store
+	owners map[string]domain.StoreOwner
+}
+
+func (l fakeLoader) Store(ctx context.Context, id string) (domain.Store, error) {
+	store, ok := l.stores[id]
+	if !ok {
+		return domain.Store{}, ErrNotFound
+	}
+	store.StoreOwner = l.owners[store.StoreOwnerID]
+	return store, nil
+}
+
+func (l fakeLoader) StoreOwner(ctx context.Context, id string) (domain.StoreOwner, error) {
+	owner, ok := l.owners[id]
+	if !ok {
+		return domain.StoreOwner{}, ErrNotFound
+	}
+	return owner, nil
+}
+
+// Two tenants: alice owns store-a through owner-a, bob owns store-b.
+var tenants = fakeLoader{
+	owners: map[string]domain.StoreOwner{
+		"owner-a": {ID: "owner-a", UserID: "alice"},
+		"owner-b": {ID: "owner-b", UserID: "bob"},
+	},
+	stores: map[string]domain.Store{
+		"store-a": {ID: "store-a", StoreOwnerID: "owner-a"},
+		"store-b": {ID: "store-b", StoreOwnerID: "owner-b"},
+	},
+}
+
+var (
+	alice = auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+	bob   = auth.Claims{ID: "bob", Role: auth.RoleStoreOwner}
+	admin = auth.Claims{ID: "root", Role: auth.RoleAdmin}
+)
+
+func TestStorePolicy(t *testing.T) {
+	p := New(tenants)
+
+	tests := []struct {
+		name    string
+		claims  auth.Claims
+		storeID string
+		want    error
+	}{
+		{"owner", alice, "store-a", nil},
+		{"other tenant", bob, "store-a", ErrForbidden},
+		{"other tenant reversed", alice, "store-b", ErrForbidden},
+		{"staff of the store owner's user id", auth.Claims{ID: "alice", Role: auth.RoleStoreStaff}, "store-a", ErrForbidden},
+		{"customer with owner's id", auth.Claims{ID: "alice", Role: auth.RoleCustomer}, "store-a", ErrForbidden},
+		{"admin", admin, "store-b", nil},
+		{"missing store", alice, "store-x", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := p.Store(context.Background(), tt.claims, tt.storeID)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Store error = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && store.ID != tt.storeID {
+				t.Errorf("Store = %+v", store)
+			}
+			if tt.want != nil && store.ID != "" {
+				t.Errorf("Store returned %q alongside an error", store.ID)
+			}
+		})
+	}
+}
+
+func TestStoreOwnerPolicy(t *testing.T) {
+	p := New(tenants)
+
+	tests := []struct {
+		name    string
+		claims  auth.Claims
+		ownerID string
+		want    error
+	}{
+		{"own profile", alice, "owner-a", nil},
+		{"other tenant", bob, "owner-a", ErrForbidden},
+		{"customer", auth.Claims{ID: "carol", Role: auth.RoleCustomer}, "owner-b", ErrForbidden},
+		{"admin", admin, "owner-a", nil},
+		{"missing profile", bob, "owner-x", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.StoreOwner(context.Background(), tt.claims, tt.ownerID)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("StoreOwner error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+// Package interfaces declares the storage the store-management services
+// depend on. Lookups return domain.ErrNotFound when nothing matches.
+package interfaces
+
+import (
+	"context"
+	"store-management/internal/domain"
+)
+
+// StoreOwnerRepository stores store owner profiles.
+type StoreOwnerRepository interface {
+	Create(ctx context.Context, owner *domain.StoreOwner) error
+	FindByID(ctx context.Context, id string) (domain.StoreOwner, error)
+	FindByUserID(ctx context.Context, userID string) (domain.StoreOwner, error)
+	List(ctx context.Context) ([]domain.StoreOwner, error)
+	Update(ctx context.Context, owner *domain.StoreOwner) error
+	Delete(ctx context.Context, id string) error
+}
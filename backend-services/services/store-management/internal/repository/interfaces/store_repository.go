@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+	"store-management/internal/domain"
+)
+
+// StoreRepository stores stores.
+type StoreRepository interface {
+	Create(ctx context.Context, store *domain.Store) error
+	// FindByID populates the store's StoreOwner.
+	FindByID(ctx context.Context, id string) (domain.Store, error)
+	FindByOwnerID(ctx context.Context, ownerID string) ([]domain.Store, error)
+	List(ctx context.Context) ([]domain.Store, error)
+	// IDsByUserID returns the ids of the stores owned by a user.
+	IDsByUserID(ctx context.Context, userID string) ([]string, error)
+	// Update saves the store's own fields, leaving its owner alone.
+	Update(ctx context.Context, store *domain.Store) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,98 @@
+// Package memory implements the repositories in memory, for tests and
+// local runs without a database.
+package memory
+
+import (
+	"context"
+	"sort"
+	"store-management/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StoreOwnerRepository struct {
+	mu     sync.RWMutex
+	owners map[string]domain.StoreOwner
+}
+
+func NewStoreOwnerRepository() *StoreOwnerRepository {
+	return &StoreOwnerRepository{owners: make(map[string]domain.StoreOwner)}
+}
+
+func (r *StoreOwnerRepository) Create(ctx context.Context, owner *domain.StoreOwner) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.owners {
+		if existing.UserID == owner.UserID {
+			return domain.ErrOwnerExists
+		}
+	}
+	if owner.ID == "" {
+		owner.ID = uuid.NewString()
+	}
+	owner.CreatedAt = time.Now()
+	owner.UpdatedAt = owner.CreatedAt
+	r.owners[owner.ID] = *owner
+	return nil
+}
+
+func (r *StoreOwnerRepository) FindByID(ctx context.Context, id string) (domain.StoreOwner, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owner, ok := r.owners[id]
+	if !ok {
+		return domain.StoreOwner{}, domain.ErrNotFound
+	}
+	return owner, nil
+}
+
+func (r *StoreOwnerRepository) FindByUserID(ctx context.Context, userID string) (domain.StoreOwner, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, owner := range r.owners {
+		if owner.UserID == userID {
+			return owner, nil
+		}
+	}
+	return domain.StoreOwner{}, domain.ErrNotFound
+}
+
+func (r *StoreOwnerRepository) List(ctx context.Context) ([]domain.StoreOwner, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owners := make([]domain.StoreOwner, 0, len(r.owners))
+	for _, owner := range r.owners {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool { return owners[i].CreatedAt.Before(owners[j].CreatedAt) })
+	return owners, nil
+}
+
+func (r *StoreOwnerRepository) Update(ctx context.Context, owner *domain.StoreOwner) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.owners[owner.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	owner.UpdatedAt = time.Now()
+	r.owners[owner.ID] = *owner
+	return nil
+}
+
+func (r *StoreOwnerRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.owners[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.owners, id)
+	return nil
+}
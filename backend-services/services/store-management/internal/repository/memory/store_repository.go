@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"store-management/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StoreRepository struct {
+	owners *StoreOwnerRepository
+
+	mu     sync.RWMutex
+	stores map[string]domain.Store
+}
+
+// NewStoreRepository returns a StoreRepository whose stores belong to
+// owners in owners.
+func NewStoreRepository(owners *StoreOwnerRepository) *StoreRepository {
+	return &StoreRepository{owners: owners, stores: make(map[string]domain.Store)}
+}
+
+func (r *StoreRepository) Create(ctx context.Context, store *domain.Store) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// As the unique index on stores.store_owner_id does
+	for _, existing := range r.stores {
+		if existing.StoreOwnerID == store.StoreOwnerID {
+			return domain.ErrStoreExists
+		}
+	}
+	if store.ID == "" {
+		store.ID = uuid.NewString()
+	}
+	store.CreatedAt = time.Now()
+	store.UpdatedAt = store.CreatedAt
+	r.stores[store.ID] = withoutOwner(*store)
+	return nil
+}
+
+func (r *StoreRepository) FindByID(ctx context.Context, id string) (domain.Store, error) {
+	r.mu.RLock()
+	store, ok := r.stores[id]
+	r.mu.RUnlock()
+	if !ok {
+		return domain.Store{}, domain.ErrNotFound
+	}
+
+	owner, err := r.owners.FindByID(ctx, store.StoreOwnerID)
+	if err != nil {
+		return domain.Store{}, err
+	}
+	store.StoreOwner = owner
+	return store, nil
+}
+
+func (r *StoreRepository) FindByOwnerID(ctx context.Context, ownerID string) ([]domain.Store, error) {
+	return r.filter(func(s domain.Store) bool { return s.StoreOwnerID == ownerID }), nil
+}
+
+func (r *StoreRepository) List(ctx context.Context) ([]domain.Store, error) {
+	return r.filter(func(domain.Store) bool { return true }), nil
+}
+
+func (r *StoreRepository) IDsByUserID(ctx context.Context, userID string) ([]string, error) {
+	ids := []string{}
+	owner, err := r.owners.FindByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return ids, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, store := range r.filter(func(s domain.Store) bool { return s.StoreOwnerID == owner.ID }) {
+		ids = append(ids, store.ID)
+	}
+	return ids, nil
+}
+
+func (r *StoreRepository) Update(ctx context.Context, store *domain.Store) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.stores[store.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	store.UpdatedAt = time.Now()
+	r.stores[store.ID] = withoutOwner(*store)
+	return nil
+}
+
+func (r *StoreRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.stores[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.stores, id)
+	return nil
+}
+
+func (r *StoreRepository) filter(keep func(domain.Store) bool) []domain.Store {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stores := []domain.Store{}
+	for _, store := range r.stores {
+		if keep(store) {
+			stores = append(stores, store)
+		}
+	}
+	sort.Slice(stores, func(i, j int) bool { return stores[i].CreatedAt.Before(stores[j].CreatedAt) })
+	return stores
+}
+
+// withoutOwner drops the loaded owner, which is stored separately, as the
+// database does.
+func withoutOwner(store domain.Store) domain.Store {
+	store.StoreOwner = domain.StoreOwner{}
+	return store
+}
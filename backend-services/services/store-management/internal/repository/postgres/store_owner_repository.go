@@ -0,0 +1,66 @@
+// Package postgres implements the repositories with GORM.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"store-management/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type StoreOwnerRepository struct {
+	db *gorm.DB
+}
+
+func NewStoreOwnerRepository(db *gorm.DB) *StoreOwnerRepository {
+	return &StoreOwnerRepository{db: db}
+}
+
+func (r *StoreOwnerRepository) Create(ctx context.Context, owner *domain.StoreOwner) error {
+	return r.db.WithContext(ctx).Create(owner).Error
+}
+
+func (r *StoreOwnerRepository) FindByID(ctx context.Context, id string) (domain.StoreOwner, error) {
+	var owner domain.StoreOwner
+	err := r.db.WithContext(ctx).First(&owner, "id = ?", id).Error
+	return owner, notFound(err)
+}
+
+func (r *StoreOwnerRepository) FindByUserID(ctx context.Context, userID string) (domain.StoreOwner, error) {
+	var owner domain.StoreOwner
+	err := r.db.WithContext(ctx).First(&owner, "user_id = ?", userID).Error
+	return owner, notFound(err)
+}
+
+func (r *StoreOwnerRepository) List(ctx context.Context) ([]domain.StoreOwner, error) {
+	var owners []domain.StoreOwner
+	err := r.db.WithContext(ctx).Find(&owners).Error
+	return owners, err
+}
+
+func (r *StoreOwnerRepository) Update(ctx context.Context, owner *domain.StoreOwner) error {
+	return r.db.WithContext(ctx).Save(owner).Error
+}
+
+func (r *StoreOwnerRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&domain.StoreOwner{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// uniqueViolation is PostgreSQL's error code for a duplicate key.
+const uniqueViolation = "23505"
+
+// notFound translates GORM's missing record error into the domain's.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
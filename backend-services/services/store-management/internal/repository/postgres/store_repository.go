@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"store-management/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StoreRepository struct {
+	db *gorm.DB
+}
+
+func NewStoreRepository(db *gorm.DB) *StoreRepository {
+	return &StoreRepository{db: db}
+}
+
+// Create saves store, returning domain.ErrStoreExists if its owner already
+// has one.
+func (r *StoreRepository) Create(ctx context.Context, store *domain.Store) error {
+	err := r.db.WithContext(ctx).Omit(clause.Associations).Create(store).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == "idx_stores_store_owner_id" {
+		return domain.ErrStoreExists
+	}
+	return err
+}
+
+func (r *StoreRepository) FindByID(ctx context.Context, id string) (domain.Store, error) {
+	var store domain.Store
+	err := r.db.WithContext(ctx).Preload("StoreOwner").First(&store, "id = ?", id).Error
+	return store, notFound(err)
+}
+
+func (r *StoreRepository) FindByOwnerID(ctx context.Context, ownerID string) ([]domain.Store, error) {
+	var stores []domain.Store
+	err := r.db.WithContext(ctx).Where("store_owner_id = ?", ownerID).Find(&stores).Error
+	return stores, err
+}
+
+func (r *StoreRepository) List(ctx context.Context) ([]domain.Store, error) {
+	var stores []domain.Store
+	err := r.db.WithContext(ctx).Find(&stores).Error
+	return stores, err
+}
+
+func (r *StoreRepository) IDsByUserID(ctx context.Context, userID string) ([]string, error) {
+	ids := []string{}
+	err := r.db.WithContext(ctx).Model(&domain.Store{}).
+		Joins("JOIN store_owners ON store_owners.id = stores.store_owner_id").
+		Where("store_owners.user_id = ?", userID).
+		Pluck("stores.id", &ids).Error
+	return ids, err
+}
+
+func (r *StoreRepository) Update(ctx context.Context, store *domain.Store) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(store).Error
+}
+
+func (r *StoreRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&domain.Store{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
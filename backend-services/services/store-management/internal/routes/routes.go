@@ -2,35 +2,63 @@ package routes
 
 import (
 	"log"
+	"shared/auth"
 	"store-management/internal/handlers"
-	"store-management/internal/middleware"
+	"store-management/internal/policy"
+	"store-management/internal/repository/postgres"
+	"store-management/internal/service/impl"
+	"store-management/internal/utils"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
 
+// Handlers are the handlers served by the store management service.
+type Handlers struct {
+	StoreOwner *handlers.StoreOwnerHandler
+	Store      *handlers.StoreHandler
+}
+
 func SetupRoutes(r *mux.Router, db *gorm.DB) {
-	storeOwnerHandler := handlers.NewStoreOwnerHandler(db)
-	storeHandler, err := handlers.NewStoreHandler(db)
+	cloudinary, err := utils.NewCloudinaryService()
 	if err != nil {
-		log.Fatalf("Failed to initialize store handler: %v", err)
+		log.Fatalf("Failed to initialize Cloudinary: %v", err)
 	}
-	authMiddleware, err := middleware.NewAuthMiddleware()
+	owners := postgres.NewStoreOwnerRepository(db)
+	stores := postgres.NewStoreRepository(db)
+	p := policy.NewFromRepositories(stores, owners)
+	ownerService := impl.NewStoreOwnerService(owners, stores, p)
+
+	authMiddleware, err := auth.NewMiddleware()
 	if err != nil {
 		log.Fatalf("Failed to initialize auth middleware: %v", err)
 	}
+	// Users with a store owner profile act as store owners
+	authMiddleware.PromoteStoreOwners(ownerService)
 
+	Register(r, authMiddleware, Handlers{
+		StoreOwner: handlers.NewStoreOwnerHandler(ownerService),
+		Store:      handlers.NewStoreHandler(impl.NewStoreService(stores, owners, cloudinary, p)),
+	})
+}
+
+// Register adds the routes served by h, guarded by authMiddleware.
+func Register(r *mux.Router, authMiddleware *auth.Middleware, h Handlers) {
 	// Store Owner routes
-	r.HandleFunc("/api/store-owners", authMiddleware.ValidateToken(storeOwnerHandler.CreateStoreOwner)).Methods("POST")
-	r.HandleFunc("/api/store-owners", authMiddleware.ValidateToken(storeOwnerHandler.ListStoreOwners)).Methods("GET")
-	r.HandleFunc("/api/store-owners/{id}", authMiddleware.ValidateToken(storeOwnerHandler.GetStoreOwner)).Methods("GET")
-	r.HandleFunc("/api/store-owners/{id}", authMiddleware.ValidateToken(storeOwnerHandler.UpdateStoreOwner)).Methods("PUT")
-	r.HandleFunc("/api/store-owners/{id}", authMiddleware.ValidateToken(storeOwnerHandler.DeleteStoreOwner)).Methods("DELETE")
+	r.HandleFunc("/api/store-owners", authMiddleware.Require(auth.PermStoreOwnerManage, h.StoreOwner.CreateStoreOwner)).Methods("POST")
+	r.HandleFunc("/api/store-owners", authMiddleware.Require(auth.PermStoreOwnerManage, h.StoreOwner.ListStoreOwners)).Methods("GET")
+	r.HandleFunc("/api/store-owners/{id}", authMiddleware.Require(auth.PermStoreOwnerManage, h.StoreOwner.GetStoreOwner)).Methods("GET")
+	r.HandleFunc("/api/store-owners/{id}", authMiddleware.Require(auth.PermStoreOwnerManage, h.StoreOwner.UpdateStoreOwner)).Methods("PUT")
+	r.HandleFunc("/api/store-owners/{id}", authMiddleware.Require(auth.PermStoreOwnerManage, h.StoreOwner.DeleteStoreOwner)).Methods("DELETE")
 
 	// Store routes
-	r.HandleFunc("/api/stores", authMiddleware.ValidateToken(storeHandler.CreateStore)).Methods("POST")
-	r.HandleFunc("/api/stores", authMiddleware.ValidateToken(storeHandler.ListStores)).Methods("GET")
-	r.HandleFunc("/api/stores/{id}", authMiddleware.ValidateToken(storeHandler.UpdateStore)).Methods("PUT")
-	r.HandleFunc("/api/stores/{id}", authMiddleware.ValidateToken(storeHandler.DeleteStore)).Methods("DELETE")
-	r.HandleFunc("/api/store-owners/{ownerID}/stores", authMiddleware.ValidateToken(storeHandler.GetStoresByOwner)).Methods("GET")
+	r.HandleFunc("/api/stores", authMiddleware.Require(auth.PermStoreWrite, h.Store.CreateStore)).Methods("POST")
+	r.HandleFunc("/api/stores", authMiddleware.Require(auth.PermStoreRead, h.Store.ListStores)).Methods("GET")
+	r.HandleFunc("/api/stores/{id}", authMiddleware.Require(auth.PermStoreWrite, h.Store.UpdateStore)).Methods("PUT")
+	r.HandleFunc("/api/stores/{id}", authMiddleware.Require(auth.PermStoreWrite, h.Store.DeleteStore)).Methods("DELETE")
+	// Any signed-in user may browse a seller's stores
+	r.HandleFunc("/api/store-owners/{ownerID}/stores", authMiddleware.ValidateToken(h.Store.GetStoresByOwner)).Methods("GET")
+
+	// Internal routes, called by other services only
+	r.HandleFunc("/internal/users/{userId}/stores", h.Store.ListUserStoreIDs).Methods("GET")
 }
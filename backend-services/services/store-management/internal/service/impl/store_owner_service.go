@@ -0,0 +1,105 @@
+// Package impl implements the store-management services on top of the
+// repositories.
+package impl
+
+import (
+	"context"
+	"errors"
+	"shared/auth"
+	"store-management/internal/domain"
+	"store-management/internal/policy"
+	repositories "store-management/internal/repository/interfaces"
+	services "store-management/internal/service/interfaces"
+)
+
+var _ services.StoreOwnerService = (*StoreOwnerService)(nil)
+
+type StoreOwnerService struct {
+	owners repositories.StoreOwnerRepository
+	stores repositories.StoreRepository
+	policy *policy.Policy
+}
+
+func NewStoreOwnerService(owners repositories.StoreOwnerRepository, stores repositories.StoreRepository, p *policy.Policy) *StoreOwnerService {
+	return &StoreOwnerService{owners: owners, stores: stores, policy: p}
+}
+
+func (s *StoreOwnerService) Create(ctx context.Context, claims auth.Claims, owner domain.StoreOwner) (domain.StoreOwner, error) {
+	_, err := s.owners.FindByUserID(ctx, claims.ID)
+	if err == nil {
+		return domain.StoreOwner{}, domain.ErrOwnerExists
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return domain.StoreOwner{}, err
+	}
+
+	// The profile always belongs to the caller
+	owner.ID = ""
+	owner.UserID = claims.ID
+	if err := s.owners.Create(ctx, &owner); err != nil {
+		return domain.StoreOwner{}, err
+	}
+	return owner, nil
+}
+
+func (s *StoreOwnerService) Get(ctx context.Context, claims auth.Claims, id string) (domain.StoreOwner, error) {
+	return s.policy.StoreOwner(ctx, claims, id)
+}
+
+func (s *StoreOwnerService) Update(ctx context.Context, claims auth.Claims, id string, changes domain.StoreOwner) (domain.StoreOwner, error) {
+	owner, err := s.policy.StoreOwner(ctx, claims, id)
+	if err != nil {
+		return domain.StoreOwner{}, err
+	}
+
+	// The profile cannot be handed to another user, so only its details
+	// change
+	if changes.BusinessName != "" {
+		owner.BusinessName = changes.BusinessName
+	}
+	if changes.Phone != "" {
+		owner.Phone = changes.Phone
+	}
+	if err := s.owners.Update(ctx, &owner); err != nil {
+		return domain.StoreOwner{}, err
+	}
+	return owner, nil
+}
+
+func (s *StoreOwnerService) Delete(ctx context.Context, claims auth.Claims, id string) error {
+	if _, err := s.policy.StoreOwner(ctx, claims, id); err != nil {
+		return err
+	}
+
+	stores, err := s.stores.FindByOwnerID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(stores) > 0 {
+		return domain.ErrOwnerHasStores
+	}
+	return s.owners.Delete(ctx, id)
+}
+
+func (s *StoreOwnerService) List(ctx context.Context, claims auth.Claims) ([]domain.StoreOwner, error) {
+	if claims.Can(auth.PermStoreOwnerManageAny) {
+		return s.owners.List(ctx)
+	}
+
+	owner, err := s.owners.FindByUserID(ctx, claims.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return []domain.StoreOwner{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []domain.StoreOwner{owner}, nil
+}
+
+func (s *StoreOwnerService) IsStoreOwner(ctx context.Context, userID string) (bool, error) {
+	_, err := s.owners.FindByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
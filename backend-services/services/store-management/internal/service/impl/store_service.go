@@ -0,0 +1,154 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"shared/auth"
+	"store-management/internal/domain"
+	"store-management/internal/policy"
+	repositories "store-management/internal/repository/interfaces"
+	services "store-management/internal/service/interfaces"
+)
+
+var _ services.StoreService = (*StoreService)(nil)
+
+type StoreService struct {
+	stores repositories.StoreRepository
+	owners repositories.StoreOwnerRepository
+	images services.ImageStore
+	policy *policy.Policy
+}
+
+func NewStoreService(stores repositories.StoreRepository, owners repositories.StoreOwnerRepository, images services.ImageStore, p *policy.Policy) *StoreService {
+	return &StoreService{stores: stores, owners: owners, images: images, policy: p}
+}
+
+func (s *StoreService) Create(ctx context.Context, claims auth.Claims, input services.StoreInput, logo io.Reader) (domain.Store, error) {
+	owner, err := s.owners.FindByUserID(ctx, claims.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Store{}, domain.ErrNotStoreOwner
+	}
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	existing, err := s.stores.FindByOwnerID(ctx, owner.ID)
+	if err != nil {
+		return domain.Store{}, err
+	}
+	if len(existing) > 0 {
+		return domain.Store{}, domain.ErrStoreExists
+	}
+
+	store := domain.Store{
+		StoreOwnerID: owner.ID,
+		Name:         input.Name,
+		Description:  input.Description,
+		Street:       input.Street,
+		City:         input.City,
+		State:        input.State,
+	}
+	// The store is created first, as its id names the logo
+	if err := s.stores.Create(ctx, &store); err != nil {
+		return domain.Store{}, err
+	}
+	if logo == nil {
+		return store, nil
+	}
+
+	logoURL, err := s.images.UploadImage(ctx, logo, logoID(store.ID))
+	if err != nil {
+		if err := s.stores.Delete(ctx, store.ID); err != nil {
+			slog.ErrorContext(ctx, "Failed to roll back store after logo upload failed", "store_id", store.ID, "error", err)
+		}
+		return domain.Store{}, fmt.Errorf("failed to upload logo: %w", err)
+	}
+	store.LogoURL = logoURL
+	if err := s.stores.Update(ctx, &store); err != nil {
+		return domain.Store{}, err
+	}
+	return store, nil
+}
+
+func (s *StoreService) Update(ctx context.Context, claims auth.Claims, id string, input services.StoreInput, logo io.Reader) (domain.Store, error) {
+	store, err := s.policy.Store(ctx, claims, id)
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	if input.Name != "" {
+		store.Name = input.Name
+	}
+	if input.Description != "" {
+		store.Description = input.Description
+	}
+	if input.Street != "" {
+		store.Street = input.Street
+	}
+	if input.City != "" {
+		store.City = input.City
+	}
+	if input.State != "" {
+		store.State = input.State
+	}
+
+	if logo != nil {
+		if store.LogoURL != "" {
+			if err := s.images.DeleteImage(ctx, logoID(store.ID)); err != nil {
+				slog.WarnContext(ctx, "Failed to delete old logo", "store_id", store.ID, "error", err)
+			}
+		}
+		logoURL, err := s.images.UploadImage(ctx, logo, logoID(store.ID))
+		if err != nil {
+			return domain.Store{}, fmt.Errorf("failed to upload logo: %w", err)
+		}
+		store.LogoURL = logoURL
+	}
+
+	if err := s.stores.Update(ctx, &store); err != nil {
+		return domain.Store{}, err
+	}
+	return store, nil
+}
+
+func (s *StoreService) Delete(ctx context.Context, claims auth.Claims, id string) error {
+	store, err := s.policy.Store(ctx, claims, id)
+	if err != nil {
+		return err
+	}
+
+	if store.LogoURL != "" {
+		if err := s.images.DeleteImage(ctx, logoID(store.ID)); err != nil {
+			slog.WarnContext(ctx, "Failed to delete logo", "store_id", store.ID, "error", err)
+		}
+	}
+	return s.stores.Delete(ctx, store.ID)
+}
+
+func (s *StoreService) List(ctx context.Context, claims auth.Claims) ([]domain.Store, error) {
+	if claims.Can(auth.PermStoreManageAny) {
+		return s.stores.List(ctx)
+	}
+
+	owner, err := s.owners.FindByUserID(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.stores.FindByOwnerID(ctx, owner.ID)
+}
+
+func (s *StoreService) ListByOwner(ctx context.Context, ownerID string) ([]domain.Store, error) {
+	return s.stores.FindByOwnerID(ctx, ownerID)
+}
+
+func (s *StoreService) UserStoreIDs(ctx context.Context, userID string) ([]string, error) {
+	return s.stores.IDsByUserID(ctx, userID)
+}
+
+// logoID is the logo's public id in the image store.
+func logoID(storeID string) string {
+	return fmt.Sprintf("store-%s-logo", storeID)
+}
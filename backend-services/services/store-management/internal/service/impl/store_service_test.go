@@ -0,0 +1,151 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"shared/auth"
+	"store-management/internal/domain"
+	"store-management/internal/policy"
+	"store-management/internal/repository/memory"
+	services "store-management/internal/service/interfaces"
+	"strings"
+	"testing"
+)
+
+type fakeImages struct {
+	fail     bool
+	uploaded []string
+	deleted  []string
+}
+
+func (f *fakeImages) UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error) {
+	if f.fail {
+		return "", errors.New("upload failed")
+	}
+	f.uploaded = append(f.uploaded, publicID)
+	return "https://images.test/" + publicID, nil
+}
+
+func (f *fakeImages) DeleteImage(ctx context.Context, publicID string) error {
+	f.deleted = append(f.deleted, publicID)
+	return nil
+}
+
+func newServices(t *testing.T) (*StoreService, *StoreOwnerService, *memory.StoreRepository, *fakeImages) {
+	t.Helper()
+	owners := memory.NewStoreOwnerRepository()
+	stores := memory.NewStoreRepository(owners)
+	images := &fakeImages{}
+	p := policy.NewFromRepositories(stores, owners)
+	return NewStoreService(stores, owners, images, p), NewStoreOwnerService(owners, stores, p), stores, images
+}
+
+func TestStoreLifecycle(t *testing.T) {
+	ctx := context.Background()
+	storeService, ownerService, _, images := newServices(t)
+	alice := auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+
+	if _, err := storeService.Create(ctx, alice, services.StoreInput{Name: "Atlas"}, nil); !errors.Is(err, domain.ErrNotStoreOwner) {
+		t.Fatalf("Create before registering = %v, want ErrNotStoreOwner", err)
+	}
+
+	owner, err := ownerService.Create(ctx, alice, domain.StoreOwner{UserID: "mallory", BusinessName: "Alice Crafts"})
+	if err != nil || owner.UserID != "alice" {
+		t.Fatalf("register = %+v, %v", owner, err)
+	}
+	if _, err := ownerService.Create(ctx, alice, domain.StoreOwner{BusinessName: "Again"}); !errors.Is(err, domain.ErrOwnerExists) {
+		t.Fatalf("second register = %v, want ErrOwnerExists", err)
+	}
+
+	store, err := storeService.Create(ctx, alice, services.StoreInput{Name: "Atlas", City: "Fes"}, strings.NewReader("png"))
+	if err != nil || store.StoreOwnerID != owner.ID || store.LogoURL == "" {
+		t.Fatalf("Create = %+v, %v", store, err)
+	}
+	if _, err := storeService.Create(ctx, alice, services.StoreInput{Name: "Second"}, nil); !errors.Is(err, domain.ErrStoreExists) {
+		t.Fatalf("second store = %v, want ErrStoreExists", err)
+	}
+
+	store, err = storeService.Update(ctx, alice, store.ID, services.StoreInput{Name: "Atlas Souk"}, strings.NewReader("jpg"))
+	if err != nil || store.Name != "Atlas Souk" || store.City != "Fes" {
+		t.Fatalf("Update = %+v, %v", store, err)
+	}
+	if len(images.deleted) != 1 || len(images.uploaded) != 2 {
+		t.Errorf("logo replaced with %d deletes and %d uploads, want 1 and 2", len(images.deleted), len(images.uploaded))
+	}
+
+	if err := ownerService.Delete(ctx, alice, owner.ID); !errors.Is(err, domain.ErrOwnerHasStores) {
+		t.Fatalf("delete owner with a store = %v, want ErrOwnerHasStores", err)
+	}
+	if err := storeService.Delete(ctx, alice, store.ID); err != nil {
+		t.Fatalf("Delete store: %v", err)
+	}
+	if err := ownerService.Delete(ctx, alice, owner.ID); err != nil {
+		t.Fatalf("Delete owner: %v", err)
+	}
+}
+
+func TestCreateStoreRollsBackWhenLogoUploadFails(t *testing.T) {
+	ctx := context.Background()
+	storeService, ownerService, stores, images := newServices(t)
+	alice := auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+	owner, _ := ownerService.Create(ctx, alice, domain.StoreOwner{BusinessName: "Alice Crafts"})
+	images.fail = true
+
+	if _, err := storeService.Create(ctx, alice, services.StoreInput{Name: "Atlas"}, strings.NewReader("png")); err == nil {
+		t.Fatal("Create succeeded despite the failed upload")
+	}
+	if left, _ := stores.FindByOwnerID(ctx, owner.ID); len(left) != 0 {
+		t.Errorf("failed create left %d stores", len(left))
+	}
+}
+
+func TestConcurrentCreatesMakeOneStore(t *testing.T) {
+	ctx := context.Background()
+	storeService, ownerService, stores, _ := newServices(t)
+	alice := auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+	owner, _ := ownerService.Create(ctx, alice, domain.StoreOwner{BusinessName: "Alice Crafts"})
+
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := storeService.Create(ctx, alice, services.StoreInput{Name: "Atlas"}, nil)
+			errs <- err
+		}()
+	}
+	created := 0
+	for i := 0; i < cap(errs); i++ {
+		err := <-errs
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, domain.ErrStoreExists):
+			t.Errorf("Create = %v, want %v", err, domain.ErrStoreExists)
+		}
+	}
+	if left, _ := stores.FindByOwnerID(ctx, owner.ID); created != 1 || len(left) != 1 {
+		t.Errorf("created %d stores, owner has %d; want 1", created, len(left))
+	}
+}
+
+func TestStoreChangesAreOwnerOnly(t *testing.T) {
+	ctx := context.Background()
+	storeService, ownerService, _, _ := newServices(t)
+	alice := auth.Claims{ID: "alice", Role: auth.RoleStoreOwner}
+	bob := auth.Claims{ID: "bob", Role: auth.RoleStoreOwner}
+	ownerService.Create(ctx, alice, domain.StoreOwner{BusinessName: "Alice Crafts"})
+	store, _ := storeService.Create(ctx, alice, services.StoreInput{Name: "Atlas"}, nil)
+
+	if _, err := storeService.Update(ctx, bob, store.ID, services.StoreInput{Name: "Mine"}, nil); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Update by bob = %v, want ErrForbidden", err)
+	}
+	if err := storeService.Delete(ctx, bob, store.ID); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Delete by bob = %v, want ErrForbidden", err)
+	}
+	if ids, _ := storeService.UserStoreIDs(ctx, "bob"); len(ids) != 0 {
+		t.Errorf("bob's stores = %v", ids)
+	}
+	if ids, _ := storeService.UserStoreIDs(ctx, "alice"); len(ids) != 1 || ids[0] != store.ID {
+		t.Errorf("alice's stores = %v", ids)
+	}
+}
@@ -0,0 +1,25 @@
+// Package interfaces declares the store-management business operations
+// used by the handlers. Operations on a record the caller may not touch
+// return domain.ErrForbidden.
+package interfaces
+
+import (
+	"context"
+	"shared/auth"
+	"store-management/internal/domain"
+)
+
+// StoreOwnerService manages store owner profiles. A user has at most one.
+type StoreOwnerService interface {
+	// Create registers a profile for the caller.
+	Create(ctx context.Context, claims auth.Claims, owner domain.StoreOwner) (domain.StoreOwner, error)
+	Get(ctx context.Context, claims auth.Claims, id string) (domain.StoreOwner, error)
+	// Update applies the non-empty fields of changes.
+	Update(ctx context.Context, claims auth.Claims, id string, changes domain.StoreOwner) (domain.StoreOwner, error)
+	// Delete removes a profile that no longer has stores.
+	Delete(ctx context.Context, claims auth.Claims, id string) error
+	// List returns every profile for admins and the caller's own otherwise.
+	List(ctx context.Context, claims auth.Claims) ([]domain.StoreOwner, error)
+	// IsStoreOwner reports whether the user has registered a profile.
+	IsStoreOwner(ctx context.Context, userID string) (bool, error)
+}
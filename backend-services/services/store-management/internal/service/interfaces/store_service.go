@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+	"shared/auth"
+	"store-management/internal/domain"
+)
+
+// StoreInput holds the store fields set by callers. Empty fields are left
+// unchanged on update.
+type StoreInput struct {
+	Name        string
+	Description string
+	Street      string
+	City        string
+	State       string
+}
+
+// StoreService manages stores. A store owner has at most one store.
+type StoreService interface {
+	// Create opens the caller's store, uploading logo if it is not nil.
+	Create(ctx context.Context, claims auth.Claims, input StoreInput, logo io.Reader) (domain.Store, error)
+	// Update changes a store the caller owns, replacing its logo if logo
+	// is not nil.
+	Update(ctx context.Context, claims auth.Claims, id string, input StoreInput, logo io.Reader) (domain.Store, error)
+	Delete(ctx context.Context, claims auth.Claims, id string) error
+	// List returns every store for admins and the caller's otherwise.
+	List(ctx context.Context, claims auth.Claims) ([]domain.Store, error)
+	ListByOwner(ctx context.Context, ownerID string) ([]domain.Store, error)
+	// UserStoreIDs returns the ids of the stores a user owns.
+	UserStoreIDs(ctx context.Context, userID string) ([]string, error)
+}
+
+// ImageStore hosts store logos.
+type ImageStore interface {
+	UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error)
+	DeleteImage(ctx context.Context, publicID string) error
+}
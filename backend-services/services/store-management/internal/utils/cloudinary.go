@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/cloudinary/cloudinary-go/v2"
@@ -28,7 +29,7 @@ func NewCloudinaryService() (*CloudinaryService, error) {
 	return &CloudinaryService{cld: cld}, nil
 }
 
-func (s *CloudinaryService) UploadImage(ctx context.Context, file interface{}, publicID string) (string, error) {
+func (s *CloudinaryService) UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error) {
 	// Upload file to Cloudinary
 	result, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
 		PublicID: publicID,
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"shared/signing"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+var signingKey = signing.Key{ID: "test", Secret: []byte(strings.Repeat("k", 32))}
+
+func newTestMiddleware(t *testing.T) (*Middleware, func(claims Claims) string) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, _ := jwk.New(pub)
+	pubKey.Set(jwk.KeyIDKey, "test-key")
+	pubKey.Set(jwk.AlgorithmKey, jwa.EdDSA)
+	privKey, _ := jwk.New(priv)
+	privKey.Set(jwk.KeyIDKey, "test-key")
+
+	sign := func(claims Claims) string {
+		token := jwt.New()
+		token.Set("id", claims.ID)
+		token.Set("email", claims.Email)
+		token.Set("role", claims.Role)
+		token.Set(jwt.IssuerKey, DefaultIssuer)
+		token.Set(jwt.AudienceKey, DefaultAudience)
+		token.Set(jwt.ExpirationKey, time.Now().Add(time.Hour))
+		signed, err := jwt.Sign(token, jwa.EdDSA, privKey)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return string(signed)
+	}
+	keys, err := NewKeySet("", pubKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(NewVerifier(keys, DefaultIssuer, DefaultAudience)), sign
+}
+
+// setIdentity sets the identity headers the gateway would forward for
+// claims, signs the request and verifies it, as the services' signing
+// middleware does.
+func setIdentity(r *http.Request, claims Claims) {
+	setUnsignedIdentity(r, claims)
+	signing.NewSigner(signingKey).Sign(r, r.URL.RequestURI(), nil)
+	verifier, _ := signing.NewVerifier([]signing.Key{signingKey})
+	verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, verified *http.Request) {
+		*r = *verified
+	})).ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func setUnsignedIdentity(r *http.Request, claims Claims) {
+	r.Header.Set(headerUserID, claims.ID)
+	r.Header.Set(headerUserEmail, claims.Email)
+	r.Header.Set(headerUserRole, claims.Role)
+	r.Header.Set(headerStoreID, claims.StoreID)
+}
+
+func TestValidateToken(t *testing.T) {
+	m, sign := newTestMiddleware(t)
+	owner := Claims{ID: "u1", Email: "u1@example.com", Role: "store_owner", StoreID: "s1"}
+
+	tests := []struct {
+		name  string
+		setup func(r *http.Request)
+		want  int
+	}{
+		{"no credentials", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+sign(owner)) }, http.StatusOK},
+		{"bad token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer garbage") }, http.StatusUnauthorized},
+		{"gateway identity", func(r *http.Request) { setIdentity(r, owner) }, http.StatusOK},
+		{"unsigned identity", func(r *http.Request) { setUnsignedIdentity(r, owner) }, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Claims
+			h := m.ValidateToken(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = GetClaims(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.setup(req)
+			rec := httptest.NewRecorder()
+			h(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && (got.ID != "u1" || got.Role != "store_owner") {
+				t.Errorf("claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	m, _ := newTestMiddleware(t)
+	h := m.ValidateToken(m.RequireRole("admin", "store_owner")(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for role, want := range map[string]int{
+		"admin":       http.StatusOK,
+		"store_owner": http.StatusOK,
+		"customer":    http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		setIdentity(req, Claims{ID: "u1", Role: role})
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != want {
+			t.Errorf("role %s: status = %d, want %d", role, rec.Code, want)
+		}
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	m, _ := newTestMiddleware(t)
+
+	tests := []struct {
+		role string
+		perm Permission
+		want int
+	}{
+		{"user", PermOrderCreate, http.StatusOK},
+		{RoleCustomer, PermProductWrite, http.StatusForbidden},
+		{RoleCustomer, PermOrderManage, http.StatusForbidden},
+		{RoleStoreStaff, PermProductWrite, http.StatusOK},
+		{RoleStoreStaff, PermStoreWrite, http.StatusForbidden},
+		{RoleStoreOwner, PermStoreWrite, http.StatusOK},
+		{RoleStoreOwner, PermStoreManageAny, http.StatusForbidden},
+		{RoleAdmin, PermStoreManageAny, http.StatusOK},
+		{"unknown", PermOrderRead, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		h := m.Require(tt.perm, func(w http.ResponseWriter, r *http.Request) {})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		setIdentity(req, Claims{ID: "u1", Role: tt.role})
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.role, tt.perm, rec.Code, tt.want)
+		}
+	}
+}
+
+// fakeOwners knows alice as a store owner and fails for anyone called
+// "down".
+type fakeOwners struct {
+	lookups int
+}
+
+func (f *fakeOwners) IsStoreOwner(ctx context.Context, userID string) (bool, error) {
+	f.lookups++
+	if userID == "down" {
+		return false, errors.New("store-management unavailable")
+	}
+	return userID == "alice", nil
+}
+
+func TestPromoteStoreOwners(t *testing.T) {
+	m, _ := newTestMiddleware(t)
+	owners := &fakeOwners{}
+	m.PromoteStoreOwners(owners)
+
+	tests := []struct {
+		claims  Claims
+		perm    Permission
+		want    int
+		lookups int
+	}{
+		{Claims{ID: "alice", Role: "user"}, PermStoreWrite, http.StatusOK, 1},
+		{Claims{ID: "bob", Role: "user"}, PermStoreWrite, http.StatusForbidden, 1},
+		{Claims{ID: "down", Role: "user"}, PermProductWrite, http.StatusServiceUnavailable, 1},
+		// No lookup when the role decides
+		{Claims{ID: "alice", Role: "user"}, PermOrderCreate, http.StatusOK, 0},
+		{Claims{ID: "alice", Role: "user"}, PermStoreManageAny, http.StatusForbidden, 0},
+		{Claims{ID: "alice", Role: RoleStoreStaff}, PermStoreWrite, http.StatusForbidden, 0},
+	}
+
+	for _, tt := range tests {
+		owners.lookups = 0
+		var role string
+		h := m.Require(tt.perm, func(w http.ResponseWriter, r *http.Request) {
+			claims, _ := GetClaims(r.Context())
+			role = claims.Role
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		setIdentity(req, tt.claims)
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != tt.want || owners.lookups != tt.lookups {
+			t.Errorf("%s %s: status = %d after %d lookups, want %d after %d", tt.claims.ID, tt.perm, rec.Code, owners.lookups, tt.want, tt.lookups)
+		}
+		if rec.Code == http.StatusOK && tt.lookups > 0 && role != RoleStoreOwner {
+			t.Errorf("%s %s: handler saw role %q, want %q", tt.claims.ID, tt.perm, role, RoleStoreOwner)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package authtest issues tokens accepted by an auth.Middleware, so that
+// services can test their routes with real authentication.
+package authtest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"shared/auth"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+// Issuer signs tokens with a key generated for the test.
+type Issuer struct {
+	private    jwk.Key
+	middleware *auth.Middleware
+}
+
+// NewIssuer returns an Issuer whose Middleware accepts its tokens.
+func NewIssuer(t testing.TB) *Issuer {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, _ := jwk.New(pub)
+	public.Set(jwk.KeyIDKey, "authtest")
+	public.Set(jwk.AlgorithmKey, jwa.EdDSA)
+	private, _ := jwk.New(priv)
+	private.Set(jwk.KeyIDKey, "authtest")
+
+	keys, err := auth.NewKeySet("", public, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := auth.NewVerifier(keys, auth.DefaultIssuer, auth.DefaultAudience)
+	return &Issuer{private: private, middleware: auth.New(verifier)}
+}
+
+// Middleware returns a Middleware verifying the Issuer's tokens.
+func (i *Issuer) Middleware() *auth.Middleware {
+	return i.middleware
+}
+
+// Token returns a bearer token for claims, valid for an hour.
+func (i *Issuer) Token(t testing.TB, claims auth.Claims) string {
+	t.Helper()
+
+	token := jwt.New()
+	token.Set("id", claims.ID)
+	token.Set("email", claims.Email)
+	token.Set("role", claims.Role)
+	if claims.StoreID != "" {
+		token.Set("store_id", claims.StoreID)
+	}
+	token.Set(jwt.IssuerKey, auth.DefaultIssuer)
+	token.Set(jwt.AudienceKey, auth.DefaultAudience)
+	token.Set(jwt.ExpirationKey, time.Now().Add(time.Hour))
+	signed, err := jwt.Sign(token, jwa.EdDSA, i.private)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return string(signed)
+}
+
+// Authorize sets r's Authorization header to a token for claims.
+func (i *Issuer) Authorize(t testing.TB, r *http.Request, claims auth.Claims) {
+	t.Helper()
+	r.Header.Set("Authorization", "Bearer "+i.Token(t, claims))
+}
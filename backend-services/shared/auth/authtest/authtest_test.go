@@ -0,0 +1,40 @@
+package authtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"testing"
+)
+
+func TestIssuedTokensPassMiddleware(t *testing.T) {
+	issuer := NewIssuer(t)
+	want := auth.Claims{ID: "u1", Email: "u1@example.com", Role: auth.RoleStoreStaff, StoreID: "s1"}
+
+	var got auth.Claims
+	h := issuer.Middleware().ValidateToken(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = auth.GetClaims(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	issuer.Authorize(t, req, want)
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	if rec.Code != http.StatusOK || got != want {
+		t.Fatalf("status %d with claims %+v, want 200 with %+v", rec.Code, got, want)
+	}
+}
+
+func TestOtherIssuersAreRejected(t *testing.T) {
+	h := NewIssuer(t).Middleware().ValidateToken(func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	NewIssuer(t).Authorize(t, req, auth.Claims{ID: "u1"})
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+}
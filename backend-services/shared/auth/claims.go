@@ -0,0 +1,27 @@
+// Package auth verifies the auth service's bearer tokens and authenticates
+// requests to the services, either from the identity headers signed by the
+// API gateway or from a bearer token.
+package auth
+
+import "context"
+
+// Claims identifies the caller. StoreID is only set for store staff.
+type Claims struct {
+	ID      string `json:"id"`
+	Email   string `json:"email"`
+	Role    string `json:"role"`
+	StoreID string `json:"store_id"`
+}
+
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying claims.
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// GetClaims returns the claims of the authenticated caller.
+func GetClaims(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
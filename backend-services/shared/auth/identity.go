@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+	"shared/signing"
+)
+
+// Identity headers set by the API gateway after it has verified the
+// caller's token. They are covered by the request signature, so they are
+// only trusted on requests signing.Verifier.Middleware has verified.
+const (
+	headerUserID    = "X-User-ID"
+	headerUserEmail = "X-User-Email"
+	headerUserRole  = "X-User-Role"
+	headerStoreID   = "X-Store-ID"
+)
+
+// identityFromHeaders returns the claims forwarded by the gateway. ok is
+// false when the request carries no identity or was not signed.
+func identityFromHeaders(r *http.Request) (claims Claims, ok bool) {
+	if !signing.Verified(r.Context()) || r.Header.Get(headerUserID) == "" {
+		return Claims{}, false
+	}
+	return Claims{
+		ID:      r.Header.Get(headerUserID),
+		Email:   r.Header.Get(headerUserEmail),
+		Role:    r.Header.Get(headerUserRole),
+		StoreID: r.Header.Get(headerStoreID),
+	}, true
+}
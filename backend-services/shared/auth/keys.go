@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+// DefaultRefreshInterval is how often the JWKS is re-fetched when no
+// interval is configured.
+const DefaultRefreshInterval = 15 * time.Minute
+
+// minRefreshInterval stops tokens with unknown key ids from making every
+// request re-fetch the JWKS.
+const minRefreshInterval = 30 * time.Second
+
+// KeySet holds the keys tokens may be signed with: those published at the
+// auth service's JWKS URL, re-fetched periodically and whenever a token
+// names an unknown kid, plus an optional static key used as a fallback.
+type KeySet struct {
+	url      string
+	static   jwk.Key
+	interval time.Duration
+	client   *http.Client
+	now      func() time.Time
+
+	// refreshing serialises fetches.
+	refreshing sync.Mutex
+
+	mu        sync.Mutex
+	remote    jwk.Set
+	fetched   time.Time
+	attempted time.Time
+}
+
+// NewKeySet returns a KeySet for the JWKS at url and the static key.
+// Either may be empty, but not both.
+func NewKeySet(url string, static jwk.Key, interval time.Duration) (*KeySet, error) {
+	if url == "" && static == nil {
+		return nil, fmt.Errorf("no JWKS URL or static key configured")
+	}
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	return &KeySet{
+		url:      url,
+		static:   static,
+		interval: interval,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+// Refresh fetches the JWKS. The previous keys are kept if it fails.
+func (s *KeySet) Refresh(ctx context.Context) error {
+	if s.url == "" {
+		return nil
+	}
+
+	s.refreshing.Lock()
+	defer s.refreshing.Unlock()
+	return s.fetch(ctx)
+}
+
+func (s *KeySet) fetch(ctx context.Context) error {
+	s.mu.Lock()
+	s.attempted = s.now()
+	s.mu.Unlock()
+
+	set, err := jwk.Fetch(ctx, s.url, jwk.WithHTTPClient(s.client))
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	s.mu.Lock()
+	s.remote = set
+	s.fetched = s.now()
+	s.mu.Unlock()
+	return nil
+}
+
+// keysFor returns the keys a token signed with kid may be verified
+// against. A token without a kid can only use the static key, or the
+// JWKS when it holds a single key.
+func (s *KeySet) keysFor(ctx context.Context, kid string) jwk.Set {
+	if s.url != "" {
+		s.refreshIfNeeded(ctx, kid)
+	}
+
+	s.mu.Lock()
+	remote := s.remote
+	s.mu.Unlock()
+
+	set := jwk.NewSet()
+	if kid == "" && s.static != nil {
+		set.Add(s.static)
+		return set
+	}
+	if remote != nil {
+		for i := 0; i < remote.Len(); i++ {
+			key, _ := remote.Get(i)
+			set.Add(key)
+		}
+	}
+	if s.static != nil {
+		// The JWKS wins if it publishes the same kid.
+		if _, dup := set.LookupKeyID(s.static.KeyID()); s.static.KeyID() == "" || !dup {
+			set.Add(s.static)
+		}
+	}
+	return set
+}
+
+// refreshIfNeeded re-fetches the JWKS in the background once it is stale,
+// and before verifying a token whose kid it does not hold, so a rotated
+// key is picked up straight away.
+func (s *KeySet) refreshIfNeeded(ctx context.Context, kid string) {
+	s.mu.Lock()
+	now := s.now()
+	stale := now.Sub(s.fetched) >= s.interval
+	unknown := s.remote == nil
+	if kid != "" && s.remote != nil {
+		_, found := s.remote.LookupKeyID(kid)
+		unknown = !found
+	}
+	throttled := now.Sub(s.attempted) < minRefreshInterval
+	s.mu.Unlock()
+
+	if throttled {
+		return
+	}
+
+	if unknown {
+		s.refreshing.Lock()
+		defer s.refreshing.Unlock()
+
+		// Another request may have fetched while this one waited.
+		s.mu.Lock()
+		throttled = s.now().Sub(s.attempted) < minRefreshInterval
+		s.mu.Unlock()
+		if throttled {
+			return
+		}
+		if err := s.fetch(ctx); err != nil {
+			slog.WarnContext(ctx, "JWKS refresh failed", "url", s.url, "error", err)
+		}
+		return
+	}
+
+	if stale && s.refreshing.TryLock() {
+		go func() {
+			defer s.refreshing.Unlock()
+			if err := s.fetch(context.Background()); err != nil {
+				slog.Warn("JWKS refresh failed", "url", s.url, "error", err)
+			}
+		}()
+	}
+}
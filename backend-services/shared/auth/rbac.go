@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"shared/response"
+)
+
+// Roles a user can hold. BetterAuth gives new users the role "user", which
+// is treated as RoleCustomer.
+const (
+	RoleCustomer   = "customer"
+	RoleStoreOwner = "store_owner"
+	RoleStoreStaff = "store_staff"
+	RoleAdmin      = "admin"
+)
+
+// Permission names an action routes can require.
+type Permission string
+
+const (
+	// PermStoreOwnerManage covers registering as a store owner and
+	// managing one's own store owner profile.
+	PermStoreOwnerManage Permission = "store_owner:manage"
+	// PermStoreOwnerManageAny covers every store owner profile.
+	PermStoreOwnerManageAny Permission = "store_owner:manage_any"
+	PermStoreRead           Permission = "store:read"
+	PermStoreWrite          Permission = "store:write"
+	// PermStoreManageAny covers stores the caller does not own.
+	PermStoreManageAny Permission = "store:manage_any"
+	PermProductRead    Permission = "product:read"
+	PermProductWrite   Permission = "product:write"
+	PermInventoryWrite Permission = "inventory:write"
+	PermOrderCreate    Permission = "order:create"
+	PermOrderRead      Permission = "order:read"
+	// PermOrderManage covers handling orders placed with the caller's store.
+	PermOrderManage Permission = "order:manage"
+)
+
+var customerPermissions = []Permission{
+	PermStoreOwnerManage,
+	PermProductRead,
+	PermOrderCreate,
+	PermOrderRead,
+}
+
+var staffPermissions = append([]Permission{
+	PermStoreRead,
+	PermProductWrite,
+	PermInventoryWrite,
+	PermOrderManage,
+}, customerPermissions...)
+
+var rolePermissions = map[string][]Permission{
+	RoleCustomer:   customerPermissions,
+	RoleStoreStaff: staffPermissions,
+	RoleStoreOwner: append([]Permission{PermStoreWrite}, staffPermissions...),
+}
+
+// NormalizeRole maps the roles BetterAuth issues onto the roles above.
+func NormalizeRole(role string) string {
+	if role == "" || role == "user" {
+		return RoleCustomer
+	}
+	return role
+}
+
+// HasPermission reports whether role grants perm. Admins hold every
+// permission; unknown roles hold none.
+func HasPermission(role string, perm Permission) bool {
+	role = NormalizeRole(role)
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the caller's role grants perm.
+func (c Claims) Can(perm Permission) bool {
+	return HasPermission(c.Role, perm)
+}
+
+// StoreOwners tells whether a user has become a store owner.
+type StoreOwners interface {
+	IsStoreOwner(ctx context.Context, userID string) (bool, error)
+}
+
+// PromoteStoreOwners makes Require treat customers that owners knows as
+// store owners as holding RoleStoreOwner. BetterAuth only issues the user
+// and admin roles, so this is how store owners get their permissions. It
+// returns m.
+func (m *Middleware) PromoteStoreOwners(owners StoreOwners) *Middleware {
+	m.owners = owners
+	return m
+}
+
+// Require authenticates the caller and only lets them through if their
+// role grants perm.
+func (m *Middleware) Require(perm Permission, next http.HandlerFunc) http.HandlerFunc {
+	return m.ValidateToken(m.promote(perm)(RequirePermission(perm)(next)))
+}
+
+// promote gives a customer the store owner role if perm needs it and they
+// are a store owner. Ownership is only looked up when the role matters, so
+// other routes do not depend on it.
+func (m *Middleware) promote(perm Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaims(r.Context())
+			if !ok || m.owners == nil || claims.Can(perm) || NormalizeRole(claims.Role) != RoleCustomer || !HasPermission(RoleStoreOwner, perm) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			owner, err := m.owners.IsStoreOwner(r.Context(), claims.ID)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to look up store ownership", "error", err)
+				response.Error(w, http.StatusServiceUnavailable, "Store ownership unavailable")
+				return
+			}
+			if owner {
+				claims.Role = RoleStoreOwner
+				r = r.WithContext(WithClaims(r.Context(), claims))
+			}
+			next.ServeHTTP(w, r)
+		}
+	}
+}
+
+// RequirePermission only lets callers whose role grants perm through. It
+// must run after ValidateToken.
+func RequirePermission(perm Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaims(r.Context())
+			if !ok {
+				response.Error(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+			if !claims.Can(perm) {
+				response.Error(w, http.StatusForbidden, "Missing permission "+string(perm))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+// Issuer and audience BetterAuth's JWT plugin is configured with.
+const (
+	DefaultIssuer   = "marketplace"
+	DefaultAudience = "marketplace-services"
+)
+
+// clockSkew is the leeway allowed on exp, nbf and iat.
+const clockSkew = 30 * time.Second
+
+// Verifier checks bearer tokens issued by the auth service.
+type Verifier struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewVerifier returns a Verifier accepting tokens signed with one of keys
+// for issuer and audience. Empty issuer or audience are not checked.
+func NewVerifier(keys *KeySet, issuer, audience string) *Verifier {
+	return &Verifier{keys: keys, issuer: issuer, audience: audience, now: time.Now}
+}
+
+// NewVerifierFromEnv builds a Verifier from JWT_JWKS_URL and the static
+// JWT_PUBLIC_KEY, at least one of which must be set, and JWT_ISSUER and
+// JWT_AUDIENCE. JWT_JWKS_REFRESH_INTERVAL sets how often the JWKS is
+// re-fetched.
+func NewVerifierFromEnv(ctx context.Context) (*Verifier, error) {
+	url := os.Getenv("JWT_JWKS_URL")
+	jwkJSON := os.Getenv("JWT_PUBLIC_KEY")
+	if url == "" && jwkJSON == "" {
+		return nil, fmt.Errorf("JWT_JWKS_URL or JWT_PUBLIC_KEY environment variable must be set")
+	}
+
+	var static jwk.Key
+	if jwkJSON != "" {
+		key, err := jwk.ParseKey([]byte(jwkJSON))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWK: %w", err)
+		}
+		static = key
+	}
+
+	var interval time.Duration
+	if v := os.Getenv("JWT_JWKS_REFRESH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_JWKS_REFRESH_INTERVAL: %w", err)
+		}
+		interval = d
+	}
+
+	keys, err := NewKeySet(url, static, interval)
+	if err != nil {
+		return nil, err
+	}
+	// The auth service may still be starting; keys are fetched again on
+	// the first token that needs them.
+	if err := keys.Refresh(ctx); err != nil {
+		slog.WarnContext(ctx, "Initial JWKS fetch failed", "url", url, "error", err)
+	}
+
+	return NewVerifier(keys, envOr("JWT_ISSUER", DefaultIssuer), envOr("JWT_AUDIENCE", DefaultAudience)), nil
+}
+
+// Verify checks the token's signature, expiry, not-before, issuer and
+// audience, and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	msg, err := jws.ParseString(token)
+	if err != nil {
+		return Claims{}, fmt.Errorf("malformed token: %w", err)
+	}
+	kid := msg.Signatures()[0].ProtectedHeaders().KeyID()
+
+	opts := []jwt.ParseOption{
+		jwt.WithKeySet(v.keys.keysFor(ctx, kid)),
+		jwt.UseDefaultKey(true),
+		jwt.InferAlgorithmFromKey(true),
+		jwt.WithValidate(true),
+		jwt.WithRequiredClaim(jwt.ExpirationKey),
+		jwt.WithAcceptableSkew(clockSkew),
+		jwt.WithClock(jwt.ClockFunc(v.now)),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	parsed, err := jwt.Parse([]byte(token), opts...)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	claimsJSON, err := json.Marshal(parsed.PrivateClaims())
+	if err != nil {
+		return Claims{}, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return Claims{}, fmt.Errorf("invalid token claims: %w", err)
+	}
+	if claims.ID == "" {
+		return Claims{}, fmt.Errorf("token has no user id")
+	}
+	return claims, nil
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+type testKey struct {
+	public  jwk.Key
+	private jwk.Key
+}
+
+func newTestKey(t *testing.T, kid string) testKey {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, _ := jwk.New(pub)
+	public.Set(jwk.KeyIDKey, kid)
+	public.Set(jwk.AlgorithmKey, jwa.EdDSA)
+	private, _ := jwk.New(priv)
+	private.Set(jwk.KeyIDKey, kid)
+	return testKey{public: public, private: private}
+}
+
+// sign returns a valid token for user u1, after applying edit.
+func (k testKey) sign(t *testing.T, edit func(jwt.Token)) string {
+	t.Helper()
+
+	token := jwt.New()
+	token.Set("id", "u1")
+	token.Set(jwt.IssuerKey, DefaultIssuer)
+	token.Set(jwt.AudienceKey, DefaultAudience)
+	token.Set(jwt.ExpirationKey, time.Now().Add(time.Hour))
+	if edit != nil {
+		edit(token)
+	}
+	signed, err := jwt.Sign(token, jwa.EdDSA, k.private)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return string(signed)
+}
+
+// jwksServer publishes the public halves of the keys it currently holds.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []testKey
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...testKey) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		set := jwk.NewSet()
+		for _, key := range s.keys {
+			set.Add(key.public)
+		}
+		s.mu.Unlock()
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) publish(keys ...testKey) {
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+func TestVerifyClaims(t *testing.T) {
+	key := newTestKey(t, "k1")
+	keys, err := NewKeySet("", key.public, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewVerifier(keys, DefaultIssuer, DefaultAudience)
+
+	tests := []struct {
+		name   string
+		edit   func(jwt.Token)
+		wantOK bool
+	}{
+		{"valid", nil, true},
+		{"expired", func(tok jwt.Token) { tok.Set(jwt.ExpirationKey, time.Now().Add(-time.Hour)) }, false},
+		{"no expiry", func(tok jwt.Token) { tok.Remove(jwt.ExpirationKey) }, false},
+		{"not yet valid", func(tok jwt.Token) { tok.Set(jwt.NotBeforeKey, time.Now().Add(time.Hour)) }, false},
+		{"within skew", func(tok jwt.Token) { tok.Set(jwt.NotBeforeKey, time.Now().Add(10*time.Second)) }, true},
+		{"wrong issuer", func(tok jwt.Token) { tok.Set(jwt.IssuerKey, "someone-else") }, false},
+		{"wrong audience", func(tok jwt.Token) { tok.Set(jwt.AudienceKey, "other-service") }, false},
+		{"no user id", func(tok jwt.Token) { tok.Remove("id") }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), key.sign(t, tt.edit))
+			if tt.wantOK && (err != nil || claims.ID != "u1") {
+				t.Fatalf("Verify = %+v, %v; want u1", claims, err)
+			}
+			if !tt.wantOK && err == nil {
+				t.Fatal("Verify succeeded, want error")
+			}
+		})
+	}
+
+	other := newTestKey(t, "k1")
+	if _, err := v.Verify(context.Background(), other.sign(t, nil)); err == nil {
+		t.Error("token signed with a different key verified")
+	}
+}
+
+func TestVerifyPicksUpRotatedKey(t *testing.T) {
+	old, next := newTestKey(t, "old"), newTestKey(t, "next")
+	server := newJWKSServer(t, old)
+
+	keys, err := NewKeySet(server.URL, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	v := NewVerifier(keys, DefaultIssuer, DefaultAudience)
+
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+
+	if _, err := v.Verify(context.Background(), old.sign(t, nil)); err != nil {
+		t.Fatalf("old key: %v", err)
+	}
+
+	// The auth service rotates; an unknown kid triggers a re-fetch once
+	// the throttle has passed.
+	server.publish(old, next)
+	now = now.Add(minRefreshInterval)
+	if _, err := v.Verify(context.Background(), next.sign(t, nil)); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if got := server.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+
+	// Tokens with unknown kids cannot force a fetch per request.
+	stranger := newTestKey(t, "stranger")
+	for i := 0; i < 5; i++ {
+		if _, err := v.Verify(context.Background(), stranger.sign(t, nil)); err == nil {
+			t.Fatal("unknown key verified")
+		}
+	}
+	if got := server.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d after unknown kids, want 2", got)
+	}
+}
+
+func TestVerifyFallsBackToStaticKey(t *testing.T) {
+	static := newTestKey(t, "static")
+	server := newJWKSServer(t)
+	server.Close()
+
+	keys, err := NewKeySet(server.URL, static.public, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh succeeded against a closed server")
+	}
+	v := NewVerifier(keys, DefaultIssuer, DefaultAudience)
+
+	if _, err := v.Verify(context.Background(), static.sign(t, nil)); err != nil {
+		t.Fatalf("static key: %v", err)
+	}
+}
+
+func TestNewKeySetRequiresASource(t *testing.T) {
+	if _, err := NewKeySet("", nil, 0); err == nil {
+		t.Fatal("NewKeySet with no URL or key succeeded")
+	}
+}
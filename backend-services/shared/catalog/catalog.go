@@ -0,0 +1,115 @@
+// Package catalog looks up products in product-catalog on behalf of other
+// services.
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"shared/client"
+	"shared/response"
+	"shared/signing"
+	"strings"
+)
+
+// CodeInsufficientStock is the problem code product-catalog answers with
+// when a reservation asks for more stock than is available.
+const CodeInsufficientStock response.Code = "insufficient_stock"
+
+// ErrInsufficientStock is returned by Reserve when the product does not
+// have enough stock available.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// Product is what product-catalog tells other services about a product.
+type Product struct {
+	ID       string  `json:"id"`
+	StoreID  string  `json:"store_id"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	IsActive bool    `json:"is_active"`
+}
+
+// Products is the body of product-catalog's internal product lookup.
+type Products struct {
+	Products []Product `json:"products"`
+}
+
+// Client calls product-catalog's internal routes.
+type Client struct {
+	client *client.Client
+}
+
+// NewClient returns a Client querying product-catalog through c.
+func NewClient(c *client.Client) *Client {
+	return &Client{client: c}
+}
+
+// NewClientFromEnv queries product-catalog at PRODUCT_SERVICE_URL,
+// signing requests as configured by signing.NewSignerFromEnv.
+func NewClientFromEnv() (*Client, error) {
+	baseURL := os.Getenv("PRODUCT_SERVICE_URL")
+	if baseURL == "" {
+		return nil, fmt.Errorf("PRODUCT_SERVICE_URL environment variable not set")
+	}
+	signer, err := signing.NewSignerFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(client.New(baseURL, signer)), nil
+}
+
+// Products returns the current state of the products with the given ids.
+// Products that do not exist are left out.
+func (c *Client) Products(ctx context.Context, ids []string) ([]Product, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var body Products
+	if err := c.client.GetJSON(ctx, "/internal/products?ids="+url.QueryEscape(strings.Join(ids, ",")), &body); err != nil {
+		return nil, fmt.Errorf("failed to look up products: %w", err)
+	}
+	return body.Products, nil
+}
+
+// Reserve holds quantity of the product for the order. Reserving again for
+// the same order has no effect.
+func (c *Client) Reserve(ctx context.Context, productID, orderID string, quantity int) error {
+	in := struct {
+		OrderID  string `json:"order_id"`
+		Quantity int    `json:"quantity"`
+	}{orderID, quantity}
+	err := c.client.Do(ctx, http.MethodPost, reservationsPath(productID), in, nil)
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) && statusErr.Problem != nil && statusErr.Problem.Code == CodeInsufficientStock {
+		return fmt.Errorf("failed to reserve product %s: %w", productID, ErrInsufficientStock)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reserve product %s: %w", productID, err)
+	}
+	return nil
+}
+
+// Release returns the stock reserved for the order. Releasing before
+// reserving stops the reservation from being made later.
+func (c *Client) Release(ctx context.Context, productID, orderID string) error {
+	if err := c.client.Do(ctx, http.MethodDelete, reservationsPath(productID)+"/"+url.PathEscape(orderID), nil, nil); err != nil {
+		return fmt.Errorf("failed to release product %s: %w", productID, err)
+	}
+	return nil
+}
+
+// Commit takes the stock reserved for the order off the shelf once it
+// ships.
+func (c *Client) Commit(ctx context.Context, productID, orderID string) error {
+	if err := c.client.Do(ctx, http.MethodPost, reservationsPath(productID)+"/"+url.PathEscape(orderID)+"/commit", nil, nil); err != nil {
+		return fmt.Errorf("failed to commit product %s: %w", productID, err)
+	}
+	return nil
+}
+
+func reservationsPath(productID string) string {
+	return "/internal/products/" + url.PathEscape(productID) + "/reservations"
+}
@@ -0,0 +1,125 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"shared/client"
+	"shared/response"
+	"shared/signing"
+	"strings"
+	"testing"
+)
+
+var testKey = signing.Key{ID: "test", Secret: []byte(strings.Repeat("k", 32))}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	verifier, err := signing.NewVerifier([]signing.Key{testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verifier.Verify(r); err != nil {
+			response.Error(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewClient(client.New(server.URL, signing.NewSigner(testKey)))
+}
+
+func TestProducts(t *testing.T) {
+	known := map[string]Product{
+		"p1": {ID: "p1", StoreID: "s1", Name: "Teapot", Price: 120, IsActive: true},
+		"p2": {ID: "p2", StoreID: "s1", Name: "Rug", Price: 900},
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/products" {
+			response.Error(w, http.StatusNotFound, "Not found")
+			return
+		}
+		body := Products{Products: []Product{}}
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			if p, ok := known[id]; ok {
+				body.Products = append(body.Products, p)
+			}
+		}
+		response.JSON(w, http.StatusOK, body)
+	})
+
+	products, err := c.Products(context.Background(), []string{"p1", "missing", "p2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 || products[0] != known["p1"] || products[1] != known["p2"] {
+		t.Errorf("Products = %+v", products)
+	}
+
+	if products, err := c.Products(context.Background(), nil); err != nil || len(products) != 0 {
+		t.Errorf("Products(nil) = %+v, %v", products, err)
+	}
+}
+
+func TestProductsFailure(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusServiceUnavailable, "Database unavailable")
+	})
+
+	_, err := c.Products(context.Background(), []string{"p1"})
+	var statusErr *client.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Products error = %v, want a 503 StatusError", err)
+	}
+}
+
+func TestReservations(t *testing.T) {
+	var got []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/internal/products/p2/reservations" {
+			response.WriteError(w, response.NewProblem(http.StatusConflict, CodeInsufficientStock, "Not enough stock"))
+			return
+		}
+		response.JSON(w, http.StatusOK, map[string]string{"status": "held"})
+	})
+	ctx := context.Background()
+
+	if err := c.Reserve(ctx, "p1", "o1", 2); err != nil {
+		t.Errorf("Reserve = %v", err)
+	}
+	if err := c.Reserve(ctx, "p2", "o1", 2); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Reserve without stock = %v, want ErrInsufficientStock", err)
+	}
+	if err := c.Release(ctx, "p1", "o1"); err != nil {
+		t.Errorf("Release = %v", err)
+	}
+	if err := c.Commit(ctx, "p1", "o1"); err != nil {
+		t.Errorf("Commit = %v", err)
+	}
+
+	want := []string{
+		"POST /internal/products/p1/reservations",
+		"POST /internal/products/p2/reservations",
+		"DELETE /internal/products/p1/reservations/o1",
+		"POST /internal/products/p1/reservations/o1/commit",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+}
+
+func TestReserveFailure(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusServiceUnavailable, "Database unavailable")
+	})
+
+	err := c.Reserve(context.Background(), "p1", "o1", 1)
+	if err == nil || errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Reserve error = %v, want a plain failure", err)
+	}
+}
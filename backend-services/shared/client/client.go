@@ -0,0 +1,104 @@
+// Package client calls other services directly, signing requests the way
+// the gateway does and passing on the caller's request id and trace.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"shared/middleware"
+	"shared/response"
+	"shared/signing"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// StatusError is returned when a service answers with a non-2xx status.
+// Problem holds the service's problem details, if it sent any.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	Problem    *response.Problem
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("service responded %d: %s", e.StatusCode, e.Body)
+}
+
+// Client sends requests to one service.
+type Client struct {
+	baseURL string
+	signer  *signing.Signer
+	http    *http.Client
+}
+
+// New returns a Client for the service at baseURL, signing requests with
+// signer.
+func New(baseURL string, signer *signing.Signer) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		signer:  signer,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetJSON fetches path and decodes the JSON response into v.
+func (c *Client) GetJSON(ctx context.Context, path string, v interface{}) error {
+	return c.Do(ctx, http.MethodGet, path, nil, v)
+}
+
+// Do sends in, if not nil, as JSON and decodes the data of the JSON
+// response into out, if not nil.
+func (c *Client) Do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", method, path, err)
+		}
+		body = data
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	c.signer.Sign(req, req.URL.RequestURI(), body)
+	if id := middleware.RequestID(ctx); id != "" {
+		req.Header.Set(middleware.HeaderRequestID, id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+		var problem response.Problem
+		if json.Unmarshal(data, &problem) == nil && problem.Code != "" {
+			statusErr.Problem = &problem
+		}
+		return statusErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response.Envelope{Data: out}); err != nil {
+		return fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+	return nil
+}
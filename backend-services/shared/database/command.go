@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+)
+
+// MigrateCommand implements a service's "migrate" subcommand:
+//
+//	migrate [up]         apply pending migrations
+//	migrate down [steps] roll back the latest steps migrations, default 1
+//	migrate status       list migrations and when they were applied
+func MigrateCommand(service string, migrations fs.FS, args []string) error {
+	dbConn, err := Open()
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	migrator, err := NewMigrator(dbConn.SQLDB, service, migrations)
+	if err != nil {
+		return err
+	}
+	return migrator.Run(context.Background(), args, os.Stdout)
+}
+
+// Run executes the migrate subcommand args, writing results to out.
+func (m *Migrator) Run(ctx context.Context, args []string, out io.Writer) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		rolledBack, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Rolled back %d migration(s)\n", rolledBack)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%03d  %-40s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, want up, down [steps] or status", command)
+	}
+	return nil
+}
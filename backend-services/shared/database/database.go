@@ -0,0 +1,76 @@
+// Package database opens the service's PostgreSQL connection.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"shared/tracing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type DBConnection struct {
+	GormDB *gorm.DB
+	SQLDB  *sql.DB
+}
+
+// InitDB opens the database and applies the service's pending migrations
+// from migrations.
+func InitDB(service string, migrations fs.FS) (*DBConnection, error) {
+	dbConn, err := Open()
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(dbConn.SQLDB, service, migrations)
+	if err != nil {
+		dbConn.Close()
+		return nil, err
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		dbConn.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	if applied > 0 {
+		slog.Info("Database migrated", "applied", applied)
+	}
+	return dbConn, nil
+}
+
+// Open connects to DATABASE_URL and records a span for each query.
+func Open() (*DBConnection, error) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return nil, fmt.Errorf("DATABASE_URL is not set")
+	}
+
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := gormDB.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	// Get underlying *sql.DB for cleanup
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB from gorm.DB: %w", err)
+	}
+
+	return &DBConnection{
+		GormDB: gormDB,
+		SQLDB:  sqlDB,
+	}, nil
+}
+
+func (dbConn *DBConnection) Close() error {
+	return dbConn.SQLDB.Close()
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"shared/response"
+	"time"
+)
+
+// healthTimeout bounds the database ping of a health check, within the
+// gateway's default probe timeout of 2s.
+const healthTimeout = time.Second
+
+// HealthHandler reports the service healthy while db answers a ping. It is
+// served without a request signature, for the gateway's health checks.
+func HealthHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+		defer cancel()
+
+		if err := db.PingContext(ctx); err != nil {
+			slog.WarnContext(ctx, "Health check failed", "error", err)
+			response.Error(w, http.StatusServiceUnavailable, "Database unavailable")
+			return
+		}
+		response.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
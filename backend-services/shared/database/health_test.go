@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pingDriver opens connections that only answer pings, failing while down
+// is set.
+type pingDriver struct {
+	down *bool
+}
+
+func (d pingDriver) Open(name string) (driver.Conn, error) {
+	return pingConn(d), nil
+}
+
+type pingConn pingDriver
+
+func (c pingConn) Ping(ctx context.Context) error {
+	if *c.down {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c pingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c pingConn) Close() error { return nil }
+
+func (c pingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestHealthHandler(t *testing.T) {
+	var down bool
+	sql.Register("ping", pingDriver{down: &down})
+	db, err := sql.Open("ping", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	h := HealthHandler(db)
+
+	for _, tt := range []struct {
+		down bool
+		want int
+	}{{false, http.StatusOK}, {true, http.StatusServiceUnavailable}} {
+		down = tt.down
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if rec.Code != tt.want {
+			t.Errorf("down %v: status = %d, want %d", tt.down, rec.Code, tt.want)
+		}
+	}
+}
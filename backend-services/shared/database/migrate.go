@@ -0,0 +1,306 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is one versioned schema change, read from a
+// <version>_<name>.up.sql file and its matching .down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads the migrations in the root of fsys, ordered by
+// version. Every migration needs an up file; down files are optional but
+// a migration without one cannot be rolled back.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		version, name, direction, err := parseMigrationName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseMigrationName splits "001_create_stores_table.up.sql" into its
+// version, name and direction.
+func parseMigrationName(file string) (int64, string, string, error) {
+	base := strings.TrimSuffix(file, ".sql")
+	direction := path.Ext(base)
+	if direction != ".up" && direction != ".down" {
+		return 0, "", "", fmt.Errorf("migration %s must end in .up.sql or .down.sql", file)
+	}
+	base = strings.TrimSuffix(base, direction)
+
+	prefix, name, ok := strings.Cut(base, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if !ok || err != nil || version <= 0 || name == "" {
+		return 0, "", "", fmt.Errorf("migration %s must be named <version>_<name>%s.sql", file, direction)
+	}
+	return version, name, strings.TrimPrefix(direction, "."), nil
+}
+
+// Migrator applies a service's migrations, recording them in the
+// schema_migrations table under the service's name. Every run holds a
+// PostgreSQL advisory lock so that replicas starting together migrate one
+// at a time.
+type Migrator struct {
+	db         *sql.DB
+	service    string
+	migrations []Migration
+	lockID     int64
+}
+
+// NewMigrator returns a Migrator for the migrations in fsys. service
+// names the advisory lock and the service's rows in schema_migrations, so
+// services sharing a database neither block each other nor take each
+// other's versions for their own.
+func NewMigrator(db *sql.DB, service string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	h := fnv.New64a()
+	h.Write([]byte("schema_migrations:" + service))
+	return &Migrator{db: db, service: service, migrations: migrations, lockID: int64(h.Sum64())}, nil
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, migration.Up, true); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "Applied migration", "version", migration.Version, "name", migration.Name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the latest steps applied migrations and returns how
+// many were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+			if err := m.apply(ctx, conn, migration, migration.Down, false); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "Rolled back migration", "version", migration.Version, "name", migration.Name)
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status lists every known migration and when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if at, ok := done[migration.Version]; ok {
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// locked runs fn on one connection while holding the advisory lock.
+// Advisory locks belong to the session, so the lock, the migrations and
+// the unlock must all use the same connection.
+func (m *Migrator) locked(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockID)
+
+	if err := m.prepareTable(ctx, conn); err != nil {
+		return fmt.Errorf("failed to prepare schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+// schemaMigrationsLockID serializes changes to the schema_migrations table
+// itself, which every service shares.
+const schemaMigrationsLockID = 0x736368656d61
+
+// prepareTable creates schema_migrations, or upgrades one from before it
+// was keyed by service. The rows of such a table are left with an empty
+// service until a service claims those matching its migrations by version
+// and name.
+func (m *Migrator) prepareTable(ctx context.Context, conn *sql.Conn) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", schemaMigrationsLockID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		service    text NOT NULL,
+		version    bigint NOT NULL,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now(),
+		PRIMARY KEY (service, version)
+	)`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS service text NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DO $$
+	BEGIN
+		IF NOT EXISTS (
+			SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_schema = current_schema() AND table_name = 'schema_migrations'
+				AND constraint_name = 'schema_migrations_pkey' AND column_name = 'service'
+		) THEN
+			ALTER TABLE schema_migrations DROP CONSTRAINT schema_migrations_pkey, ADD PRIMARY KEY (service, version);
+		END IF;
+	END $$`); err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if _, err := tx.ExecContext(ctx, "UPDATE schema_migrations SET service = $1 WHERE service = '' AND version = $2 AND name = $3", m.service, migration.Version, migration.Name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations WHERE service = $1", m.service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		done[version] = at
+	}
+	return done, rows.Err()
+}
+
+// apply runs one migration script and records it, or removes its record
+// when rolling back, in a single transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, script string, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (service, version, name) VALUES ($1, $2, $3)", m.service, migration.Version, migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE service = $1 AND version = $2", m.service, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_stores_logo.up.sql":     {Data: []byte("ALTER TABLE stores ADD COLUMN logo text;")},
+		"002_add_stores_logo.down.sql":   {Data: []byte("ALTER TABLE stores DROP COLUMN logo;")},
+		"001_create_stores_table.up.sql": {Data: []byte("CREATE TABLE stores (id uuid);")},
+		"migrations.go":                  {Data: []byte("package migrations")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("loaded %d migrations, want 2", len(migrations))
+	}
+	if m := migrations[0]; m.Version != 1 || m.Name != "create_stores_table" || m.Down != "" {
+		t.Errorf("first migration = %+v", m)
+	}
+	if m := migrations[1]; m.Version != 2 || m.Up == "" || m.Down == "" {
+		t.Errorf("second migration = %+v", m)
+	}
+}
+
+func TestLoadMigrationsRejectsBadFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"no direction":   {"001_create.sql": {Data: []byte("SELECT 1")}},
+		"no version":     {"create_stores.up.sql": {Data: []byte("SELECT 1")}},
+		"no name":        {"001.up.sql": {Data: []byte("SELECT 1")}},
+		"down only":      {"001_create.down.sql": {Data: []byte("SELECT 1")}},
+		"empty up":       {"001_create.up.sql": {Data: []byte("  \n")}},
+		"version reused": {"001_create.up.sql": {Data: []byte("SELECT 1")}, "001_other.up.sql": {Data: []byte("SELECT 1")}},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadMigrations(fsys); err == nil {
+				t.Error("LoadMigrations succeeded")
+			}
+		})
+	}
+}
+
+func TestRunRejectsBadArguments(t *testing.T) {
+	m := &Migrator{}
+	for _, args := range [][]string{{"sideways"}, {"down", "0"}, {"down", "all"}} {
+		if err := m.Run(context.Background(), args, io.Discard); err == nil {
+			t.Errorf("Run(%q) succeeded", args)
+		}
+	}
+}
+
+// TestMigratorsShareADatabase runs against the PostgreSQL database at
+// TEST_DATABASE_URL, and is skipped without one.
+func TestMigratorsShareADatabase(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	t.Setenv("DATABASE_URL", dsn)
+	dbConn, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbConn.Close()
+	ctx := context.Background()
+
+	// Both services number their first migration 1
+	suffix := time.Now().UnixNano()
+	newMigrator := func(service string) *Migrator {
+		t.Helper()
+		table := fmt.Sprintf("%s_%d", service, suffix)
+		m, err := NewMigrator(dbConn.SQLDB, table, fstest.MapFS{
+			"001_create_table.up.sql":   {Data: []byte("CREATE TABLE " + table + " (id int)")},
+			"001_create_table.down.sql": {Data: []byte("DROP TABLE " + table)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { m.Down(context.Background(), 1) })
+		return m
+	}
+	orders, stores := newMigrator("orders"), newMigrator("stores")
+
+	for _, m := range []*Migrator{orders, stores} {
+		if applied, err := m.Up(ctx); applied != 1 || err != nil {
+			t.Fatalf("%s: Up = %d, %v, want its migration applied", m.service, applied, err)
+		}
+		if _, err := dbConn.SQLDB.ExecContext(ctx, "SELECT id FROM "+m.service); err != nil {
+			t.Errorf("%s: table missing: %v", m.service, err)
+		}
+	}
+
+	if rolledBack, err := orders.Down(ctx, 1); rolledBack != 1 || err != nil {
+		t.Fatalf("orders: Down = %d, %v", rolledBack, err)
+	}
+	statuses, err := stores.Status(ctx)
+	if err != nil || len(statuses) != 1 || statuses[0].AppliedAt == nil {
+		t.Errorf("stores after rolling back orders: Status = %+v, %v", statuses, err)
+	}
+}
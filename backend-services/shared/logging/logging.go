@@ -0,0 +1,106 @@
+// Package logging configures structured JSON logging. Log lines written
+// with a request's context carry that request's id, trace id and user id.
+//
+// Never log tokens, emails or other personal data; identify users by id.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Init makes a JSON handler writing to stdout the default logger. The
+// level is read from LOG_LEVEL (debug, info, warn or error) and defaults
+// to info. Remaining log.Fatal calls are logged at error level.
+func Init(service string) {
+	handler := NewHandler(os.Stdout, level(os.Getenv("LOG_LEVEL")))
+	slog.SetDefault(slog.New(handler).With("service", service))
+	slog.SetLogLoggerLevel(slog.LevelError)
+}
+
+// NewHandler returns a JSON handler that adds request fields from the
+// context to each record.
+func NewHandler(w io.Writer, level slog.Level) slog.Handler {
+	return contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})}
+}
+
+func level(s string) slog.Level {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+type fieldsKey struct{}
+
+// fields holds the attributes added to a request's log lines. It is
+// shared by every context derived from the request, so attributes added
+// deep in the handler chain, like the user id, reach the access log.
+type fields struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// NewContext returns ctx with an empty set of log fields, unless it
+// already has one.
+func NewContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, fieldsKey{}, &fields{})
+}
+
+// Add adds key-value pairs to every later log line written with ctx. It
+// does nothing if ctx has no log fields.
+func Add(ctx context.Context, args ...any) {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return
+	}
+	record := slog.Record{}
+	record.Add(args...)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record.Attrs(func(attr slog.Attr) bool {
+		f.attrs = append(f.attrs, attr)
+		return true
+	})
+}
+
+// contextHandler adds the request's log fields and trace id to records
+// logged with a context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.mu.Lock()
+		record.AddAttrs(f.attrs...)
+		f.mu.Unlock()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
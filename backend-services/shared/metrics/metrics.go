@@ -0,0 +1,60 @@
+// Package metrics records the HTTP and database metrics every service
+// exposes to Prometheus. Services register their own business metrics
+// alongside.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"shared/middleware"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	duration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by route template, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+)
+
+// RegisterDB exposes the connection pool stats of db, labelled with the
+// service's name.
+func RegisterDB(db *sql.DB, service string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, service))
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the count and latency of each request, labelled by
+// the route routes gives it so that ids in paths do not multiply series.
+func Middleware(routes middleware.RouteTemplates) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := middleware.NewResponseRecorder(w)
+
+			next.ServeHTTP(sw, r)
+
+			route := middleware.Route(routes, r)
+			status := strconv.Itoa(sw.Status)
+			requests.WithLabelValues(route, r.Method, status).Inc()
+			duration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
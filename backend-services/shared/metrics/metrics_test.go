@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"shared/middleware"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Middleware(middleware.MuxRoutes))
+	router.HandleFunc("/api/orders/{orderId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/api/orders/1", "/api/orders/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(requests.WithLabelValues("/api/orders/{orderId}", "GET", "404")); got != 2 {
+		t.Errorf("requests = %v, want 2", got)
+	}
+}
+
+type prefixRoutes map[string]string
+
+func (p prefixRoutes) RouteTemplate(method, path string) string {
+	for prefix, template := range p {
+		if strings.HasPrefix(path, prefix) {
+			return template
+		}
+	}
+	return ""
+}
+
+func TestMiddlewareLabelsByNamedRoute(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Middleware(prefixRoutes{"/api/stores/": "/api/stores/*/products"}))
+	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	router.PathPrefix("/api").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	for _, path := range []string{"/api/stores/1/products", "/api/stores/2/products", "/health"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	if got := testutil.ToFloat64(requests.WithLabelValues("/api/stores/*/products", "POST", "201")); got != 2 {
+		t.Errorf("proxied requests = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(requests.WithLabelValues("/health", "POST", "200")); got != 1 {
+		t.Errorf("health requests = %v, want 1", got)
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// LoggingMiddleware writes an access log line for each request. Only the
+// path is logged: query strings may carry personal data.
+func LoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := NewResponseRecorder(w)
+
+		next.ServeHTTP(rw, r)
+
+		slog.InfoContext(r.Context(), "request",
+			"method", r.Method,
+			"route", RouteTemplate(r),
+			"path", r.URL.Path,
+			"status", rw.Status,
+			"bytes", rw.Bytes,
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
+
+// RouteTemplate returns the template of the mux route r matched, such as
+// "/api/orders/{orderId}", or "unmatched".
+func RouteTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+// RouteTemplates names the route serving a request, so that paths are
+// labelled by route rather than by their ids. The gateway names requests
+// by its configured routes.
+type RouteTemplates interface {
+	RouteTemplate(method, path string) string
+}
+
+// MuxRoutes names requests by the mux route they matched.
+var MuxRoutes RouteTemplates = muxRoutes{}
+
+type muxRoutes struct{}
+
+func (muxRoutes) RouteTemplate(method, path string) string { return "" }
+
+// Route returns the template routes gives r, falling back to the template
+// of the mux route r matched.
+func Route(routes RouteTemplates, r *http.Request) string {
+	if route := routes.RouteTemplate(r.Method, r.URL.Path); route != "" {
+		return route
+	}
+	return RouteTemplate(r)
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"shared/logging"
+)
+
+// HeaderRequestID carries the id linking a request's log lines across the
+// gateway and services.
+const HeaderRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestIDMiddleware keeps the request's X-Request-ID if it is well formed
+// and generates one otherwise, e.g. for clients of the gateway or calls
+// between services. The id is set on the request, so the gateway forwards
+// it upstream, and on the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		r.Header.Set(HeaderRequestID, id)
+		w.Header().Set(HeaderRequestID, id)
+
+		ctx := logging.NewContext(context.WithValue(r.Context(), requestIDKey{}, id))
+		logging.Add(ctx, "request_id", id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestID returns the id RequestIDMiddleware assigned to the request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts up to 128 letters, digits and -_.: so that ids
+// are safe to copy into logs and headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(HeaderRequestID)
+		if RequestID(r.Context()) != seen {
+			t.Errorf("context id %q != header id %q", RequestID(r.Context()), seen)
+		}
+	}))
+
+	tests := []struct {
+		name, sent string
+		kept       bool
+	}{
+		{"missing", "", false},
+		{"valid", "abc-123", true},
+		{"invalid", "bad id\n", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.sent != "" {
+			req.Header.Set(HeaderRequestID, tt.sent)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if seen == "" || rec.Header().Get(HeaderRequestID) != seen {
+			t.Errorf("%s: forwarded %q, responded %q", tt.name, seen, rec.Header().Get(HeaderRequestID))
+		}
+		if (seen == tt.sent) != tt.kept {
+			t.Errorf("%s: id = %q, kept = %v, want %v", tt.name, seen, seen == tt.sent, tt.kept)
+		}
+	}
+}
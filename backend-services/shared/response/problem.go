@@ -0,0 +1,104 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Code identifies an error for clients. Codes are stable; messages may
+// change.
+type Code string
+
+// Codes shared by every service. Services add their own for errors
+// clients handle specially.
+const (
+	CodeBadRequest      Code = "bad_request"
+	CodeUnauthorized    Code = "unauthorized"
+	CodeForbidden       Code = "forbidden"
+	CodeNotFound        Code = "not_found"
+	CodeConflict        Code = "conflict"
+	CodePayloadTooLarge Code = "payload_too_large"
+	CodeRateLimited     Code = "rate_limited"
+	CodeInternal        Code = "internal_error"
+	CodeBadGateway      Code = "bad_gateway"
+	CodeUnavailable     Code = "service_unavailable"
+	CodeTimeout         Code = "gateway_timeout"
+)
+
+// CodeForStatus returns the default code for an HTTP status.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway:
+		return CodeBadGateway
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Problem is an RFC 7807 problem details object, extended with a code and
+// the request id. It is also an error, so it can be returned up to the
+// handler that writes it.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Code      Code   `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Extensions are written as additional members of the object.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// NewProblem returns a problem with the given status, code and detail.
+func NewProblem(status int, code Code, detail string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail, Code: code}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return string(p.Code) + ": " + p.Detail
+	}
+	return string(p.Code)
+}
+
+// MarshalJSON adds the extensions to the standard members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	data, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	members := make(map[string]interface{}, len(p.Extensions))
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	var standard map[string]interface{}
+	if err := json.Unmarshal(data, &standard); err != nil {
+		return nil, err
+	}
+	// Extensions never replace the standard members
+	for k, v := range standard {
+		members[k] = v
+	}
+	return json.Marshal(members)
+}
@@ -0,0 +1,53 @@
+// Package response writes JSON responses. Successful responses wrap their
+// payload in an Envelope; errors are RFC 7807 problem details with a
+// machine-readable code, so clients can handle every error the same way.
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ProblemContentType is the media type of problem details.
+const ProblemContentType = "application/problem+json"
+
+// headerRequestID is set on the response by the request id middleware of
+// the gateway and the services.
+const headerRequestID = "X-Request-ID"
+
+// Envelope is the body of every successful JSON response.
+type Envelope struct {
+	Data interface{} `json:"data"`
+}
+
+// JSON writes data in the success envelope with the given status.
+func JSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Envelope{Data: data})
+}
+
+// Error writes a problem with the given status, the status's default code
+// and message as its detail.
+func Error(w http.ResponseWriter, status int, message string) {
+	WriteError(w, NewProblem(status, CodeForStatus(status), message))
+}
+
+// WriteError writes err as problem details. Errors other than a *Problem
+// are reported as internal errors without their message, which may hold
+// details clients should not see.
+func WriteError(w http.ResponseWriter, err error) {
+	var problem *Problem
+	if !errors.As(err, &problem) {
+		problem = NewProblem(http.StatusInternalServerError, CodeInternal, "Internal server error")
+	}
+
+	body := *problem
+	if body.RequestID == "" {
+		body.RequestID = w.Header().Get(headerRequestID)
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(body.Status)
+	json.NewEncoder(w).Encode(&body)
+}
@@ -0,0 +1,91 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONWrapsDataInEnvelope(t *testing.T) {
+	rec := httptest.NewRecorder()
+	JSON(rec, http.StatusCreated, map[string]string{"id": "p1"})
+
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if got := rec.Body.String(); got != `{"data":{"id":"p1"}}`+"\n" {
+		t.Errorf("body = %s", got)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		write      func(w http.ResponseWriter)
+		wantStatus int
+		wantCode   Code
+		wantDetail string
+	}{
+		{
+			name:       "default code for status",
+			write:      func(w http.ResponseWriter) { Error(w, http.StatusNotFound, "Product not found") },
+			wantStatus: http.StatusNotFound,
+			wantCode:   CodeNotFound,
+			wantDetail: "Product not found",
+		},
+		{
+			name: "wrapped problem",
+			write: func(w http.ResponseWriter) {
+				WriteError(w, fmt.Errorf("create: %w", NewProblem(http.StatusConflict, "store_exists", "Store exists")))
+			},
+			wantStatus: http.StatusConflict,
+			wantCode:   "store_exists",
+			wantDetail: "Store exists",
+		},
+		{
+			name:       "other errors are hidden",
+			write:      func(w http.ResponseWriter) { WriteError(w, errors.New("pq: connection refused")) },
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   CodeInternal,
+			wantDetail: "Internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("X-Request-ID", "req-1")
+			tt.write(rec)
+
+			var got Problem
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.wantStatus || rec.Header().Get("Content-Type") != ProblemContentType {
+				t.Errorf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+			}
+			want := Problem{Type: "about:blank", Title: http.StatusText(tt.wantStatus), Status: tt.wantStatus, Detail: tt.wantDetail, Code: tt.wantCode, RequestID: "req-1"}
+			if got.Type != want.Type || got.Title != want.Title || got.Status != want.Status || got.Detail != want.Detail || got.Code != want.Code || got.RequestID != want.RequestID {
+				t.Errorf("problem = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestProblemExtensions(t *testing.T) {
+	problem := NewProblem(http.StatusServiceUnavailable, "circuit_open", "Try again later")
+	problem.Extensions = map[string]interface{}{"retry_after": 5, "status": 200}
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	json.Unmarshal(data, &got)
+	if got["retry_after"] != float64(5) || got["status"] != float64(503) || got["code"] != "circuit_open" {
+		t.Errorf("problem = %s", data)
+	}
+}
@@ -0,0 +1,48 @@
+// Package server runs an HTTP server until the process is told to stop.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ShutdownTimeout bounds how long in-flight requests may take to finish
+// once a stop signal arrives.
+const ShutdownTimeout = 5 * time.Second
+
+// Run serves handler on addr until SIGINT or SIGTERM, then shuts down
+// gracefully. It returns an error if the server could not start.
+func Run(addr string, handler http.Handler) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return Serve(ctx, &http.Server{Addr: addr, Handler: handler})
+}
+
+// Serve runs server until ctx is cancelled, then shuts it down.
+func Serve(ctx context.Context, server *http.Server) error {
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "addr", server.Addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
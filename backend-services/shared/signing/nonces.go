@@ -0,0 +1,59 @@
+package signing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// nonceCache remembers nonces in memory until their signatures can no
+// longer be accepted.
+type nonceCache struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func newNonceCache(now func() time.Time) *nonceCache {
+	return &nonceCache{now: now, seen: make(map[string]time.Time)}
+}
+
+func (c *nonceCache) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastSweep) > MaxSkew {
+		for n, expires := range c.seen {
+			if now.After(expires) {
+				delete(c.seen, n)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if expires, ok := c.seen[nonce]; ok && !now.After(expires) {
+		return false, nil
+	}
+	c.seen[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// RedisNonceStore shares nonces between service replicas through any
+// server speaking the Redis protocol, so a request replayed to another
+// replica is still rejected.
+type RedisNonceStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+func NewRedisNonceStore(client redis.Cmdable, prefix string) *RedisNonceStore {
+	return &RedisNonceStore{client: client, prefix: prefix}
+}
+
+func (s *RedisNonceStore) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+nonce, 1, ttl).Result()
+}
@@ -0,0 +1,247 @@
+package signing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	oldKey = Key{ID: "2024", Secret: []byte(strings.Repeat("a", 32))}
+	newKey = Key{ID: "2025", Secret: []byte(strings.Repeat("b", 32))}
+)
+
+func signedRequest(t *testing.T, signer *Signer, method, target, body string) *http.Request {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	data, err := ReadBody(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.Sign(r, r.URL.RequestURI(), data)
+	return r
+}
+
+func TestVerify(t *testing.T) {
+	v, err := NewVerifier([]Key{oldKey, newKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewSigner(newKey)
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		want    error
+	}{
+		{"valid", func() *http.Request {
+			return signedRequest(t, signer, http.MethodPost, "/api/orders?x=1", `{"total":1}`)
+		}, nil},
+		{"old key during rotation", func() *http.Request {
+			return signedRequest(t, NewSigner(oldKey), http.MethodGet, "/api/orders", "")
+		}, nil},
+		{"unsigned", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+		}, errUnsigned},
+		{"retired key", func() *http.Request {
+			return signedRequest(t, NewSigner(Key{ID: "2023", Secret: []byte(strings.Repeat("c", 32))}), http.MethodGet, "/", "")
+		}, errUnknownKey},
+		{"forged with known key id", func() *http.Request {
+			return signedRequest(t, NewSigner(Key{ID: newKey.ID, Secret: []byte(strings.Repeat("z", 32))}), http.MethodGet, "/", "")
+		}, errBadSignature},
+		{"body tampered", func() *http.Request {
+			r := signedRequest(t, signer, http.MethodPost, "/api/orders", `{"total":1}`)
+			r.Body = io.NopCloser(strings.NewReader(`{"total":0}`))
+			return r
+		}, errBadSignature},
+		{"path tampered", func() *http.Request {
+			r := signedRequest(t, signer, http.MethodDelete, "/api/stores/1", "")
+			r.URL.Path = "/api/stores/2"
+			return r
+		}, errBadSignature},
+		{"query tampered", func() *http.Request {
+			r := signedRequest(t, signer, http.MethodGet, "/api/orders?user=1", "")
+			r.URL.RawQuery = "user=2"
+			return r
+		}, errBadSignature},
+		{"method tampered", func() *http.Request {
+			r := signedRequest(t, signer, http.MethodGet, "/api/stores/1", "")
+			r.Method = http.MethodDelete
+			return r
+		}, errBadSignature},
+		{"identity tampered", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+			r.Header.Set("X-User-ID", "alice")
+			signer.Sign(r, r.URL.RequestURI(), nil)
+			r.Header.Set("X-User-ID", "mallory")
+			return r
+		}, errBadSignature},
+		{"identity added", func() *http.Request {
+			r := signedRequest(t, signer, http.MethodGet, "/api/orders", "")
+			r.Header.Set("X-User-Role", "admin")
+			return r
+		}, errBadSignature},
+		{"stale", func() *http.Request {
+			old := NewSigner(newKey)
+			old.now = func() time.Time { return time.Now().Add(-MaxSkew - time.Minute) }
+			return signedRequest(t, old, http.MethodGet, "/", "")
+		}, errStale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := v.Verify(tt.request()); err != tt.want {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsReplay(t *testing.T) {
+	v, _ := NewVerifier([]Key{newKey})
+	r := signedRequest(t, NewSigner(newKey), http.MethodPost, "/api/orders", `{"total":1}`)
+	replay := r.Clone(r.Context())
+	replay.Body = io.NopCloser(strings.NewReader(`{"total":1}`))
+
+	if err := v.Verify(r); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+	if err := v.Verify(replay); err != errReplayed {
+		t.Fatalf("replayed Verify = %v, want %v", err, errReplayed)
+	}
+}
+
+func TestVerifyRejectsReplayToAnotherReplica(t *testing.T) {
+	srv := miniredis.RunT(t)
+	newReplica := func() *Verifier {
+		client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+		t.Cleanup(func() { client.Close() })
+		v, _ := NewVerifier([]Key{newKey})
+		return v.StoreNonces(NewRedisNonceStore(client, "signing:nonce:"))
+	}
+	a, b := newReplica(), newReplica()
+	r := signedRequest(t, NewSigner(newKey), http.MethodGet, "/api/orders", "")
+
+	if err := a.Verify(r); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+	if err := b.Verify(r.Clone(r.Context())); err != errReplayed {
+		t.Fatalf("Verify on another replica = %v, want %v", err, errReplayed)
+	}
+	if ttl := srv.TTL("signing:nonce:" + newKey.ID + ":" + r.Header.Get(HeaderNonce)); ttl != nonceTTL {
+		t.Errorf("nonce TTL = %v, want %v", ttl, nonceTTL)
+	}
+}
+
+type failingNonces struct{}
+
+func (failingNonces) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestVerifyKeepsBodyReadable(t *testing.T) {
+	v, _ := NewVerifier([]Key{newKey})
+	r := signedRequest(t, NewSigner(newKey), http.MethodPost, "/", "payload")
+
+	if err := v.Verify(r); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != "payload" {
+		t.Errorf("body = %q after Verify", body)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	v, _ := NewVerifier([]Key{newKey})
+	var verified bool
+	h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verified = Verified(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned: status = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest(t, NewSigner(newKey), http.MethodGet, "/", ""))
+	if rec.Code != http.StatusOK || !verified {
+		t.Errorf("signed: status = %d, verified %v; want 200 and verified", rec.Code, verified)
+	}
+
+	v.StoreNonces(failingNonces{})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest(t, NewSigner(newKey), http.MethodGet, "/", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("nonce store down: status = %d, want 503", rec.Code)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	secret := strings.Repeat("s", 32)
+	tests := []struct {
+		in      string
+		wantIDs int
+		wantErr bool
+	}{
+		{"", 0, true},
+		{"k1:" + secret, 1, false},
+		{"k1:" + secret + ", k2:" + secret + ":with:colons", 2, false},
+		{"k1:short", 0, true},
+		{secret, 0, true},
+		{"k1:" + secret + ",k1:" + secret, 0, true},
+	}
+	for _, tt := range tests {
+		keys, err := ParseKeys(tt.in)
+		if (err != nil) != tt.wantErr || len(keys) != tt.wantIDs {
+			t.Errorf("ParseKeys(%q) = %d keys, %v", tt.in, len(keys), err)
+		}
+	}
+}
+
+func TestFromEnvRequiresKeys(t *testing.T) {
+	t.Setenv("SERVICE_SIGNING_KEYS", "")
+	if _, err := NewVerifierFromEnv(); err == nil {
+		t.Error("NewVerifierFromEnv succeeded without keys")
+	}
+	if _, err := NewSignerFromEnv(); err == nil {
+		t.Error("NewSignerFromEnv succeeded without keys")
+	}
+
+	t.Setenv("SERVICE_SIGNING_KEYS", "a:"+strings.Repeat("a", 32)+",b:"+strings.Repeat("b", 32))
+	t.Setenv("SERVICE_SIGNING_KEY_ID", "b")
+	signer, err := NewSignerFromEnv()
+	if err != nil || signer.key.ID != "b" {
+		t.Errorf("NewSignerFromEnv = %+v, %v; want key b", signer, err)
+	}
+	t.Setenv("SERVICE_SIGNING_KEY_ID", "c")
+	if _, err := NewSignerFromEnv(); err == nil {
+		t.Error("NewSignerFromEnv accepted an unknown key id")
+	}
+
+	t.Setenv("SIGNING_NONCE_REDIS_URL", "localhost:6379")
+	if _, err := NewVerifierFromEnv(); err == nil {
+		t.Error("NewVerifierFromEnv accepted an invalid SIGNING_NONCE_REDIS_URL")
+	}
+	t.Setenv("SIGNING_NONCE_REDIS_URL", "redis://localhost:6379/0")
+	if v, err := NewVerifierFromEnv(); err != nil {
+		t.Errorf("NewVerifierFromEnv = %v", err)
+	} else if _, ok := v.nonces.(*RedisNonceStore); !ok {
+		t.Errorf("nonces kept in %T, want Redis", v.nonces)
+	}
+}
@@ -0,0 +1,172 @@
+package signing
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"shared/response"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MaxSkew is how far a signature's timestamp may be from the verifier's
+// clock. Nonces are remembered for twice as long, so a captured request
+// cannot be replayed while its timestamp is still accepted.
+const MaxSkew = 5 * time.Minute
+
+var (
+	errUnsigned     = errors.New("request is not signed")
+	errUnknownKey   = errors.New("unknown signing key")
+	errStale        = errors.New("signature timestamp outside the allowed window")
+	errReplayed     = errors.New("nonce already used")
+	errBadSignature = errors.New("signature mismatch")
+	errNonceStore   = errors.New("nonce store unavailable")
+)
+
+// nonceTTL is how long a nonce is remembered: long enough that a captured
+// request cannot be replayed while its timestamp is still accepted.
+const nonceTTL = 2 * MaxSkew
+
+// NonceStore records the nonces of verified requests.
+type NonceStore interface {
+	// Add records nonce for ttl and reports whether it was new.
+	Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// Verifier checks request signatures against every active key.
+type Verifier struct {
+	keys   map[string][]byte
+	now    func() time.Time
+	nonces NonceStore
+}
+
+// NewVerifier returns a Verifier accepting signatures made with any of
+// keys. It fails if keys is empty. Nonces are kept in memory, so replays
+// are only caught by the replica that saw the original request; use
+// StoreNonces to share them between replicas.
+func NewVerifier(keys []Key) (*Verifier, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys configured")
+	}
+	v := &Verifier{keys: make(map[string][]byte), now: time.Now}
+	v.nonces = newNonceCache(func() time.Time { return v.now() })
+	for _, key := range keys {
+		v.keys[key.ID] = key.Secret
+	}
+	return v, nil
+}
+
+// StoreNonces keeps nonces in store instead of memory.
+func (v *Verifier) StoreNonces(store NonceStore) *Verifier {
+	v.nonces = store
+	return v
+}
+
+// NewVerifierFromEnv accepts every key in SERVICE_SIGNING_KEYS. Nonces are
+// shared through Redis when SIGNING_NONCE_REDIS_URL is set, which should
+// name the server the gateway's rate limiter uses.
+func NewVerifierFromEnv() (*Verifier, error) {
+	keys, err := keysFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	v, err := NewVerifier(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	redisURL := os.Getenv("SIGNING_NONCE_REDIS_URL")
+	if redisURL == "" {
+		slog.Warn("SIGNING_NONCE_REDIS_URL not set; replay protection is per replica")
+		return v, nil
+	}
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIGNING_NONCE_REDIS_URL: %w", err)
+	}
+	return v.StoreNonces(NewRedisNonceStore(redis.NewClient(opts), "signing:nonce:")), nil
+}
+
+// Verify checks r's signature, leaving its body readable.
+func (v *Verifier) Verify(r *http.Request) error {
+	keyID := r.Header.Get(HeaderKeyID)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	sig := r.Header.Get(HeaderSignature)
+	if keyID == "" || timestamp == "" || nonce == "" || sig == "" {
+		return errUnsigned
+	}
+
+	secret, ok := v.keys[keyID]
+	if !ok {
+		return errUnknownKey
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errStale
+	}
+	now := v.now()
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > MaxSkew || age < -MaxSkew {
+		return errStale
+	}
+
+	body, err := ReadBody(r)
+	if err != nil {
+		return err
+	}
+	expected := signature(secret, r, r.URL.RequestURI(), timestamp, nonce, body)
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return errBadSignature
+	}
+
+	// Only verified nonces are recorded, so forged requests cannot burn
+	// nonces the gateway has yet to use.
+	fresh, err := v.nonces.Add(r.Context(), keyID+":"+nonce, nonceTTL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNonceStore, err)
+	}
+	if !fresh {
+		return errReplayed
+	}
+	return nil
+}
+
+// Middleware rejects requests that were not signed by the gateway or
+// another service.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			if errors.Is(err, ErrBodyTooLarge) {
+				response.Error(w, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			if errors.Is(err, errNonceStore) {
+				slog.ErrorContext(r.Context(), "Cannot check request for replay", "error", err)
+				response.Error(w, http.StatusServiceUnavailable, "Service temporarily unavailable")
+				return
+			}
+			slog.WarnContext(r.Context(), "Rejected unsigned or invalid request", "error", err)
+			response.Error(w, http.StatusUnauthorized, "Unauthorized: Direct access not allowed")
+			return
+		}
+		ctx := context.WithValue(r.Context(), verifiedKey{}, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type verifiedKey struct{}
+
+// Verified reports whether the request carrying ctx passed Middleware, so
+// that the headers its signature covers can be trusted.
+func Verified(ctx context.Context) bool {
+	verified, _ := ctx.Value(verifiedKey{}).(bool)
+	return verified
+}
@@ -0,0 +1,124 @@
+// Package stores resolves which stores a caller may act for.
+package stores
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"shared/auth"
+	"shared/client"
+	"shared/signing"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long a user's stores are cached.
+const DefaultTTL = time.Minute
+
+// Resolver looks up the stores a user owns in store-management. Store
+// staff are tied to one store by the store_id claim issued at login, which
+// also takes precedence for owners.
+type Resolver struct {
+	client *client.Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	cache     map[string]cachedStores
+	lastSweep time.Time
+}
+
+type cachedStores struct {
+	ids     []string
+	expires time.Time
+}
+
+// NewResolver returns a Resolver querying store-management through c.
+func NewResolver(c *client.Client, ttl time.Duration) *Resolver {
+	return &Resolver{client: c, ttl: ttl, now: time.Now, cache: make(map[string]cachedStores)}
+}
+
+// NewResolverFromEnv queries store-management at STORE_SERVICE_URL,
+// signing requests as configured by signing.NewSignerFromEnv.
+func NewResolverFromEnv() (*Resolver, error) {
+	baseURL := os.Getenv("STORE_SERVICE_URL")
+	if baseURL == "" {
+		return nil, fmt.Errorf("STORE_SERVICE_URL environment variable not set")
+	}
+	signer, err := signing.NewSignerFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewResolver(client.New(baseURL, signer), DefaultTTL), nil
+}
+
+// StoreIDs returns the ids of the stores the caller owns or staffs.
+func (r *Resolver) StoreIDs(ctx context.Context, claims auth.Claims) ([]string, error) {
+	if claims.StoreID != "" {
+		return []string{claims.StoreID}, nil
+	}
+
+	r.mu.Lock()
+	cached, ok := r.cache[claims.ID]
+	r.mu.Unlock()
+	if ok && r.now().Before(cached.expires) {
+		return cached.ids, nil
+	}
+
+	var body struct {
+		StoreIDs []string `json:"store_ids"`
+	}
+	if err := r.client.GetJSON(ctx, "/internal/users/"+url.PathEscape(claims.ID)+"/stores", &body); err != nil {
+		return nil, fmt.Errorf("failed to resolve stores: %w", err)
+	}
+
+	// A user with no store yet may create one at any moment, so only
+	// found stores are cached.
+	if len(body.StoreIDs) > 0 {
+		r.store(claims.ID, body.StoreIDs)
+	}
+	return body.StoreIDs, nil
+}
+
+// store caches ids for userID. Expired entries are swept at most once per
+// ttl, so users who stop calling do not stay in memory.
+func (r *Resolver) store(userID string, ids []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Sub(r.lastSweep) > r.ttl {
+		for id, cached := range r.cache {
+			if !now.Before(cached.expires) {
+				delete(r.cache, id)
+			}
+		}
+		r.lastSweep = now
+	}
+	r.cache[userID] = cachedStores{ids: ids, expires: now.Add(r.ttl)}
+}
+
+// IsStoreOwner reports whether the user owns a store, for
+// auth.Middleware.PromoteStoreOwners.
+func (r *Resolver) IsStoreOwner(ctx context.Context, userID string) (bool, error) {
+	ids, err := r.StoreIDs(ctx, auth.Claims{ID: userID})
+	return len(ids) > 0, err
+}
+
+// CanManage reports whether the caller may act for storeID.
+func (r *Resolver) CanManage(ctx context.Context, claims auth.Claims, storeID string) (bool, error) {
+	if claims.Can(auth.PermStoreManageAny) {
+		return true, nil
+	}
+	ids, err := r.StoreIDs(ctx, claims)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id == storeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
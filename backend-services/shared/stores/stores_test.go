@@ -0,0 +1,142 @@
+package stores
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"shared/auth"
+	"shared/client"
+	"shared/response"
+	"shared/signing"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testKey = signing.Key{ID: "test", Secret: []byte(strings.Repeat("k", 32))}
+
+func newTestResolver(t *testing.T, owned map[string][]string) (*Resolver, *atomic.Int32) {
+	t.Helper()
+
+	verifier, err := signing.NewVerifier([]signing.Key{testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if err := verifier.Verify(r); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var userID string
+		for id := range owned {
+			if r.URL.Path == "/internal/users/"+id+"/stores" {
+				userID = id
+			}
+		}
+		ids := owned[userID]
+		if ids == nil {
+			ids = []string{}
+		}
+		response.JSON(w, http.StatusOK, map[string][]string{"store_ids": ids})
+	}))
+	t.Cleanup(server.Close)
+
+	return NewResolver(client.New(server.URL, signing.NewSigner(testKey)), time.Minute), &calls
+}
+
+func TestCanManage(t *testing.T) {
+	r, _ := newTestResolver(t, map[string][]string{"owner": {"s1", "s2"}})
+
+	tests := []struct {
+		name    string
+		claims  auth.Claims
+		storeID string
+		want    bool
+	}{
+		{"own store", auth.Claims{ID: "owner", Role: auth.RoleStoreOwner}, "s2", true},
+		{"other store", auth.Claims{ID: "owner", Role: auth.RoleStoreOwner}, "s3", false},
+		{"user id is not a store id", auth.Claims{ID: "owner", Role: auth.RoleStoreOwner}, "owner", false},
+		{"staff claim", auth.Claims{ID: "staff", Role: auth.RoleStoreStaff, StoreID: "s3"}, "s3", true},
+		{"staff elsewhere", auth.Claims{ID: "staff", Role: auth.RoleStoreStaff, StoreID: "s3"}, "s1", false},
+		{"no stores", auth.Claims{ID: "nobody", Role: auth.RoleStoreOwner}, "s1", false},
+		{"admin", auth.Claims{ID: "root", Role: auth.RoleAdmin}, "s9", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.CanManage(context.Background(), tt.claims, tt.storeID)
+			if err != nil {
+				t.Fatalf("CanManage: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanManage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsStoreOwner(t *testing.T) {
+	r, _ := newTestResolver(t, map[string][]string{"owner": {"s1"}})
+
+	for user, want := range map[string]bool{"owner": true, "nobody": false} {
+		if got, err := r.IsStoreOwner(context.Background(), user); got != want || err != nil {
+			t.Errorf("IsStoreOwner(%s) = %v, %v, want %v", user, got, err, want)
+		}
+	}
+}
+
+func TestStoreIDsCaching(t *testing.T) {
+	r, calls := newTestResolver(t, map[string][]string{"owner": {"s1"}})
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	owner := auth.Claims{ID: "owner", Role: auth.RoleStoreOwner}
+	nobody := auth.Claims{ID: "nobody", Role: auth.RoleStoreOwner}
+
+	for i := 0; i < 3; i++ {
+		r.StoreIDs(context.Background(), owner)
+		r.StoreIDs(context.Background(), nobody)
+	}
+	// Owners are cached; users without stores are looked up every time.
+	if got := calls.Load(); got != 4 {
+		t.Errorf("calls = %d, want 4", got)
+	}
+
+	now = now.Add(time.Minute)
+	r.StoreIDs(context.Background(), owner)
+	if got := calls.Load(); got != 5 {
+		t.Errorf("calls after expiry = %d, want 5", got)
+	}
+}
+
+func TestStoreIDsSweepsExpiredEntries(t *testing.T) {
+	r, _ := newTestResolver(t, map[string][]string{"alice": {"s1"}, "bob": {"s2"}})
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	r.StoreIDs(context.Background(), auth.Claims{ID: "alice"})
+	now = now.Add(2 * time.Minute)
+	r.StoreIDs(context.Background(), auth.Claims{ID: "bob"})
+
+	if _, ok := r.cache["alice"]; ok || len(r.cache) != 1 {
+		t.Errorf("cache = %v, want only bob", r.cache)
+	}
+}
+
+func TestStoreIDsServiceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	r := NewResolver(client.New(server.URL, signing.NewSigner(testKey)), time.Minute)
+
+	if _, err := r.StoreIDs(context.Background(), auth.Claims{ID: "owner"}); err == nil {
+		t.Fatal("StoreIDs succeeded on a failed request")
+	}
+	ok, err := r.CanManage(context.Background(), auth.Claims{ID: "owner"}, "s1")
+	if err == nil || ok {
+		t.Fatalf("CanManage = %v, %v; want an error", ok, err)
+	}
+}
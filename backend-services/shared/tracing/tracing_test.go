@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"shared/middleware"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type prefixRoutes string
+
+func (p prefixRoutes) RouteTemplate(method, path string) string { return string(p) }
+
+func TestMiddlewareNamesSpansByRoute(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	tests := []struct {
+		name   string
+		routes middleware.RouteTemplates
+		want   string
+	}{
+		{"mux route", middleware.MuxRoutes, "GET /api/orders/{orderId}"},
+		{"named route", prefixRoutes("/api/orders"), "GET /api/orders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			router := mux.NewRouter()
+			router.Use(Middleware(tt.routes))
+			router.HandleFunc("/api/orders/{orderId}", func(w http.ResponseWriter, r *http.Request) {})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/orders/1", nil))
+
+			spans := recorder.Ended()
+			if len(spans) != 1 || spans[0].Name() != tt.want {
+				t.Fatalf("spans = %v, want one named %q", spans, tt.want)
+			}
+		})
+	}
+}